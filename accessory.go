@@ -0,0 +1,77 @@
+package govatar
+
+import "math/rand"
+
+// accessoryLayers lists the optional layers known to every asset pack in
+// default drawing order, drawn over the person layers
+var accessoryLayers = []string{"earrings", "beard", "glasses", "hat"}
+
+// accessoryNone is the probability that a random avatar goes without the
+// accessory of a layer. Other extra layers are always drawn unless the
+// manifest of the pack says otherwise.
+var accessoryNone = map[string]float64{
+	"earrings": 0.7,
+	"beard":    0.6,
+	"glasses":  0.6,
+	"hat":      0.7,
+}
+
+// accessoriesDir is the directory of an asset pack holding accessories
+// shared by the genders, next to the gender directories
+const accessoriesDir = "accessories"
+
+// accessoryGenders lists the gender directories wearing a shared accessory
+// layer. Layers left out are worn by all of them.
+var accessoryGenders = map[string][]string{
+	"beard": {"male"},
+}
+
+// wearsAccessory reports whether the gender directory name wears the shared
+// accessory layer
+func wearsAccessory(name, layer string) bool {
+	genders, ok := accessoryGenders[layer]
+	return !ok || layerIndex(genders, name) >= 0
+}
+
+// none returns the probability that random avatars of p drawn from s go
+// without layer
+func (s *store) none(p person, layer string) float64 {
+	if p.shared[layer] && !s.accessories {
+		return 1
+	}
+	return p.none[layer]
+}
+
+// randomAccessories picks the parts of the extra layers of spec from those
+// of p. It draws from rnd after all other layers, so adding layers to an
+// asset pack keeps the other parts of every avatar. Shared accessories are
+// picked only if shared is set, without it they draw nothing from rnd.
+func randomAccessories(rnd *rand.Rand, p person, spec *Spec, shared bool) {
+	for _, layer := range p.extras {
+		if p.shared[layer] && !shared {
+			continue
+		}
+		if none := p.none[layer]; none > 0 && rnd.Float64() < none {
+			continue
+		}
+		spec.setAccessory(layer, p.randomPart(rnd, layer))
+	}
+}
+
+// setAccessory selects the part at index i of the extra layer, a negative index
+// leaves the layer out. The accessories of copies of s are left as they are.
+func (s *Spec) setAccessory(layer string, i int) {
+	accessories := make(map[string]int, len(s.Accessories)+1)
+	for l, index := range s.Accessories {
+		accessories[l] = index
+	}
+	if i < 0 {
+		delete(accessories, layer)
+	} else {
+		accessories[layer] = i
+	}
+	if len(accessories) == 0 {
+		accessories = nil
+	}
+	s.Accessories = accessories
+}
@@ -0,0 +1,175 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// accessorizedFS returns the monster artwork with a few hats and glasses
+func accessorizedFS(t *testing.T) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	err := fs.WalkDir(os.DirFS("data/monster"), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile("data/monster/" + name)
+		fsys[name] = &fstest.MapFile{Data: data}
+		return err
+	})
+	assert.NoError(t, err)
+	for layer, colors := range map[string][]color.Color{
+		"hat":     {color.RGBA{200, 0, 0, 255}, color.RGBA{0, 0, 200, 255}},
+		"glasses": {color.RGBA{20, 20, 20, 255}},
+	} {
+		for i, c := range colors {
+			img := image.NewRGBA(image.Rect(0, 0, assetSize, assetSize))
+			for y := 0; y < 60; y++ {
+				for x := 100; x < 300; x++ {
+					img.Set(x, y+i*80, c)
+				}
+			}
+			var buf bytes.Buffer
+			assert.NoError(t, png.Encode(&buf, img))
+			fsys[layer+"/"+layer+strconv.Itoa(i+1)+".png"] = &fstest.MapFile{Data: buf.Bytes()}
+		}
+	}
+	return fsys
+}
+
+func TestAccessories(t *testing.T) {
+	withStyles(t)
+	assert.NoError(t, Register("accessorized", accessorizedFS(t)))
+	gender, _ := LookupStyle("accessorized")
+
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		username := "user" + strconv.Itoa(i)
+		spec, err := SpecFromUsername(gender, username)
+		assert.NoError(t, err)
+		// Accessories keep the other parts of the same artwork
+		monster, err := SpecFromUsername(MONSTER, username)
+		assert.NoError(t, err)
+		base := spec
+		base.Gender, base.Accessories = MONSTER, nil
+		assert.Equal(t, monster, base)
+
+		for layer, index := range spec.Accessories {
+			counts[layer]++
+			assert.Contains(t, []string{"hat", "glasses"}, layer)
+			assert.True(t, index >= 0 && index < 2)
+		}
+		again, err := SpecFromUsername(gender, username)
+		assert.NoError(t, err)
+		assert.Equal(t, spec, again)
+	}
+	// Every layer is left out of some avatars but not all
+	assert.InDelta(t, 200*(1-accessoryNone["hat"]), counts["hat"], 30)
+	assert.InDelta(t, 200*(1-accessoryNone["glasses"]), counts["glasses"], 30)
+
+	spec := NewBuilder(gender).Accessory("glasses", 0).Accessory("hat", 1).Spec()
+	assets, err := std().store.specAssets(spec)
+	assert.NoError(t, err)
+	assert.Len(t, assets, 8)
+	assert.Equal(t, "style:accessorized/glasses/glasses1.png", assets[6])
+	assert.Equal(t, "style:accessorized/hat/hat2.png", assets[7])
+
+	img, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 200, 255}, img.At(200, 100))
+	bare, err := GenerateFromSpec(NewBuilder(gender).FromSpec(spec).Accessory("hat", -1).Spec())
+	assert.NoError(t, err)
+	assert.NotEqual(t, img.At(200, 100), bare.At(200, 100))
+	assert.Equal(t, map[string]int{"glasses": 0, "hat": 1}, spec.Accessories)
+
+	_, err = NewBuilder(gender).Accessory("hat", 2).Render()
+	assert.Equal(t, errInvalidSpec, err)
+	_, err = NewBuilder(gender).Accessory("tattoo", 0).Render()
+	assert.Equal(t, errInvalidSpec, err)
+	_, err = NewBuilder(MONSTER).Accessory("tattoo", 0).Render()
+	assert.Equal(t, errInvalidSpec, err)
+
+	// Specs and descriptors record accessories
+	s := FormatSpec(spec)
+	assert.Equal(t, "v1:accessorized:f0.c0.h0.e0.m0.b0.glasses0.hat1", s)
+	parsed, err := ParseSpec(s)
+	assert.NoError(t, err)
+	assert.Equal(t, spec, parsed)
+	for _, invalid := range []string{s + ".hat0", s + ".face1", s + ".hat"} {
+		_, err = ParseSpec(invalid)
+		assert.Equal(t, errInvalidSpec, err, invalid)
+	}
+
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	assert.Len(t, d.Layers, 8)
+	assert.Equal(t, DescriptorLayer{Name: "hat", Index: 1, File: "hat2.png"}, d.Layers[7])
+	described, err := RenderDescriptor(d)
+	assert.NoError(t, err)
+	assert.Equal(t, img, described)
+
+	c := GetCatalog()
+	layers := c.Genders[len(c.Genders)-1].Layers
+	assert.Equal(t, []CatalogLayer{{"glasses", 1}, {"hat", 2}}, layers[6:])
+}
+
+func TestAccessoriesFromDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.CopyFS(dir+"/male", accessorizedFS(t)))
+	for _, name := range []string{"background", "female", "monster"} {
+		assert.NoError(t, os.CopyFS(dir+"/"+name, os.DirFS("data/"+name)))
+	}
+
+	c := DefaultConfig()
+	c.AssetsPath = dir
+	g, err := New(c)
+	assert.NoError(t, err)
+	assert.Len(t, g.store.Male.assets("hat"), 2)
+	assert.Len(t, g.store.Neutral.assets("hat"), 2)
+	assert.Nil(t, g.store.Female.assets("hat"))
+}
+
+func TestSharedAccessories(t *testing.T) {
+	s := std().store
+	assert.Len(t, s.Male.assets("beard"), 2)
+	assert.Nil(t, s.Female.assets("beard"))
+	assert.Nil(t, s.Monster.assets("beard"))
+	// Neutral draws each shared hat once
+	assert.Equal(t, s.Male.assets("hat"), s.Neutral.assets("hat"))
+	assert.Equal(t, s.Female.assets("hat"), s.Neutral.assets("hat"))
+
+	// Random avatars go without them unless asked for
+	c := DefaultConfig()
+	c.Accessories = true
+	g, err := New(c)
+	assert.NoError(t, err)
+	worn := 0
+	for i := 0; i < 50; i++ {
+		username := "user" + strconv.Itoa(i)
+		plain, err := SpecFromUsername(FEMALE, username)
+		assert.NoError(t, err)
+		assert.Nil(t, plain.Accessories)
+		spec, err := g.SpecFromUsername(FEMALE, username)
+		assert.NoError(t, err)
+		worn += len(spec.Accessories)
+		assert.NotContains(t, spec.Accessories, "beard")
+		spec.Accessories = nil
+		assert.Equal(t, plain, spec)
+	}
+	assert.True(t, worn > 0)
+
+	// Explicit specs wear them either way
+	img, err := NewBuilder(MONSTER).Accessory("hat", 1).Render()
+	assert.NoError(t, err)
+	plain, err := NewBuilder(MONSTER).Render()
+	assert.NoError(t, err)
+	assert.False(t, areImagesEquals(img, plain))
+}
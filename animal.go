@@ -0,0 +1,15 @@
+//go:build !govatar_no_animal
+
+package govatar
+
+import "embed"
+
+//go:embed data/animal
+var animalAssets embed.FS
+
+// The animal style draws cats, dogs and foxes from artwork compiled into the
+// binary: heads with ears as faces, collars as clothes, muzzles as mouths
+// and fur patterns as hair. Leave it out with the govatar_no_animal build tag.
+func init() {
+	mustRegisterEmbedded("animal", animalAssets, "data/animal")
+}
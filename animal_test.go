@@ -0,0 +1,35 @@
+//go:build !govatar_no_animal
+
+package govatar
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnimal(t *testing.T) {
+	animal, ok := LookupStyle("animal")
+	assert.True(t, ok)
+	assert.Equal(t, errStyleExists, Register("animal", os.DirFS("data/animal")))
+
+	p, err := std().store.person(animal)
+	assert.NoError(t, err)
+	// Cats, dogs and foxes in three furs each
+	assert.Len(t, p.assets("face"), 9)
+	for _, parts := range [][]string{p.assets("clothes"), p.assets("mouth"), p.assets("hair"), p.assets("eye")} {
+		assert.NotEmpty(t, parts)
+	}
+
+	img, err := GenerateStyle("animal", "username@site.com", WithSize(64))
+	assert.NoError(t, err)
+	spec, err := SpecFromUsername(animal, "username@site.com")
+	assert.NoError(t, err)
+	expected, err := GenerateFromSpec(spec, WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+	styled, err := GenerateFromUsername(MALE, "username@site.com", WithSize(64))
+	assert.NoError(t, err)
+	assert.NotEqual(t, styled, img)
+}
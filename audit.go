@@ -0,0 +1,106 @@
+package govatar
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// AuditRecord lists the asset chosen for every layer of a username's avatar.
+// Layers left out of the avatar, e.g. by rules, are empty.
+type AuditRecord struct {
+	Username   string `json:"username"`
+	Gender     string `json:"gender"`
+	Background string `json:"background"`
+	Face       string `json:"face"`
+	Clothes    string `json:"clothes"`
+	Mouth      string `json:"mouth"`
+	Hair       string `json:"hair"`
+	Eye        string `json:"eye"`
+	// Accessories holds the assets of drawn extra and dependent layers by
+	// layer name
+	Accessories map[string]string `json:"accessories,omitempty"`
+}
+
+// Audit reports the parts chosen for every username without rendering avatars.
+// Users that look the same share all part names.
+func Audit(gender Gender, usernames []string) ([]AuditRecord, error) {
+	return std().Audit(gender, usernames)
+}
+
+// Audit reports the parts g chooses for every username without rendering
+// avatars
+func (g *Generator) Audit(gender Gender, usernames []string) ([]AuditRecord, error) {
+	records := make([]AuditRecord, 0, len(usernames))
+	for _, username := range usernames {
+		spec, err := g.SpecFromUsername(gender, username)
+		if err != nil {
+			return nil, err
+		}
+		layers, assets, err := g.store.drawnLayout(spec)
+		if err != nil {
+			return nil, err
+		}
+		r := AuditRecord{Username: username, Gender: genderName(gender)}
+		for i, layer := range layers {
+			name := filepath.Base(assets[i])
+			switch layer {
+			case "background":
+				r.Background = name
+			case "face":
+				r.Face = name
+			case "clothes":
+				r.Clothes = name
+			case "mouth":
+				r.Mouth = name
+			case "hair":
+				r.Hair = name
+			case "eye":
+				r.Eye = name
+			default:
+				if r.Accessories == nil {
+					r.Accessories = map[string]string{}
+				}
+				r.Accessories[layer] = name
+			}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// WriteAuditCSV writes records as CSV with a header row. Accessories follow
+// in a column per layer, sorted by name.
+func WriteAuditCSV(w io.Writer, records []AuditRecord) error {
+	var accessories []string
+	seen := map[string]bool{}
+	for _, r := range records {
+		for layer := range r.Accessories {
+			if !seen[layer] {
+				seen[layer] = true
+				accessories = append(accessories, layer)
+			}
+		}
+	}
+	sort.Strings(accessories)
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"username", "gender", "background", "face", "clothes", "mouth", "hair", "eye"}, accessories...))
+	for _, r := range records {
+		row := []string{r.Username, r.Gender, r.Background, r.Face, r.Clothes, r.Mouth, r.Hair, r.Eye}
+		for _, layer := range accessories {
+			row = append(row, r.Accessories[layer])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteAuditJSON writes records as JSON array
+func WriteAuditJSON(w io.Writer, records []AuditRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
@@ -0,0 +1,96 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	usernames := []string{"username@site.com", "username2@site.com"}
+	records, err := Audit(FEMALE, usernames)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	spec, err := SpecFromUsername(FEMALE, usernames[0])
+	assert.NoError(t, err)
+	layers, assets, err := std().store.drawnLayout(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "username@site.com", records[0].Username)
+	assert.Equal(t, "female", records[0].Gender)
+	assert.Equal(t, filepath.Base(assets[layerIndex(layers, "face")]), records[0].Face)
+	assert.Equal(t, filepath.Base(assets[layerIndex(layers, "hair")]), records[0].Hair)
+
+	_, err = Audit(Gender(-1), usernames)
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestWriteAudit(t *testing.T) {
+	records, err := Audit(MALE, []string{"a", "b", "c"})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteAuditCSV(&buf, records))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, []string{"username", "gender", "background", "face", "clothes", "mouth", "hair", "eye"}, rows[0][:8])
+	assert.Equal(t, []string{"b", "male", records[1].Background, records[1].Face, records[1].Clothes,
+		records[1].Mouth, records[1].Hair, records[1].Eye}, rows[2][:8])
+	for i, layer := range rows[0][8:] {
+		assert.Equal(t, records[1].Accessories[layer], rows[2][8+i])
+	}
+
+	buf.Reset()
+	assert.NoError(t, WriteAuditJSON(&buf, records))
+	var decoded []AuditRecord
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, records, decoded)
+}
+
+func TestAuditLayers(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"layers": ["hair", "face"], "optional": {"hat": 0, "glasses": 0}, "rules": [{"part": "hat:1", "excludes": ["glasses", "mouth"]}]}`)}
+	assert.NoError(t, Register("audited", fsys))
+	gender, _ := LookupStyle("audited")
+
+	usernames := make([]string, 20)
+	for i := range usernames {
+		usernames[i] = "user" + strconv.Itoa(i)
+	}
+	records, err := Audit(gender, usernames)
+	assert.NoError(t, err)
+	hats := 0
+	for i, r := range records {
+		spec, err := SpecFromUsername(gender, usernames[i])
+		assert.NoError(t, err)
+		// Parts follow layer names whatever the drawing order
+		assert.Equal(t, "hair"+strconv.Itoa(spec.Hair+1)+".png", r.Hair)
+		assert.Equal(t, "face"+strconv.Itoa(spec.Face+1)+".png", r.Face)
+		assert.Equal(t, "hat"+strconv.Itoa(spec.Accessories["hat"]+1)+".png", r.Accessories["hat"])
+		if spec.Accessories["hat"] == 1 {
+			hats++
+			// Parts left out by rules are not reported
+			assert.Empty(t, r.Mouth)
+			assert.NotContains(t, r.Accessories, "glasses")
+		} else {
+			assert.NotEmpty(t, r.Mouth)
+			assert.Equal(t, "glasses1.png", r.Accessories["glasses"])
+		}
+	}
+	assert.NotZero(t, hats)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteAuditCSV(&buf, records))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"username", "gender", "background", "face", "clothes", "mouth", "hair", "eye", "glasses", "hat"}, rows[0])
+	assert.Equal(t, records[0].Accessories["hat"], rows[1][9])
+}
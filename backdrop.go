@@ -0,0 +1,84 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+var errInvalidBackground = errors.New("Invalid background image")
+
+// WithBackgroundImages draws one of images behind the character instead of
+// the background artwork, so tenants can brand avatars without their own
+// asset store. Images are picked by a hash of the parts like
+// WithSolidBackground colors, and cropped to a centered square.
+func WithBackgroundImages(images ...image.Image) Option {
+	return func(o *options) {
+		o.background = false
+		o.transparent = false
+		o.palette = nil
+		o.backgrounds = images
+		if len(images) == 0 {
+			o.err = errInvalidBackground
+		}
+		for _, img := range images {
+			if img == nil || img.Bounds().Empty() {
+				o.err = errInvalidBackground
+			}
+		}
+	}
+}
+
+// LoadBackgrounds decodes the images at the top of fsys in natural order of
+// their names, for WithBackgroundImages. Load them once and reuse them, the
+// option does not read files.
+func LoadBackgrounds(fsys fs.FS) ([]image.Image, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && knownFormat(path.Ext(entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(naturalSort(names))
+	images := make([]image.Image, 0, len(names))
+	for _, name := range names {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	if len(images) == 0 {
+		return nil, ErrAssetsNotFound
+	}
+	return images, nil
+}
+
+// backdrop fills dst with what o draws behind the character of spec when
+// the background artwork is left out
+func (o options) backdrop(dst *image.RGBA, spec Spec) {
+	if len(o.backgrounds) == 0 {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(o.fill(spec)), image.Point{}, draw.Src)
+		return
+	}
+	src := o.backgrounds[pick(spec, len(o.backgrounds))]
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x, y := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	o.filter.scaler().Scale(dst, dst.Bounds(), src, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+}
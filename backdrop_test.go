@@ -0,0 +1,87 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testBackground returns an image of w by h filled with c
+func testBackground(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestWithBackgroundImages(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithBackgroundImages(testBackground(40, 40, red)))
+	assert.NoError(t, err)
+	assert.Equal(t, color.Color(red), img.At(0, 0))
+
+	// Wide images are cropped to their middle
+	wide := testBackground(120, 40, color.Black)
+	draw.Draw(wide, image.Rect(40, 0, 80, 40), image.NewUniform(red), image.Point{}, draw.Src)
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithFilter(NEAREST), WithBackgroundImages(wide))
+	assert.NoError(t, err)
+	assert.Equal(t, color.Color(red), img.At(0, 0))
+
+	// Images are picked like palette colors
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	spec, err := g.SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	want := []color.Color{red, blue}[pick(spec, 2)]
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithBackgroundImages(testBackground(4, 4, red), testBackground(4, 4, blue)))
+	assert.NoError(t, err)
+	assert.Equal(t, want, img.At(0, 0))
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	e, err := NewEditor(d, WithSize(100), WithBackgroundImages(testBackground(4, 4, red), testBackground(4, 4, blue)))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(img, e.Image()))
+
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithBackgroundImages(testBackground(4, 4, red)))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `<image width="40" height="40" preserveAspectRatio="xMidYMid slice" href="data:image/png;base64,`)
+
+	// The last background option wins
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithBackgroundImages(testBackground(4, 4, red)), WithoutBackground())
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), img.At(0, 0))
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithBackgroundImages())
+	assert.Equal(t, errInvalidBackground, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithBackgroundImages(nil))
+	assert.Equal(t, errInvalidBackground, err)
+}
+
+func TestLoadBackgrounds(t *testing.T) {
+	encode := func(c color.Color) []byte {
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, testBackground(2, 2, c)))
+		return buf.Bytes()
+	}
+	fsys := fstest.MapFS{
+		"tenant10.png": {Data: encode(color.Black)},
+		"tenant2.png":  {Data: encode(color.White)},
+		"notes.txt":    {Data: []byte("not an image")},
+	}
+	images, err := LoadBackgrounds(fsys)
+	assert.NoError(t, err)
+	assert.Len(t, images, 2)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), color.RGBAModel.Convert(images[0].At(0, 0)))
+
+	_, err = LoadBackgrounds(fstest.MapFS{})
+	assert.Equal(t, ErrAssetsNotFound, err)
+	fsys["broken.png"] = &fstest.MapFile{Data: []byte("no png")}
+	_, err = LoadBackgrounds(fsys)
+	assert.Error(t, err)
+}
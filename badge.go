@@ -0,0 +1,143 @@
+package govatar
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+var errInvalidBadge = errors.New("Invalid badge")
+
+// badgeScale is the part of the avatar width covered by badges
+const badgeScale = 0.3
+
+// badge is a dot stamped on a corner of the avatar
+type badge struct {
+	corner Corner
+	color  color.Color
+	// count is drawn in the dot when positive
+	count int
+	img   image.Image
+}
+
+// WithBadge stamps a dot of color on corner of the avatar, like the online
+// and away dots of chat apps. On circles the dot sits on the outline.
+func WithBadge(corner Corner, c color.Color) Option {
+	return withBadge(&badge{corner: corner, color: c}, c != nil)
+}
+
+// WithBadgeCount stamps a dot of color with count in it on corner of the
+// avatar, counts above 99 read 99+
+func WithBadgeCount(corner Corner, c color.Color, count int) Option {
+	return withBadge(&badge{corner: corner, color: c, count: count}, c != nil && count > 0)
+}
+
+// WithBadgeImage stamps img cut to a circle on corner of the avatar
+func WithBadgeImage(corner Corner, img image.Image) Option {
+	return withBadge(&badge{corner: corner, img: img}, img != nil)
+}
+
+func withBadge(b *badge, ok bool) Option {
+	return func(o *options) {
+		o.badge = b
+		if !ok || b.corner < TopLeft || b.corner > BottomRight {
+			o.err = errInvalidBadge
+		}
+	}
+}
+
+// text returns the count drawn in b
+func (b *badge) text() string {
+	if b.count > 99 {
+		return "99+"
+	}
+	return strconv.Itoa(b.count)
+}
+
+// rect returns the square in the corner of bounds b is drawn in. Its center
+// lies on the outline of circles where it crosses the diagonal.
+func (b *badge) rect(bounds image.Rectangle) image.Rectangle {
+	size := bounds.Dx()
+	if bounds.Dy() < size {
+		size = bounds.Dy()
+	}
+	d := int(float64(size) * badgeScale)
+	x, y := bounds.Min.X, bounds.Min.Y
+	if b.corner == TopRight || b.corner == BottomRight {
+		x = bounds.Max.X - d
+	}
+	if b.corner == BottomLeft || b.corner == BottomRight {
+		y = bounds.Max.Y - d
+	}
+	return image.Rect(x, y, x+d, y+d)
+}
+
+// draw stamps b over img. The dot has a white border to stand out from the
+// artwork.
+func (b *badge) draw(img *image.RGBA) {
+	r := b.rect(img.Bounds())
+	border := r.Dx() / 10
+	if border < 1 {
+		border = 1
+	}
+	dot := image.NewRGBA(r)
+	draw.Draw(dot, r, image.NewUniform(color.White), image.Point{}, draw.Src)
+	inner := r.Inset(border)
+	fill := image.NewRGBA(inner)
+	if b.img != nil {
+		xdraw.CatmullRom.Scale(fill, inner, b.img, b.img.Bounds(), draw.Src, nil)
+	} else {
+		draw.Draw(fill, inner, image.NewUniform(b.color), image.Point{}, draw.Src)
+	}
+	if b.count > 0 {
+		face, text := fitText(boldFont, b.text(), float64(inner.Dy())*0.6, 6, inner.Dx()*4/5)
+		defer face.Close()
+		drawCentered(fill, face, textColor(b.color), text)
+	}
+	roundCorners(fill, float64(inner.Dx())/2)
+	draw.Draw(dot, inner, fill, inner.Min, draw.Over)
+	roundCorners(dot, float64(r.Dx())/2)
+	draw.Draw(img, r, dot, r.Min, draw.Over)
+}
+
+// svg writes b stamped on an avatar of size as SVG elements in svgGrid units
+func (b *badge) svg(w *strings.Builder, size int) error {
+	k := float64(svgGrid) / float64(size)
+	r := b.rect(image.Rect(0, 0, size, size))
+	cx, cy := float64(r.Min.X+r.Max.X)/2*k, float64(r.Min.Y+r.Max.Y)/2*k
+	outer := float64(r.Dx()) / 2 * k
+	border := r.Dx() / 10
+	if border < 1 {
+		border = 1
+	}
+	inner := outer - float64(border)*k
+	fmt.Fprintf(w, `<g shape-rendering="geometricPrecision"><circle cx="%g" cy="%g" r="%g" fill="#fff"/>`, cx, cy, outer)
+	if b.img != nil {
+		uri, err := pngDataURI(b.img)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, `<clipPath id="%sbadge"><circle cx="%g" cy="%g" r="%g"/></clipPath>`, svgID, cx, cy, inner)
+		fmt.Fprintf(w, `<image x="%g" y="%g" width="%g" height="%g" clip-path="url(#%sbadge)" preserveAspectRatio="none" href="%s"/>`,
+			cx-inner, cy-inner, 2*inner, 2*inner, svgID, uri)
+	} else {
+		fmt.Fprintf(w, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`, cx, cy, inner, svgPaint(b.color))
+	}
+	if b.count > 0 {
+		text := b.text()
+		fontSize := inner * 1.2
+		if len(text) > 2 {
+			fontSize = inner * 0.9
+		}
+		fmt.Fprintf(w, `<text x="%g" y="%g" font-family="Go, sans-serif" font-weight="bold" font-size="%.3g" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`,
+			cx, cy, fontSize, svgPaint(textColor(b.color)), text)
+	}
+	w.WriteString(`</g>`)
+	return nil
+}
@@ -0,0 +1,94 @@
+package govatar
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var badgeGreen = color.RGBA{0x2e, 0xcc, 0x71, 0xff}
+
+func TestBadgeRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	b := &badge{corner: BottomRight}
+	assert.Equal(t, image.Rect(70, 70, 100, 100), b.rect(bounds))
+	b.corner = TopLeft
+	assert.Equal(t, image.Rect(0, 0, 30, 30), b.rect(bounds))
+	b.corner = TopRight
+	assert.Equal(t, image.Rect(140, 0, 200, 60), b.rect(image.Rect(0, 0, 200, 200)))
+	b.corner = BottomLeft
+	assert.Equal(t, image.Rect(0, 70, 30, 100), b.rect(bounds))
+}
+
+func TestWithBadge(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	plain, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100))
+	assert.NoError(t, err)
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithBadge(BottomRight, badgeGreen))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(badgeGreen), img.At(85, 85))
+	assert.Equal(t, color.RGBAModel.Convert(color.White), img.At(85, 71))
+	assert.Equal(t, plain.At(20, 20), img.At(20, 20))
+	// Corners outside of the dot are left alone
+	assert.Equal(t, plain.At(99, 99), img.At(99, 99))
+
+	// Dots stay on circles
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithBadge(TopLeft, badgeGreen))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(badgeGreen), img.At(15, 15))
+
+	count, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithBadgeCount(BottomRight, badgeGreen, 3))
+	assert.NoError(t, err)
+	ink := 0
+	for y := 73; y < 97; y++ {
+		for x := 73; x < 97; x++ {
+			if count.At(x, y) != color.RGBAModel.Convert(badgeGreen) {
+				ink++
+			}
+		}
+	}
+	assert.NotZero(t, ink)
+
+	logo := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range logo.Pix {
+		logo.Pix[i] = 0xff
+	}
+	logo.Pix[0] = 0
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithBadgeImage(BottomLeft, logo))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), img.At(15, 85))
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithBadge(Corner(9), badgeGreen))
+	assert.Equal(t, errInvalidBadge, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithBadge(TopLeft, nil))
+	assert.Equal(t, errInvalidBadge, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithBadgeCount(TopLeft, badgeGreen, 0))
+	assert.Equal(t, errInvalidBadge, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithBadgeImage(TopLeft, nil))
+	assert.Equal(t, errInvalidBadge, err)
+}
+
+func TestBadgeText(t *testing.T) {
+	assert.Equal(t, "7", (&badge{count: 7}).text())
+	assert.Equal(t, "99", (&badge{count: 99}).text())
+	assert.Equal(t, "99+", (&badge{count: 100}).text())
+}
+
+func TestBadgeSVG(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithSize(100), WithBadgeCount(BottomRight, badgeGreen, 120))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `fill="#2ecc71"`)
+	assert.Contains(t, svg, `>99+</text></g></svg>`)
+	assert.NoError(t, xml.Unmarshal([]byte(svg), new(interface{})))
+
+	svg, err = g.GenerateSVGFromUsername(MALE, "username@site.com", WithBadgeImage(TopLeft, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(svg, `href="data:image/png;base64,`))
+}
@@ -0,0 +1,51 @@
+// Package boltstore implements govatar.SpecStore on top of bbolt
+package boltstore
+
+import (
+	"encoding/json"
+
+	"github.com/recoilme/govatar"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store keeps specs as JSON values keyed by user ID in a bbolt bucket
+type Store struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// New returns a store using bucket of db, creating the bucket if needed
+func New(db *bolt.DB, bucket string) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, bucket: []byte(bucket)}, nil
+}
+
+// Get returns the spec stored for userID or govatar.ErrSpecNotFound
+func (s *Store) Get(userID string) (govatar.Spec, error) {
+	var spec govatar.Spec
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(s.bucket).Get([]byte(userID))
+		if data == nil {
+			return govatar.ErrSpecNotFound
+		}
+		return json.Unmarshal(data, &spec)
+	})
+	return spec, err
+}
+
+// Put stores spec for userID replacing any previous one
+func (s *Store) Put(userID string, spec govatar.Spec) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(userID), data)
+	})
+}
@@ -0,0 +1,34 @@
+package boltstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStore(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "specs.db"), 0600, nil)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s, err := New(db, "specs")
+	assert.NoError(t, err)
+
+	_, err = s.Get("42")
+	assert.Equal(t, govatar.ErrSpecNotFound, err)
+
+	spec := govatar.Spec{Gender: govatar.FEMALE, Background: 1, Face: 2, Clothes: 3, Mouth: 4, Hair: 5, Eye: 6}
+	assert.NoError(t, s.Put("42", spec))
+	stored, err := s.Get("42")
+	assert.NoError(t, err)
+	assert.Equal(t, spec, stored)
+
+	spec.Hair = 7
+	assert.NoError(t, s.Put("42", spec))
+	stored, err = s.Get("42")
+	assert.NoError(t, err)
+	assert.Equal(t, spec, stored)
+}
@@ -0,0 +1,58 @@
+package govatar
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// brailleBase is the Unicode code point of the empty Braille pattern
+const brailleBase = 0x2800
+
+// brailleDots maps dot position within a 2x4 cell to its pattern bit
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Braille renders img as lines of Unicode Braille patterns for monochrome
+// terminals. Every character covers 2x4 pixels, giving eight times the
+// resolution of one character per pixel. width is the number of characters
+// per line. Dots mark pixels brighter than average, which suits dark
+// terminals; invert marks darker pixels instead. Empty images and widths
+// below 1 give an empty string.
+func Braille(img image.Image, width int, invert bool) string {
+	b := img.Bounds()
+	if width <= 0 || b.Empty() {
+		return ""
+	}
+	dotsW := width * 2
+	dotsH := dotsW * b.Dy() / b.Dx()
+	dotsH += (4 - dotsH%4) % 4
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, dotsW, dotsH))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, b, draw.Src, nil)
+	threshold := averageLuma(scaled)
+
+	var out strings.Builder
+	for y := 0; y < dotsH; y += 4 {
+		for x := 0; x < dotsW; x += 2 {
+			cell := rune(brailleBase)
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					c := scaled.NRGBAAt(x+dx, y+dy)
+					if c.A > 0 && (luma(c) > threshold) != invert {
+						cell |= brailleDots[dy][dx]
+					}
+				}
+			}
+			out.WriteRune(cell)
+		}
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
@@ -0,0 +1,35 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBraille(t *testing.T) {
+	// Left half white, right half black
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, image.Rect(0, 0, 4, 8), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(4, 0, 8, 8), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	assert.Equal(t, "⣿⠀\n", Braille(img, 2, false))
+	assert.Equal(t, "⠀⣿\n", Braille(img, 2, true))
+	assert.Equal(t, "", Braille(img, 0, false))
+	assert.Equal(t, "", Braille(image.NewNRGBA(image.Rect(0, 0, 0, 8)), 2, false))
+	assert.Equal(t, "", Braille(image.NewNRGBA(image.Rectangle{}), 2, false))
+}
+
+func TestBrailleAvatar(t *testing.T) {
+	img, err := GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(Braille(img, 20, false), "\n"), "\n")
+	assert.Len(t, lines, 10)
+	for _, line := range lines {
+		assert.Equal(t, 20, len([]rune(line)))
+	}
+}
@@ -0,0 +1,84 @@
+package govatar
+
+import "image"
+
+// Builder composes an avatar from explicitly chosen parts, e.g. in a profile
+// editor. Parts not chosen are index 0. Methods return the builder so calls
+// chain:
+//
+//	img, err := govatar.NewBuilder(govatar.MALE).Face(2).Hair(7).Render()
+type Builder struct {
+	gen  *Generator
+	spec Spec
+}
+
+// NewBuilder returns a builder of avatars of gender drawn by the default generator
+func NewBuilder(gender Gender) *Builder {
+	return std().NewBuilder(gender)
+}
+
+// NewBuilder returns a builder of avatars of gender drawn by g
+func (g *Generator) NewBuilder(gender Gender) *Builder {
+	return &Builder{gen: g, spec: Spec{Gender: gender}}
+}
+
+// FromSpec replaces every part with the parts of spec, so editing starts
+// from an existing avatar such as the one from SpecFromUsername
+func (b *Builder) FromSpec(spec Spec) *Builder {
+	b.spec = spec
+	return b
+}
+
+// Background selects the background by index
+func (b *Builder) Background(i int) *Builder {
+	b.spec.Background = i
+	return b
+}
+
+// Face selects the face by index
+func (b *Builder) Face(i int) *Builder {
+	b.spec.Face = i
+	return b
+}
+
+// Clothes selects the clothes by index
+func (b *Builder) Clothes(i int) *Builder {
+	b.spec.Clothes = i
+	return b
+}
+
+// Mouth selects the mouth by index
+func (b *Builder) Mouth(i int) *Builder {
+	b.spec.Mouth = i
+	return b
+}
+
+// Hair selects the hair by index
+func (b *Builder) Hair(i int) *Builder {
+	b.spec.Hair = i
+	return b
+}
+
+// Eye selects the eyes by index
+func (b *Builder) Eye(i int) *Builder {
+	b.spec.Eye = i
+	return b
+}
+
+// Accessory selects the part of an extra layer such as earrings, beard,
+// glasses or hat by index. A negative index leaves the layer out.
+func (b *Builder) Accessory(layer string, i int) *Builder {
+	b.spec.setAccessory(layer, i)
+	return b
+}
+
+// Spec returns the spec of the parts chosen so far
+func (b *Builder) Spec() Spec {
+	return b.spec
+}
+
+// Render draws the avatar. Indices out of range of the loaded assets make it
+// fail with an invalid spec error.
+func (b *Builder) Render(opts ...Option) (image.Image, error) {
+	return b.gen.GenerateFromSpec(b.spec, opts...)
+}
@@ -0,0 +1,30 @@
+package govatar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(MALE).Face(2).Hair(7).Eye(1).Clothes(4).Mouth(3).Background(0)
+	spec := Spec{Gender: MALE, Face: 2, Hair: 7, Eye: 1, Clothes: 4, Mouth: 3, Background: 0}
+	assert.Equal(t, spec, b.Spec())
+
+	img, err := b.Render(WithSize(64))
+	assert.NoError(t, err)
+	expected, err := GenerateFromSpec(spec, WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	spec, err = SpecFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	edited := NewBuilder(MALE).FromSpec(spec).Hair(0).Spec()
+	spec.Hair = 0
+	assert.Equal(t, spec, edited)
+
+	_, err = NewBuilder(MALE).Hair(10000).Render()
+	assert.Equal(t, errInvalidSpec, err)
+	_, err = NewBuilder(Gender(7)).Render()
+	assert.Equal(t, errUnknownGender, err)
+}
@@ -0,0 +1,202 @@
+package govatar
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"sync"
+)
+
+// Cache stores encoded avatars by key, e.g. in Redis or memcached so
+// instances of a service share rendered avatars. Keys are hex strings safe
+// for any backend. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key
+	Get(key string) ([]byte, bool)
+	// Set stores value for key. Implementations may drop values at any time.
+	Set(key string, value []byte)
+}
+
+// WithCache looks up avatars encoded by GenerateToFromUsername and
+// GenerateBytesFromUsername in c before drawing them, and stores them after
+func WithCache(c Cache) Option {
+	return func(o *options) {
+		o.cache = c
+	}
+}
+
+// MemoryCache is a Cache keeping the most recently used values in memory
+type MemoryCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache returns a cache of at most size values
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get implements Cache
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).value, true
+}
+
+// Set implements Cache
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		e.Value.(*memoryCacheEntry).value = value
+		return
+	}
+	c.items[key] = c.order.PushFront(&memoryCacheEntry{key, value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of cached values
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// cacheKey returns the cache key of the avatar of username encoded in format
+// with settings o. Keys change with the assets of g and the mapping version.
+func (g *Generator) cacheKey(gender Gender, username, format string, o options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%d\x00%s\x00%s\x00", MappingVersion, g.store.fingerprint(), gender, normalizeFormat(format), username)
+	o.writeKey(h)
+	// Genders of registered styles depend on registration order
+	if st, ok := style(gender); ok {
+		p := st.person
+		fmt.Fprint(h, st.name, " ")
+		p.hash(h)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeKey writes every field of o that changes the avatar to w, one line
+// per field in declaration order. Left out are ctx, cache, descriptor and
+// traits, which don't change it, font and fontFace, which draw initials
+// only, renderer, keyed by rendererName, and err.
+func (o options) writeKey(w io.Writer) {
+	fmt.Fprintln(w, "size", o.size)
+	fmt.Fprintln(w, "seed", o.seed, o.seeded)
+	fmt.Fprintln(w, "background", o.background, o.transparent)
+	fmt.Fprintln(w, "encoding", o.filter, o.quality, o.lossless)
+	fmt.Fprintln(w, "pixel art", o.pixelArt, o.supersample)
+	fmt.Fprintln(w, "shape", o.shape, o.radius, keyColors(o.matte))
+	if f := o.frame; f != nil {
+		fmt.Fprintln(w, "frame", f.width, keyColors(f.colors...))
+	}
+	if b := o.badge; b != nil {
+		fmt.Fprintln(w, "badge", b.corner, b.count, keyColors(b.color))
+		if b.img != nil {
+			hashImage(w, b.img)
+		}
+	}
+	if l := o.logo; l != nil {
+		fmt.Fprintln(w, "logo", l.corner, l.opacity)
+		hashImage(w, l.img)
+	}
+	for _, ov := range o.overlays {
+		fmt.Fprintln(w, "overlay", ov.name)
+	}
+	fmt.Fprintln(w, "palette", keyColors(o.palette...))
+	for _, img := range o.backgrounds {
+		fmt.Fprintln(w, "background")
+		hashImage(w, img)
+	}
+	for _, layer := range sortedTints(o.tints) {
+		if t := o.tints[layer]; t != nil {
+			fmt.Fprintln(w, "tint", layer, t.Hue, t.Saturation, t.Lightness, t.skin, t.tone)
+		} else {
+			fmt.Fprintln(w, "tint", layer)
+		}
+	}
+	fmt.Fprintln(w, "tint palette", keyColors(o.tintPalette...))
+	fmt.Fprintln(w, "skin", keyColors(o.skinTones...))
+	// fmt prints maps sorted by key
+	fmt.Fprintln(w, "layers", o.order, o.excluded)
+	fmt.Fprintln(w, "renderer", o.rendererName)
+}
+
+// keyColors returns colors in one color model for cache keys, nil for nil
+// colors
+func keyColors(colors ...color.Color) []interface{} {
+	keys := make([]interface{}, len(colors))
+	for i, c := range colors {
+		if c != nil {
+			keys[i] = color.RGBA64Model.Convert(c)
+		}
+	}
+	return keys
+}
+
+// hash writes the asset paths of p to w
+func (p person) hash(w io.Writer) {
+	fmt.Fprintln(w, p.assets("face"), p.assets("clothes"), p.assets("mouth"), p.assets("hair"), p.assets("eye"))
+	if len(p.extras) > 0 {
+		fmt.Fprintln(w, p.order, p.none, p.layers)
+	}
+	if len(p.rules) > 0 {
+		fmt.Fprintln(w, p.rules)
+	}
+	if len(p.weights) > 0 {
+		fmt.Fprintln(w, p.weights)
+	}
+	if len(p.dependents) > 0 {
+		fmt.Fprintln(w, p.dependents)
+	}
+}
+
+// hashImage writes the bounds and pixels of img to w
+func hashImage(w io.Writer, img image.Image) {
+	pix := image.NewRGBA(img.Bounds())
+	draw.Draw(pix, pix.Bounds(), img, pix.Rect.Min, draw.Src)
+	fmt.Fprint(w, pix.Rect)
+	w.Write(pix.Pix)
+}
+
+// fingerprint returns a hash of the asset paths of s
+func (s *store) fingerprint() string {
+	s.fingerprintOnce.Do(func() {
+		h := sha256.New()
+		fmt.Fprintln(h, s.Background)
+		if s.backgroundWeights != nil {
+			fmt.Fprintln(h, s.backgroundWeights)
+		}
+		if s.accessories {
+			fmt.Fprintln(h, "accessories")
+		}
+		for _, p := range []person{s.Male, s.Female, s.Monster} {
+			p.hash(h)
+		}
+		s.fingerprintHash = hex.EncodeToString(h.Sum(nil))
+	})
+	return s.fingerprintHash
+}
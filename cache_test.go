@@ -0,0 +1,133 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Set("a", []byte("a"))
+	c.Set("b", []byte("b"))
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	// b is the least recently used
+	c.Set("c", []byte("c"))
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), value)
+
+	c.Set("a", []byte("A"))
+	value, _ = c.Get("a")
+	assert.Equal(t, []byte("A"), value)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCacheKeyOptions(t *testing.T) {
+	// Fields leaving the avatar as is, see writeKey
+	unkeyed := map[string]bool{"ctx": true, "cache": true, "descriptor": true, "traits": true, "font": true, "fontFace": true, "renderer": true, "err": true}
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	// Options with one field set, add new fields of options here
+	set := map[string]func(o *options){
+		"size":         func(o *options) { o.size = 1 },
+		"seed":         func(o *options) { o.seed = 1 },
+		"seeded":       func(o *options) { o.seeded = true },
+		"background":   func(o *options) { o.background = true },
+		"transparent":  func(o *options) { o.transparent = true },
+		"filter":       func(o *options) { o.filter = NEAREST },
+		"quality":      func(o *options) { o.quality = 1 },
+		"lossless":     func(o *options) { o.lossless = true },
+		"pixelArt":     func(o *options) { o.pixelArt = 2 },
+		"supersample":  func(o *options) { o.supersample = 2 },
+		"shape":        func(o *options) { o.shape = Circle },
+		"radius":       func(o *options) { o.radius = 1 },
+		"matte":        func(o *options) { o.matte = color.Black },
+		"frame":        func(o *options) { o.frame = &frame{width: 1, colors: []color.Color{color.Black}} },
+		"badge":        func(o *options) { o.badge = &badge{color: color.Black} },
+		"logo":         func(o *options) { o.logo = &logo{img: img} },
+		"overlays":     func(o *options) { o.overlays = []overlay{{name: "hat", img: img}} },
+		"palette":      func(o *options) { o.palette = []color.Color{color.Black} },
+		"backgrounds":  func(o *options) { o.backgrounds = []image.Image{img} },
+		"tints":        func(o *options) { o.tints = map[string]*Tint{"hair": nil} },
+		"tintPalette":  func(o *options) { o.tintPalette = []color.Color{color.Black} },
+		"skinTones":    func(o *options) { o.skinTones = []color.Color{color.Black} },
+		"order":        func(o *options) { o.order = []string{"hair"} },
+		"excluded":     func(o *options) { o.excluded = map[string]bool{"hair": true} },
+		"rendererName": func(o *options) { o.rendererName = IdenticonRenderer },
+	}
+	key := std().cacheKey(MALE, "username", "png", options{})
+	fields := reflect.TypeOf(options{})
+	for i := 0; i < fields.NumField(); i++ {
+		name := fields.Field(i).Name
+		if unkeyed[name] {
+			continue
+		}
+		if !assert.Contains(t, set, name, "options field missing from cache keys") {
+			continue
+		}
+		var o options
+		set[name](&o)
+		assert.NotEqual(t, key, std().cacheKey(MALE, "username", "png", o), name)
+	}
+	assert.Equal(t, fields.NumField(), len(set)+len(unkeyed))
+
+	// Tints are keyed with the colors parts are remapped to
+	hair := func(tint Tint) string {
+		return std().cacheKey(MALE, "username", "png", options{tints: map[string]*Tint{"hair": &tint}})
+	}
+	assert.NotEqual(t, hair(Tint{}), hair(Tint{skin: color.NRGBA{A: 0xff}}))
+	assert.NotEqual(t, hair(Tint{}), hair(Tint{tone: color.NRGBA{A: 0xff}}))
+	assert.NotEqual(t, hair(Tint{}), hair(Tint{Hue: 1}))
+}
+
+// countingCache counts lookups of a MemoryCache
+type countingCache struct {
+	*MemoryCache
+	hits, misses int
+}
+
+func (c *countingCache) Get(key string) ([]byte, bool) {
+	value, ok := c.MemoryCache.Get(key)
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return value, ok
+}
+
+func TestWithCache(t *testing.T) {
+	c := &countingCache{MemoryCache: NewMemoryCache(10)}
+	b, err := GenerateBytesFromUsername(MALE, "username@site.com", "png", WithCache(c))
+	assert.NoError(t, err)
+	cached, err := GenerateBytesFromUsername(MALE, "username@site.com", "png", WithCache(c))
+	assert.NoError(t, err)
+	assert.Equal(t, b, cached)
+	assert.Equal(t, 1, c.hits)
+	assert.Equal(t, 1, c.misses)
+
+	uncached, err := GenerateBytesFromUsername(MALE, "username@site.com", "png")
+	assert.NoError(t, err)
+	assert.Equal(t, b, uncached)
+
+	// Every setting changes the key
+	for _, opts := range [][]Option{
+		{WithSize(64)}, {WithSeed(1)}, {WithTransparent()}, {WithQuality(50)}, {WithFilter(NEAREST)},
+	} {
+		_, err = GenerateBytesFromUsername(MALE, "username@site.com", "png", append(opts, WithCache(c))...)
+		assert.NoError(t, err)
+	}
+	_, err = GenerateBytesFromUsername(FEMALE, "username@site.com", "png", WithCache(c))
+	assert.NoError(t, err)
+	_, err = GenerateBytesFromUsername(MALE, "username@site.com", "jpg", WithCache(c))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.hits)
+	assert.Equal(t, 8, c.Len())
+}
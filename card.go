@@ -0,0 +1,53 @@
+package govatar
+
+import (
+	"image"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// OpenGraph card size recommended by social networks
+const (
+	CardWidth  = 1200
+	CardHeight = 630
+)
+
+// GenerateCard lays out the avatar of username next to name and an optional
+// subtitle on a background colored from the username, producing a
+// CardWidth x CardHeight social preview image. Long names are shrunk and
+// then shortened with an ellipsis.
+func GenerateCard(gender Gender, username, name, subtitle string) (image.Image, error) {
+	avatar, err := GenerateFromUsername(gender, username)
+	if err != nil {
+		return nil, err
+	}
+	scheme := NewScheme(usernameSeed(username))
+	card := image.NewRGBA(image.Rect(0, 0, CardWidth, CardHeight))
+	draw.Draw(card, card.Bounds(), image.NewUniform(scheme.Background), image.Point{}, draw.Src)
+	// Accent stripe along the bottom edge
+	draw.Draw(card, image.Rect(0, CardHeight-24, CardWidth, CardHeight), image.NewUniform(scheme.Primary), image.Point{}, draw.Src)
+
+	const margin, avatarSize = 80, 420
+	top := (CardHeight - avatarSize) / 2
+	xdraw.CatmullRom.Scale(card, image.Rect(margin, top, margin+avatarSize, top+avatarSize), avatar, avatar.Bounds(), draw.Over, nil)
+
+	textX := 2*margin + avatarSize
+	textWidth := CardWidth - textX - margin
+	ink := textColor(scheme.Background)
+
+	nameFace, name := fitText(boldFont, name, 80, 40, textWidth)
+	defer nameFace.Close()
+	nameY := CardHeight / 2
+	if subtitle == "" {
+		nameY += nameFace.Metrics().Ascent.Ceil() / 2
+	}
+	drawText(card, nameFace, ink, textX, nameY, name)
+
+	if subtitle != "" {
+		subFace, subtitle := fitText(regularFont, subtitle, 44, 24, textWidth)
+		defer subFace.Close()
+		drawText(card, subFace, ink, textX, nameY+subFace.Metrics().Height.Ceil()+16, subtitle)
+	}
+	return card, nil
+}
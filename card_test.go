@@ -0,0 +1,34 @@
+package govatar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font"
+)
+
+func TestGenerateCard(t *testing.T) {
+	card, err := GenerateCard(MALE, "username@site.com", "John Doe", "Software engineer")
+	assert.NoError(t, err)
+	assert.Equal(t, CardWidth, card.Bounds().Dx())
+	assert.Equal(t, CardHeight, card.Bounds().Dy())
+
+	other, err := GenerateCard(MALE, "username@site.com", "Jane Doe", "")
+	assert.NoError(t, err)
+	assert.False(t, areImagesEquals(card, other))
+
+	_, err = GenerateCard(Gender(-1), "username@site.com", "John Doe", "")
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestFitText(t *testing.T) {
+	face, text := fitText(boldFont, "John", 80, 40, 600)
+	assert.Equal(t, "John", text)
+	assert.Equal(t, font.MeasureString(fontFace(boldFont, 80), "John"), font.MeasureString(face, text))
+
+	long := strings.Repeat("Wolfeschlegelsteinhausen", 5)
+	face, text = fitText(boldFont, long, 80, 40, 600)
+	assert.True(t, strings.HasSuffix(text, "…"))
+	assert.True(t, font.MeasureString(face, text).Ceil() <= 600)
+}
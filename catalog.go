@@ -0,0 +1,48 @@
+package govatar
+
+// MappingVersion is increased whenever the username to parts mapping changes,
+// i.e. when the same username may start producing a different avatar
+const MappingVersion = 1
+
+// Catalog describes the available genders and their layers
+type Catalog struct {
+	MappingVersion int             `json:"mappingVersion"`
+	Genders        []CatalogGender `json:"genders"`
+}
+
+// CatalogGender lists layers of a gender in drawing order. Besides face,
+// clothes, mouth, hair and eye there may be extra layers such as
+// accessories, which avatars may go without.
+type CatalogGender struct {
+	Name   string         `json:"name"`
+	Layers []CatalogLayer `json:"layers"`
+}
+
+// CatalogLayer is a layer category and the number of parts available for it
+type CatalogLayer struct {
+	Name  string `json:"name"`
+	Parts int    `json:"parts"`
+}
+
+// GetCatalog returns the catalog of loaded assets, suitable for building avatar editors
+func GetCatalog() Catalog {
+	return std().Catalog()
+}
+
+// Catalog returns the catalog of assets of g
+func (g *Generator) Catalog() Catalog {
+	s := g.store
+	c := Catalog{MappingVersion: MappingVersion}
+	for _, g := range genders() {
+		p, _ := s.person(g)
+		layers := []CatalogLayer{{Name: "background", Parts: len(s.Background)}}
+		for _, layer := range p.order {
+			if _, ok := p.dependents[layer]; ok {
+				continue
+			}
+			layers = append(layers, CatalogLayer{Name: layer, Parts: len(p.assets(layer))})
+		}
+		c.Genders = append(c.Genders, CatalogGender{Name: genderName(g), Layers: layers})
+	}
+	return c
+}
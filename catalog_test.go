@@ -0,0 +1,23 @@
+package govatar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCatalog(t *testing.T) {
+	c := GetCatalog()
+	assert.Equal(t, MappingVersion, c.MappingVersion)
+	assert.Len(t, c.Genders, len(Styles()))
+
+	male := c.Genders[0]
+	assert.Equal(t, "male", male.Name)
+	assert.Equal(t, CatalogLayer{"background", len(std().store.Background)}, male.Layers[0])
+	assert.Equal(t, CatalogLayer{"eye", len(std().store.Male.assets("eye"))}, male.Layers[5])
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"mappingVersion":1`)
+}
@@ -0,0 +1,118 @@
+package govatar
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// Harmony represents the rule used to relate the colors of a scheme
+type Harmony int
+
+// Analogous and complementary harmonies
+const (
+	ANALOGOUS Harmony = iota
+	COMPLEMENTARY
+)
+
+// Scheme is a harmonized set of colors derived from a seed.
+// Background is meant for the backdrop, Primary for clothes tint
+// and Accent for decorations.
+type Scheme struct {
+	Harmony    Harmony
+	Hue        float64
+	Background color.RGBA
+	Primary    color.RGBA
+	Accent     color.RGBA
+}
+
+// NewScheme derives a harmonized color scheme from seed.
+// The same seed always yields the same scheme.
+func NewScheme(seed int64) Scheme {
+	rnd := rand.New(rand.NewSource(seed))
+	hue := rnd.Float64() * 360
+	harmony := Harmony(randInt(rnd, 0, 2))
+
+	var accentHue float64
+	switch harmony {
+	case COMPLEMENTARY:
+		accentHue = hue + 180
+	default:
+		accentHue = hue + 30
+	}
+
+	return Scheme{
+		Harmony:    harmony,
+		Hue:        hue,
+		Background: hslToRGB(hue, 0.35+rnd.Float64()*0.2, 0.85),
+		Primary:    hslToRGB(hue, 0.55+rnd.Float64()*0.25, 0.45),
+		Accent:     hslToRGB(accentHue, 0.6+rnd.Float64()*0.2, 0.55),
+	}
+}
+
+// specScheme returns the scheme of the avatar of spec, which its solid
+// background and picked tints are taken from
+func specScheme(spec Spec) Scheme {
+	return NewScheme(specSeed(spec))
+}
+
+// specSeed returns a seed hashed from the parts of spec, so an avatar always
+// gets the same colors
+func specSeed(spec Spec) int64 {
+	h := fnv.New32a()
+	fmt.Fprint(h, spec.Gender, spec.Face, spec.Clothes, spec.Mouth, spec.Hair, spec.Eye)
+	return int64(h.Sum32())
+}
+
+// nearestColor returns the index of the color of palette closest to c in hue
+// and saturation. Grays are closest to colors of little saturation.
+func nearestColor(palette []color.Color, c color.RGBA) int {
+	chroma := func(c color.Color) (float64, float64) {
+		h, s, _ := rgbToHSL(color.NRGBAModel.Convert(c).(color.NRGBA))
+		return s * math.Cos(h*math.Pi/180), s * math.Sin(h*math.Pi/180)
+	}
+	x, y := chroma(c)
+	best, min := 0, math.Inf(1)
+	for i, p := range palette {
+		px, py := chroma(p)
+		if d := (px-x)*(px-x) + (py-y)*(py-y); d < min {
+			best, min = i, d
+		}
+	}
+	return best
+}
+
+// hslToRGB converts hue (degrees), saturation and lightness (0..1) to an opaque color
+func hslToRGB(h, s, l float64) color.RGBA {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,66 @@
+package govatar
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScheme(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		s1, s2 := NewScheme(seed), NewScheme(seed)
+		assert.Equal(t, s1, s2, "seed %d", seed)
+		want := 30.0
+		if s1.Harmony == COMPLEMENTARY {
+			want = 180
+		}
+		assert.InDelta(t, want, hueDistance(hueOf(s1.Primary), hueOf(s1.Accent)), 3, "seed %d", seed)
+	}
+}
+
+func TestSpecScheme(t *testing.T) {
+	o := options{palette: DefaultPalette, tints: map[string]*Tint{"clothes": nil, "hair": nil}}
+	for _, username := range []string{"a", "b", "c", "d", "e", "f"} {
+		spec, err := SpecFromUsername(FEMALE, username)
+		assert.NoError(t, err)
+		scheme := specScheme(spec)
+		// The solid background and picked tints share the scheme
+		bg := color.RGBAModel.Convert(o.fill(spec)).(color.RGBA)
+		d := hueDistance(hueOf(bg), scheme.Hue)
+		assert.True(t, d <= 45, "username %s: background hue is %.1f away from the scheme", username, d)
+		assert.Equal(t, color.NRGBA(scheme.Primary), o.tint(spec, "clothes").tone, username)
+		assert.Equal(t, color.NRGBA(scheme.Accent), o.tint(spec, "hair").tone, username)
+	}
+}
+
+func TestHSLToRGB(t *testing.T) {
+	cases := []struct {
+		h, s, l  float64
+		expected color.RGBA
+	}{
+		{0, 1, 0.5, color.RGBA{255, 0, 0, 255}},
+		{120, 1, 0.5, color.RGBA{0, 255, 0, 255}},
+		{240, 1, 0.5, color.RGBA{0, 0, 255, 255}},
+		{360, 1, 0.5, color.RGBA{255, 0, 0, 255}},
+		{0, 0, 1, color.RGBA{255, 255, 255, 255}},
+		{0, 0, 0, color.RGBA{0, 0, 0, 255}},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, hslToRGB(c.h, c.s, c.l), "case #%d", i)
+	}
+}
+
+func hueOf(c color.RGBA) float64 {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	return math.Mod(math.Atan2(math.Sqrt(3)*(g-b), 2*r-g-b)*180/math.Pi+360, 360)
+}
+
+func hueDistance(a, b float64) float64 {
+	d := math.Abs(a - b)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
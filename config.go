@@ -0,0 +1,117 @@
+package govatar
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+)
+
+// assetSize is width and height of asset images in pixels
+const assetSize = 400
+
+// maxSize is the largest avatar size accepted by Config
+const maxSize = 4096
+
+var (
+	errInvalidSize        = errors.New("Invalid avatar size")
+	errInvalidJPEGQuality = errors.New("Invalid JPEG quality")
+	errInvalidFilter      = errors.New("Invalid resampling filter")
+	errInvalidQuality     = errors.New("Invalid quality")
+)
+
+// ErrAssetsNotFound is returned by New when asset directories are missing,
+// and by generation calls for genders without parts, e.g. by package level
+// functions when ./data is missing
+var ErrAssetsNotFound = errors.New("Assets not found")
+
+// Config holds generation settings
+type Config struct {
+	// Size is width and height of avatars in pixels
+	Size int
+	// JPEGQuality is quality of jpeg, lossy webp and avif output from 1 to 100
+	JPEGQuality int
+	// AssetsPath is directory with background and per gender assets
+	AssetsPath string
+	// Filter resamples avatars whose Size differs from the assets
+	Filter Filter
+	// LayerOrder overrides the drawing order of the asset pack, see
+	// WithLayerOrder. Empty keeps the order of the pack.
+	LayerOrder []string
+	// Rules keep parts of the assets apart in addition to the rules of the
+	// asset pack, see Rule
+	Rules []Rule
+	// Accessories puts the shared accessories of the accessories directory
+	// of AssetsPath on random avatars. They change the avatars of existing
+	// usernames, so they are left to explicit specs by default.
+	Accessories bool
+}
+
+// DefaultConfig returns the default settings: 400x400 avatars,
+// jpeg quality 80 and assets in ./data
+func DefaultConfig() Config {
+	return Config{
+		Size:        assetSize,
+		JPEGQuality: 80,
+		AssetsPath:  "data",
+	}
+}
+
+// Validate checks that settings are in range and assets directories exist
+func (c Config) Validate() error {
+	if c.Size <= 0 || c.Size > maxSize {
+		return errInvalidSize
+	}
+	if c.JPEGQuality < 1 || c.JPEGQuality > 100 {
+		return errInvalidJPEGQuality
+	}
+	if c.Filter < CatmullRom || c.Filter > NEAREST {
+		return errInvalidFilter
+	}
+	if err := checkLayerOrder(c.LayerOrder); err != nil {
+		return err
+	}
+	if _, err := parseRules(c.Rules); err != nil {
+		return err
+	}
+	dirs := assetDirs()
+	if c.Accessories {
+		dirs = append(dirs, accessoriesDir)
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(filepath.Join(c.AssetsPath, dir))
+		if err != nil || !info.IsDir() {
+			return ErrAssetsNotFound
+		}
+	}
+	return nil
+}
+
+// assetDirs lists slash separated directories every asset set has
+func assetDirs() []string {
+	dirs := []string{"background"}
+	for _, g := range []Gender{MALE, FEMALE, MONSTER} {
+		for _, layer := range personLayers {
+			dirs = append(dirs, path.Join(genderName(g), layer))
+		}
+	}
+	return dirs
+}
+
+// Configure validates c and applies it to all following package level
+// generation calls, reloading assets if AssetsPath changed. It must not be
+// called concurrently with generation.
+func Configure(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	// Keep std from loading the default assets after this
+	defaultOnce.Do(func() {})
+	if defaultGenerator != nil && defaultGenerator.config.AssetsPath == c.AssetsPath && reflect.DeepEqual(defaultGenerator.config.Rules, c.Rules) && defaultGenerator.config.Accessories == c.Accessories {
+		defaultGenerator = newGenerator(defaultGenerator.store, c)
+	} else {
+		defaultGenerator = newGenerator(loadConfigStore(c), c)
+	}
+	return nil
+}
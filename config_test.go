@@ -0,0 +1,45 @@
+package govatar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	c := DefaultConfig()
+	c.Size = 0
+	assert.Equal(t, errInvalidSize, c.Validate())
+	c.Size = maxSize + 1
+	assert.Equal(t, errInvalidSize, c.Validate())
+
+	c = DefaultConfig()
+	c.JPEGQuality = 101
+	assert.Equal(t, errInvalidJPEGQuality, c.Validate())
+
+	c = DefaultConfig()
+	c.Filter = -1
+	assert.Equal(t, errInvalidFilter, c.Validate())
+
+	c = DefaultConfig()
+	c.AssetsPath = "data/background"
+	assert.Equal(t, ErrAssetsNotFound, c.Validate())
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(DefaultConfig())
+
+	c := DefaultConfig()
+	c.Size = 128
+	assert.NoError(t, Configure(c))
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 128, avatar.Bounds().Dx())
+	assert.Equal(t, 128, avatar.Bounds().Dy())
+
+	c.Size = -1
+	assert.Equal(t, errInvalidSize, Configure(c))
+	assert.Equal(t, 128, std().Config().Size)
+}
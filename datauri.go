@@ -0,0 +1,58 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+)
+
+// GenerateDataURI generates avatar from string as a base64 data URI in
+// format (png, jpeg, jpg, gif, webp, avif, svg), ready to inline in HTML
+// or JSON
+func GenerateDataURI(gender Gender, username string, format string, opts ...Option) (string, error) {
+	return std().GenerateDataURI(gender, username, format, opts...)
+}
+
+// GenerateDataURI generates avatar from string as a base64 data URI in
+// format (png, jpeg, jpg, gif, webp, avif, svg)
+func (g *Generator) GenerateDataURI(gender Gender, username string, format string, opts ...Option) (string, error) {
+	b, err := g.encodeFromUsername(gender, username, format, opts)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + MIMEType(format) + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// pngDataURI returns img as a png data URI, for images inlined in SVG
+func pngDataURI(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// encodeFromUsername generates avatar from string encoded in any format
+// known to MIMEType
+func (g *Generator) encodeFromUsername(gender Gender, username string, format string, opts []Option) ([]byte, error) {
+	if normalizeFormat(format) == "svg" {
+		svg, err := g.GenerateSVGFromUsername(gender, username, opts...)
+		return []byte(svg), err
+	}
+	return g.GenerateBytesFromUsername(gender, username, format, opts...)
+}
+
+// MIMEType returns the media type of format (png, jpeg, jpg, gif, webp, avif, svg)
+// or an empty string for unknown formats
+func MIMEType(format string) string {
+	switch f := normalizeFormat(format); f {
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "svg":
+		return "image/svg+xml"
+	case "png", "gif", "webp", "avif":
+		return "image/" + f
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateDataURI(t *testing.T) {
+	uri, err := GenerateDataURI(FEMALE, "username@site.com", "png", WithSize(32))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "data:image/png;base64,"))
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, 32, img.Bounds().Dx())
+
+	uri, err = GenerateDataURI(FEMALE, "username@site.com", "svg")
+	assert.NoError(t, err)
+	svg, err := GenerateSVGFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "data:image/svg+xml;base64,"+base64.StdEncoding.EncodeToString([]byte(svg)), uri)
+
+	_, err = GenerateDataURI(FEMALE, "username@site.com", "bmp")
+	assert.Equal(t, errUnknownFormat, err)
+}
+
+func TestMIMEType(t *testing.T) {
+	assert.Equal(t, "image/jpeg", MIMEType(".JPG"))
+	assert.Equal(t, "image/webp", MIMEType("webp"))
+	assert.Equal(t, "image/avif", MIMEType("avif"))
+	assert.Equal(t, "image/svg+xml", MIMEType("svg"))
+	assert.Equal(t, "", MIMEType("bmp"))
+}
@@ -0,0 +1,154 @@
+package govatar
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// dependentLayer is a layer whose parts are never picked on their own but
+// drawn with the parts of another layer, e.g. back hair behind the face
+// that goes with a hair style
+type dependentLayer struct {
+	// parent is the layer whose parts pull in the parts of the layer
+	parent string
+	// assets holds the part pulled in by every part of parent in selection
+	// order, "" for parts of parent that pull in nothing
+	assets []string
+}
+
+// loadDependents moves the extra layers manifest makes dependent from
+// p.extras to p.dependents. A part of a dependent layer goes with the part
+// of the parent layer of the same file name.
+func (p *person) loadDependents(src assetSource, dir string, manifest *Manifest) {
+	if manifest == nil || len(manifest.Dependent) == 0 {
+		return
+	}
+	extras := p.extras[:0]
+	for _, layer := range p.extras {
+		parent := manifest.Dependent[layer]
+		if parent == "" || p.layers[parent] == nil {
+			extras = append(extras, layer)
+			continue
+		}
+		byName := map[string]string{}
+		for _, asset := range src.list(filepath.Join(dir, layer)) {
+			byName[filepath.Base(asset)] = asset
+		}
+		d := dependentLayer{parent: parent, assets: make([]string, len(p.layers[parent]))}
+		for i, asset := range p.layers[parent] {
+			d.assets[i] = byName[filepath.Base(asset)]
+		}
+		if p.dependents == nil {
+			p.dependents = map[string]dependentLayer{}
+		}
+		p.dependents[layer] = d
+		delete(p.layers, layer)
+		delete(p.none, layer)
+	}
+	// Parents can't depend on other layers themselves
+	for layer, d := range p.dependents {
+		if _, ok := p.dependents[d.parent]; ok {
+			delete(p.dependents, layer)
+		}
+	}
+	p.extras = extras
+}
+
+// drawable returns the assets of p by layer including dependent layers
+func (p person) drawable() map[string][]string {
+	if len(p.dependents) == 0 {
+		return p.layers
+	}
+	all := make(map[string][]string, len(p.layers)+len(p.dependents))
+	for layer, assets := range p.layers {
+		all[layer] = assets
+	}
+	for layer, d := range p.dependents {
+		all[layer] = d.assets
+	}
+	return all
+}
+
+// placeDependents places the layers parents maps to their parent layers,
+// which order leaves out, right behind their parents
+func placeDependents(order []string, parents map[string]string) []string {
+	var missing []string
+	for layer := range parents {
+		if layerIndex(order, layer) < 0 && layerIndex(order, parents[layer]) >= 0 {
+			missing = append(missing, layer)
+		}
+	}
+	sort.Strings(missing)
+	for _, layer := range missing {
+		i := layerIndex(order, parents[layer])
+		order = append(order[:i:i], append([]string{layer}, order[i:]...)...)
+	}
+	return order
+}
+
+// parents maps the dependent layers of p to their parent layers
+func (p person) parents() map[string]string {
+	parents := make(map[string]string, len(p.dependents))
+	for layer, d := range p.dependents {
+		parents[layer] = d.parent
+	}
+	return parents
+}
+
+// mixDependents returns the dependent layers of a person with the parts of
+// a followed by those of b
+func mixDependents(a, b person) map[string]dependentLayer {
+	if len(a.dependents) == 0 && len(b.dependents) == 0 {
+		return nil
+	}
+	mixed := map[string]dependentLayer{}
+	for _, p := range []person{a, b} {
+		for layer, d := range p.dependents {
+			if _, ok := mixed[layer]; !ok {
+				mixed[layer] = dependentLayer{parent: d.parent}
+			}
+		}
+	}
+	for layer, m := range mixed {
+		for _, p := range []person{a, b} {
+			assets := make([]string, len(p.layers[m.parent]))
+			if d, ok := p.dependents[layer]; ok && d.parent == m.parent {
+				assets = d.assets
+			}
+			m.assets = append(m.assets, assets...)
+		}
+		mixed[layer] = m
+	}
+	return mixed
+}
+
+// checkDependents lists problems of the dependent layers of m: parents that
+// are no layer and parts that go with no part of the parent
+func checkDependents(m *Manifest) (problems []string) {
+	var layers []string
+	for layer := range m.Dependent {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	for _, layer := range layers {
+		parent := m.Dependent[layer]
+		if isPersonLayer(layer) || !validLayer(parent) || layer == parent || m.Dependent[parent] != "" {
+			problems = append(problems, fmt.Sprintf("%s: layer %s can't depend on %s", ManifestFile, layer, parent))
+			continue
+		}
+		for _, g := range []Gender{MALE, FEMALE, MONSTER} {
+			layers := m.Genders[genderName(g)]
+			parts := map[string]bool{}
+			for _, name := range layers[parent] {
+				parts[name] = true
+			}
+			for _, name := range layers[layer] {
+				if !parts[name] {
+					problems = append(problems, fmt.Sprintf("%s: no %s part goes with %s", filepath.Join(genderName(g), layer, name), parent, name))
+				}
+			}
+		}
+	}
+	return problems
+}
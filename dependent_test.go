@@ -0,0 +1,114 @@
+package govatar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependentLayers(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys["hair/hair2.png"] = fsys["hat/hat1.png"]
+	fsys["hair/hair3.png"] = fsys["hat/hat1.png"]
+	assert.NoError(t, Register("plain", fsys))
+	fsys["hairback/hair1.png"] = fsys["glasses/glasses1.png"]
+	fsys["hairback/hair2.png"] = fsys["hat/hat2.png"]
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"dependent": {"hairback": "hair"}, "rules": [{"part": "hat:1", "excludes": ["hair"]}]}`)}
+	assert.NoError(t, Register("layered", fsys))
+	gender, _ := LookupStyle("layered")
+
+	st, _ := style(gender)
+	assert.Equal(t, []string{"face", "clothes", "mouth", "hairback", "hair", "eye", "glasses", "hat"}, st.person.order)
+	assert.Equal(t, []string{"glasses", "hat"}, st.person.extras)
+	for _, l := range GetCatalog().Genders[len(GetCatalog().Genders)-1].Layers {
+		assert.NotEqual(t, "hairback", l.Name)
+	}
+
+	// Dependent layers leave the parts of every username as they are
+	plain, _ := LookupStyle("plain")
+	for i := 0; i < 20; i++ {
+		spec, err := SpecFromUsername(gender, "user"+strconv.Itoa(i))
+		assert.NoError(t, err)
+		assert.NotContains(t, spec.Accessories, "hairback")
+		other, err := SpecFromUsername(plain, "user"+strconv.Itoa(i))
+		assert.NoError(t, err)
+		other.Gender = gender
+		assert.Equal(t, other, spec)
+	}
+
+	spec := NewBuilder(gender).Spec()
+	layers, assets, err := std().store.drawnLayout(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"background", "face", "clothes", "mouth", "hairback", "hair", "eye"}, layers)
+	assert.Equal(t, "style:layered/hairback/hair1.png", assets[4])
+	img, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+	bare, err := GenerateFromSpec(spec, WithoutLayers("hairback"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, bare, img)
+
+	// Parts without a dependent part and parts left out by rules pull in nothing
+	layers, _, err = std().store.drawnLayout(NewBuilder(gender).Hair(2).Spec())
+	assert.NoError(t, err)
+	assert.NotContains(t, layers, "hairback")
+	layers, _, err = std().store.drawnLayout(NewBuilder(gender).Accessory("hat", 1).Spec())
+	assert.NoError(t, err)
+	assert.NotContains(t, layers, "hairback")
+
+	// The editor redraws dependent parts along with their parent
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	e, err := NewEditor(d)
+	assert.NoError(t, err)
+	for _, hair := range []int{1, 2, 0} {
+		img, err = e.Set(HAIR, hair)
+		assert.NoError(t, err)
+		expected, err := GenerateFromSpec(NewBuilder(gender).Hair(hair).Spec())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, img, hair)
+	}
+}
+
+func TestPackDependentLayers(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+	writeTestImage(t, filepath.Join(dir, "male", "hairback", "hair1.png"), 400)
+	m, err := BuildPack(dir)
+	assert.NoError(t, err)
+
+	m.Dependent = map[string]string{"hairback": "hair"}
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	m, err = BuildPack(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"hairback": "hair"}, m.Dependent)
+
+	// Layers drop the dependent layer to draw it right behind its parent
+	m.Layers = personLayers
+	data, err = json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	m, err = BuildPack(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"face", "clothes", "mouth", "hairback", "hair", "eye"}, m.Layers)
+
+	writeTestImage(t, filepath.Join(dir, "male", "hairback", "hair2.png"), 400)
+	m.Dependent["face"] = "hair"
+	data, err = json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	_, err = BuildPack(dir)
+	problems, ok := err.(PackProblems)
+	assert.True(t, ok)
+	assert.Len(t, problems, 2)
+	assert.Contains(t, err.Error(), "layer face can't depend on hair")
+	assert.Contains(t, err.Error(), "no hair part goes with hair2.png")
+}
@@ -0,0 +1,241 @@
+package govatar
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"path/filepath"
+)
+
+var errInvalidDescriptor = errors.New("Invalid descriptor")
+
+// specLayers lists the layers every spec has in default drawing order
+var specLayers = append([]string{"background"}, personLayers...)
+
+// Part identifies a layer of an avatar
+type Part int
+
+// Parts in drawing order
+const (
+	BACKGROUND Part = iota
+	FACE
+	CLOTHES
+	MOUTH
+	HAIR
+	EYE
+)
+
+// String returns the layer name of p as used by Catalog and Descriptor
+func (p Part) String() string {
+	if p < 0 || int(p) >= len(specLayers) {
+		return ""
+	}
+	return specLayers[p]
+}
+
+// Descriptor records exactly what an avatar was generated from: the gender
+// and the index and file name of the part chosen for every layer. Persist it
+// as JSON and draw the same avatar later, at any size, with RenderDescriptor.
+type Descriptor struct {
+	Gender Gender
+	// Layers in drawing order
+	Layers []DescriptorLayer
+}
+
+// DescriptorLayer is the part chosen for a layer
+type DescriptorLayer struct {
+	// Name of the layer as in Catalog: background, face, clothes, mouth, hair,
+	// eye, or an extra layer of the asset pack
+	Name string `json:"name"`
+	// Index of the part in the layer
+	Index int `json:"index"`
+	// File name of the part. When set it takes precedence over Index, so
+	// descriptors survive parts being added to an asset pack.
+	File string `json:"file,omitempty"`
+}
+
+// With returns a copy of d with the part of layer part replaced by the part
+// at index, leaving all other layers as they are. Invalid parts make
+// RenderDescriptor fail.
+func (d Descriptor) With(part Part, index int) Descriptor {
+	return d.WithLayer(part.String(), index)
+}
+
+// WithLayer returns a copy of d with the part of the layer named layer, e.g.
+// "hat", replaced by the part at index. A negative index leaves out an
+// accessory or other extra layer.
+func (d Descriptor) WithLayer(layer string, index int) Descriptor {
+	extra := layerIndex(specLayers, layer) < 0
+	layers := make([]DescriptorLayer, 0, len(d.Layers)+1)
+	found := false
+	for _, l := range d.Layers {
+		if l.Name == layer {
+			found = true
+			if extra && index < 0 {
+				continue
+			}
+			l = DescriptorLayer{Name: layer, Index: index}
+		}
+		layers = append(layers, l)
+	}
+	if !found && (!extra || index >= 0) {
+		layers = append(layers, DescriptorLayer{Name: layer, Index: index})
+	}
+	d.Layers = layers
+	return d
+}
+
+// Index returns the index of the part chosen for layer part, false if d
+// leaves the layer out
+func (d Descriptor) Index(part Part) (int, bool) {
+	for _, l := range d.Layers {
+		if l.Name == part.String() {
+			return l.Index, true
+		}
+	}
+	return 0, false
+}
+
+type descriptorJSON struct {
+	MappingVersion int               `json:"mappingVersion"`
+	Gender         Gender            `json:"gender"`
+	Layers         []DescriptorLayer `json:"layers"`
+}
+
+// MarshalJSON encodes d with the gender by name
+func (d Descriptor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(descriptorJSON{MappingVersion: MappingVersion, Gender: d.Gender, Layers: d.Layers})
+}
+
+// UnmarshalJSON decodes a descriptor encoded by MarshalJSON
+func (d *Descriptor) UnmarshalJSON(data []byte) error {
+	var v descriptorJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*d = Descriptor{Gender: v.Gender, Layers: v.Layers}
+	return nil
+}
+
+// WithDescriptor stores the descriptor of the generated avatar in d
+func WithDescriptor(d *Descriptor) Option {
+	return func(o *options) {
+		o.descriptor = d
+	}
+}
+
+// Describe returns the descriptor of the avatar of spec
+func Describe(spec Spec) (Descriptor, error) {
+	return std().Describe(spec)
+}
+
+// RenderDescriptor draws the avatar recorded in d
+func RenderDescriptor(d Descriptor, opts ...Option) (image.Image, error) {
+	return std().RenderDescriptor(d, opts...)
+}
+
+// Describe returns the descriptor of the avatar of spec drawn by g
+func (g *Generator) Describe(spec Spec) (Descriptor, error) {
+	layers, assets, err := g.store.specLayout(spec)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	d := Descriptor{Gender: spec.Gender, Layers: make([]DescriptorLayer, len(layers))}
+	for i, name := range layers {
+		index := spec.Accessories[name]
+		if p := spec.layer(name); p != nil {
+			index = *p
+		}
+		d.Layers[i] = DescriptorLayer{Name: name, Index: index, File: filepath.Base(assets[i])}
+	}
+	return d, nil
+}
+
+// RenderDescriptor draws the avatar recorded in d with assets of g
+func (g *Generator) RenderDescriptor(d Descriptor, opts ...Option) (image.Image, error) {
+	spec, err := g.store.descriptorSpec(d)
+	if err != nil {
+		return nil, err
+	}
+	return g.GenerateFromSpec(spec, opts...)
+}
+
+// describe stores the descriptor and traits of spec if o asks for them
+func (g *Generator) describe(spec Spec, o options) error {
+	if o.descriptor != nil {
+		d, err := g.Describe(spec)
+		if err != nil {
+			return err
+		}
+		*o.descriptor = d
+	}
+	if o.traits != nil {
+		t, err := g.DescribeTraits(spec)
+		if err != nil {
+			return err
+		}
+		*o.traits = t
+	}
+	return nil
+}
+
+// descriptorSpec resolves descriptor layers to part indices. Layers left out
+// of d are index 0.
+func (s *store) descriptorSpec(d Descriptor) (Spec, error) {
+	p, err := s.person(d.Gender)
+	if err != nil {
+		return Spec{}, err
+	}
+	parts := map[string][]string{"background": s.Background}
+	for layer, assets := range p.layers {
+		parts[layer] = assets
+	}
+	spec := Spec{Gender: d.Gender}
+	for _, l := range d.Layers {
+		index := spec.layer(l.Name)
+		extra := index == nil
+		if extra {
+			if parts[l.Name] == nil {
+				return Spec{}, errInvalidDescriptor
+			}
+			index = new(int)
+		}
+		*index = l.Index
+		if l.File != "" {
+			*index = -1
+			for i, asset := range parts[l.Name] {
+				if filepath.Base(asset) == l.File {
+					*index = i
+					break
+				}
+			}
+			if *index < 0 {
+				return Spec{}, errInvalidDescriptor
+			}
+		}
+		if extra {
+			spec.setAccessory(l.Name, *index)
+		}
+	}
+	return spec, nil
+}
+
+// layer returns the index of the part of layer named name, or nil for
+// unknown layers
+func (s *Spec) layer(name string) *int {
+	switch name {
+	case "background":
+		return &s.Background
+	case "face":
+		return &s.Face
+	case "clothes":
+		return &s.Clothes
+	case "mouth":
+		return &s.Mouth
+	case "hair":
+		return &s.Hair
+	case "eye":
+		return &s.Eye
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptor(t *testing.T) {
+	var d Descriptor
+	img, err := GenerateFromUsername(FEMALE, "username@site.com", WithSize(64), WithDescriptor(&d))
+	assert.NoError(t, err)
+	spec, err := SpecFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	layers, assets, err := std().store.specLayout(spec)
+	assert.NoError(t, err)
+
+	assert.Equal(t, FEMALE, d.Gender)
+	assert.Len(t, d.Layers, len(layers))
+	assert.Equal(t, specLayers, layers[:6])
+	for i, l := range d.Layers {
+		assert.Equal(t, layers[i], l.Name)
+		assert.Equal(t, filepath.Base(assets[i]), l.File)
+	}
+	assert.Equal(t, spec.Hair, d.Layers[4].Index)
+	described, err := Describe(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, d, described)
+
+	data, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"gender":"female"`)
+	var decoded Descriptor
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, d, decoded)
+
+	rendered, err := RenderDescriptor(decoded, WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, img, rendered)
+	large, err := RenderDescriptor(decoded, WithSize(128))
+	assert.NoError(t, err)
+	assert.Equal(t, 128, large.Bounds().Dx())
+}
+
+func TestDescriptorCached(t *testing.T) {
+	cache := NewMemoryCache(8)
+	var first, second Descriptor
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateToFromUsername(&buf, MALE, "username", "png", WithCache(cache), WithDescriptor(&first)))
+	assert.NoError(t, GenerateToFromUsername(&buf, MALE, "username", "png", WithCache(cache), WithDescriptor(&second)))
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, first, second)
+	assert.Len(t, second.Layers, 6)
+}
+
+func TestDescriptorSpec(t *testing.T) {
+	s := std().store
+	spec, err := s.descriptorSpec(Descriptor{Gender: MALE, Layers: []DescriptorLayer{{Name: "hair", Index: 3}}})
+	assert.NoError(t, err)
+	assert.Equal(t, Spec{Gender: MALE, Hair: 3}, spec)
+
+	// File takes precedence over index
+	spec, err = s.descriptorSpec(Descriptor{Gender: MALE, Layers: []DescriptorLayer{{Name: "eye", Index: 0, File: filepath.Base(s.Male.assets("eye")[2])}}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, spec.Eye)
+
+	for _, d := range []Descriptor{
+		{Gender: MALE, Layers: []DescriptorLayer{{Name: "tail"}}},
+		{Gender: MALE, Layers: []DescriptorLayer{{Name: "eye", File: "missing.png"}}},
+	} {
+		_, err = s.descriptorSpec(d)
+		assert.Equal(t, errInvalidDescriptor, err)
+	}
+	_, err = RenderDescriptor(Descriptor{Gender: MALE, Layers: []DescriptorLayer{{Name: "face", Index: 10000}}})
+	assert.Equal(t, errInvalidSpec, err)
+
+	var d Descriptor
+	assert.Equal(t, errUnknownGender, json.Unmarshal([]byte(`{"gender":"alien"}`), &d))
+	_, err = json.Marshal(Descriptor{Gender: Gender(7)})
+	assert.Error(t, err)
+}
+
+func TestDescriptorWith(t *testing.T) {
+	d, err := Describe(Spec{Gender: MALE, Face: 1, Hair: 2, Eye: 3})
+	assert.NoError(t, err)
+	hair, ok := d.Index(HAIR)
+	assert.True(t, ok)
+	assert.Equal(t, 2, hair)
+
+	changed := d.With(HAIR, 5)
+	hair, _ = changed.Index(HAIR)
+	assert.Equal(t, 5, hair)
+	// d is left as it was
+	hair, _ = d.Index(HAIR)
+	assert.Equal(t, 2, hair)
+
+	spec, err := std().store.descriptorSpec(changed)
+	assert.NoError(t, err)
+	assert.Equal(t, Spec{Gender: MALE, Face: 1, Hair: 5, Eye: 3}, spec)
+	img, err := RenderDescriptor(changed, WithSize(64))
+	assert.NoError(t, err)
+	expected, err := GenerateFromSpec(spec, WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	partial := Descriptor{Gender: FEMALE}.With(EYE, 4).With(FACE, 1)
+	assert.Equal(t, []DescriptorLayer{{Name: "eye", Index: 4}, {Name: "face", Index: 1}}, partial.Layers)
+	_, ok = partial.Index(HAIR)
+	assert.False(t, ok)
+
+	assert.Equal(t, "background", BACKGROUND.String())
+	assert.Equal(t, "eye", EYE.String())
+	_, err = RenderDescriptor(d.With(Part(9), 0))
+	assert.Equal(t, errInvalidDescriptor, err)
+}
+
+func TestDescriptorWithLayer(t *testing.T) {
+	d, err := Describe(Spec{Gender: MALE, Hair: 2})
+	assert.NoError(t, err)
+	hatted := d.WithLayer("hat", 1).WithLayer("glasses", 0)
+	assert.Len(t, d.Layers, 6)
+	spec, err := std().store.descriptorSpec(hatted)
+	assert.NoError(t, err)
+	assert.Equal(t, Spec{Gender: MALE, Hair: 2, Accessories: map[string]int{"hat": 1, "glasses": 0}}, spec)
+	assert.Equal(t, d.With(HAIR, 4), d.WithLayer("hair", 4))
+
+	// Negative indices leave out extra layers only
+	bare := hatted.WithLayer("hat", -1).WithLayer("earrings", -1)
+	spec, err = std().store.descriptorSpec(bare)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"glasses": 0}, spec.Accessories)
+	_, err = RenderDescriptor(d.WithLayer("hair", -1))
+	assert.Error(t, err)
+	_, err = RenderDescriptor(d.WithLayer("tattoo", 0))
+	assert.Equal(t, errInvalidDescriptor, err)
+}
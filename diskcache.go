@@ -0,0 +1,204 @@
+package govatar
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskCache is a Cache storing values as files in a directory, evicting the
+// least recently used once they take more than a byte limit. Files are kept
+// across restarts.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	order *list.List
+	files map[string]*list.Element
+}
+
+type diskCacheEntry struct {
+	name string
+	size int64
+}
+
+// NewDiskCache returns a cache in dir holding at most maxBytes, creating dir
+// if needed. Files left in dir by an earlier cache are reused.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &DiskCache{dir: dir, maxBytes: maxBytes, order: list.New(), files: make(map[string]*list.Element)}
+
+	type cached struct {
+		entry diskCacheEntry
+		used  time.Time
+	}
+	var existing []cached
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(d.Name(), ".tmp") {
+			// Left by an interrupted Set
+			return os.Remove(path)
+		}
+		if !isDiskCacheName(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		existing = append(existing, cached{diskCacheEntry{d.Name(), info.Size()}, info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].used.After(existing[j].used) })
+	for _, e := range existing {
+		c.files[e.entry.name] = c.order.PushBack(&diskCacheEntry{e.entry.name, e.entry.size})
+		c.size += e.entry.size
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Get implements Cache
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	name := diskCacheName(key)
+	c.mu.Lock()
+	e, ok := c.files[name]
+	if ok {
+		c.order.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	path := c.path(name)
+	value, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// Modification time orders files by use after a restart
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return value, true
+}
+
+// Set implements Cache. Values larger than the cache are not stored.
+func (c *DiskCache) Set(key string, value []byte) {
+	size := int64(len(value))
+	if size > c.maxBytes {
+		return
+	}
+	name := diskCacheName(key)
+	path := c.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	// Write under a temporary name so readers never see a partial file
+	tmp, err := ioutil.TempFile(filepath.Dir(path), name+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.files[name]; ok {
+		entry := e.Value.(*diskCacheEntry)
+		c.size += size - entry.size
+		entry.size = size
+		c.order.MoveToFront(e)
+	} else {
+		c.files[name] = c.order.PushFront(&diskCacheEntry{name, size})
+		c.size += size
+	}
+	c.evict()
+}
+
+// Size returns the number of bytes stored
+func (c *DiskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// evict removes least recently used files until the cache fits maxBytes.
+// c.mu must be held.
+func (c *DiskCache) evict() {
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*diskCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.files, entry.name)
+		c.size -= entry.size
+		os.Remove(c.path(entry.name))
+	}
+}
+
+// path returns the file of name, spread over subdirectories by its prefix
+func (c *DiskCache) path(name string) string {
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+// diskCacheName returns file name of key
+func diskCacheName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// isDiskCacheName reports whether name is a file name made by diskCacheName,
+// other files in the cache directory are left alone
+func isDiskCacheName(name string) bool {
+	b, err := hex.DecodeString(name)
+	return err == nil && len(b) == sha256.Size && name == strings.ToLower(name)
+}
+
+var (
+	diskCachesMu sync.Mutex
+	diskCaches   = make(map[string]*DiskCache)
+)
+
+// WithDiskCache is WithCache with a DiskCache in dir holding at most
+// maxBytes. Calls with the same dir share one cache.
+func WithDiskCache(dir string, maxBytes int64) Option {
+	diskCachesMu.Lock()
+	defer diskCachesMu.Unlock()
+	c, ok := diskCaches[dir]
+	if !ok {
+		var err error
+		if c, err = NewDiskCache(dir, maxBytes); err != nil {
+			return func(o *options) {
+				o.err = err
+			}
+		}
+		diskCaches[dir] = c
+	}
+	return WithCache(c)
+}
@@ -0,0 +1,89 @@
+package govatar
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 10)
+	assert.NoError(t, err)
+
+	c.Set("a", []byte("aaaa"))
+	c.Set("b", []byte("bbbb"))
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("aaaa"), value)
+	assert.Equal(t, int64(8), c.Size())
+
+	// b is the least recently used and no longer fits
+	c.Set("c", []byte("cccc"))
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, err = os.Stat(c.path(diskCacheName("b")))
+	assert.True(t, os.IsNotExist(err))
+
+	// Too large to store
+	c.Set("d", bytes.Repeat([]byte("d"), 11))
+	_, ok = c.Get("d")
+	assert.False(t, ok)
+
+	c.Set("a", []byte("A"))
+	value, _ = c.Get("a")
+	assert.Equal(t, []byte("A"), value)
+	assert.Equal(t, int64(5), c.Size())
+}
+
+func TestDiskCacheReopen(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 100)
+	assert.NoError(t, err)
+	c.Set("a", []byte("aaaa"))
+	c.Set("b", []byte("bbbb"))
+	old := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(c.path(diskCacheName("a")), old, old))
+
+	foreign := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, ioutil.WriteFile(foreign, []byte("keep me"), 0644))
+	stale := c.path(diskCacheName("a")) + ".123.tmp"
+	assert.NoError(t, ioutil.WriteFile(stale, []byte("c"), 0644))
+
+	// Reopened smaller, the least recently used a is evicted
+	c, err = NewDiskCache(dir, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), c.Size())
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	value, ok := c.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bbbb"), value)
+
+	_, err = os.Stat(foreign)
+	assert.NoError(t, err)
+	_, err = os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWithDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	b, err := GenerateBytesFromUsername(MALE, "username@site.com", "png", WithDiskCache(dir, 1<<20))
+	assert.NoError(t, err)
+	cached, err := GenerateBytesFromUsername(MALE, "username@site.com", "png", WithDiskCache(dir, 1<<20))
+	assert.NoError(t, err)
+	assert.Equal(t, b, cached)
+	assert.Equal(t, int64(len(b)), diskCaches[dir].Size())
+
+	file := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, ioutil.WriteFile(file, nil, 0644))
+	_, err = GenerateBytesFromUsername(MALE, "username@site.com", "png", WithDiskCache(file, 1<<20))
+	assert.Error(t, err)
+}
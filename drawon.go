@@ -0,0 +1,48 @@
+package govatar
+
+import (
+	"image"
+	"image/draw"
+)
+
+// DrawOn draws the avatar generated from username into r of dst, over what
+// is there, for report headers, certificates and leaderboards. WithSize has
+// no effect, the avatar fills r.
+func DrawOn(dst draw.Image, r image.Rectangle, gender Gender, username string, opts ...Option) error {
+	return std().DrawOn(dst, r, gender, username, opts...)
+}
+
+// DrawOn draws the avatar generated from username into r of dst, over what
+// is there. Avatars without shape, frame, badge, logo or pixel art options
+// are scaled from the artwork straight into dst.
+func (g *Generator) DrawOn(dst draw.Image, r image.Rectangle, gender Gender, username string, opts ...Option) error {
+	size := r.Dx()
+	if r.Dy() > size {
+		size = r.Dy()
+	}
+	o, err := g.options(append(opts, WithSize(size)))
+	if err != nil {
+		return err
+	}
+	if !o.seeded {
+		o.seed = usernameSeed(username)
+	}
+	spec, err := g.selectParts(gender, o.seed, o)
+	if err != nil {
+		return err
+	}
+	// Decorations are drawn at the size of r
+	if o.pixelArt == 0 && !o.decorated() {
+		o.size = assetSize
+	}
+	img, err := g.compose(spec, o)
+	if err != nil {
+		return err
+	}
+	if img.Bounds().Size() == r.Size() {
+		draw.Draw(dst, r, img, img.Bounds().Min, draw.Over)
+		return nil
+	}
+	o.filter.scaler().Scale(dst, r, img, img.Bounds(), draw.Over, nil)
+	return nil
+}
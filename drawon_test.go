@@ -0,0 +1,49 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrawOn(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	canvas := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	r := image.Rect(150, 50, 250, 150)
+	assert.NoError(t, g.DrawOn(canvas, r, MALE, "username@site.com"))
+	want, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(want, canvas.SubImage(r)))
+	assert.Equal(t, color.RGBA{}, canvas.At(149, 50))
+	assert.Equal(t, color.RGBA{}, canvas.At(250, 149))
+
+	// Transparent areas show the canvas
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	r = image.Rect(0, 0, 100, 100)
+	assert.NoError(t, g.DrawOn(canvas, r, MALE, "username@site.com", WithShape(Circle)))
+	assert.Equal(t, color.RGBAModel.Convert(color.Black), canvas.At(0, 0))
+	want, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle))
+	assert.NoError(t, err)
+	assert.Equal(t, want.At(50, 50), canvas.At(50, 50))
+
+	assert.Equal(t, errInvalidSize, g.DrawOn(canvas, image.Rectangle{}, MALE, "username@site.com"))
+	assert.Equal(t, errUnknownGender, g.DrawOn(canvas, r, Gender(99), "username@site.com"))
+}
+
+func BenchmarkDrawOn(b *testing.B) {
+	g, err := New(DefaultConfig())
+	if err != nil {
+		b.Fatal(err)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, 1200, 630))
+	r := image.Rect(80, 105, 500, 525)
+	for i := 0; i < b.N; i++ {
+		if err := g.DrawOn(canvas, r, MALE, "username@site.com"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package govatar
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+)
+
+// Editor redraws an avatar as its parts change one at a time, e.g. in a live
+// avatar editor. It keeps every layer decoded together with the composite
+// of the layers below it, so a change re-composites only the layers above
+// the changed one within the area the old and new part cover.
+// An Editor is not safe for concurrent use.
+type Editor struct {
+	gen  *Generator
+	o    options
+	spec Spec
+	// layers holds the decoded part of every layer, nil for a left out background
+	layers []*editorLayer
+	// names of the layers
+	names []string
+	// composites[i] holds layers 0 to i drawn over each other
+	composites []*image.RGBA
+	// decoded caches layers by asset path
+	decoded map[string]*editorLayer
+	img     image.Image
+}
+
+type editorLayer struct {
+	img image.Image
+	// bounds of the pixels the part covers
+	bounds image.Rectangle
+}
+
+// NewEditor returns an editor of the avatar recorded in d drawn by the
+// default generator
+func NewEditor(d Descriptor, opts ...Option) (*Editor, error) {
+	return std().NewEditor(d, opts...)
+}
+
+// NewEditor returns an editor of the avatar recorded in d drawn by g.
+// Size, filter and background options apply to every image it draws.
+func (g *Generator) NewEditor(d Descriptor, opts ...Option) (*Editor, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := g.store.descriptorSpec(d)
+	if err != nil {
+		return nil, err
+	}
+	e := &Editor{gen: g, o: o, decoded: make(map[string]*editorLayer)}
+	if err = e.load(spec); err != nil {
+		return nil, err
+	}
+	e.composite()
+	e.spec = spec
+	e.img = e.finish()
+	return e, nil
+}
+
+// Image returns the avatar as last drawn
+func (e *Editor) Image() image.Image {
+	return e.img
+}
+
+// Descriptor returns the descriptor of the avatar as last drawn
+func (e *Editor) Descriptor() Descriptor {
+	d, _ := e.gen.Describe(e.spec)
+	return d
+}
+
+// Set replaces the part of layer part with the part at index and returns
+// the redrawn avatar. The avatar is left as it was if Set fails.
+func (e *Editor) Set(part Part, index int) (image.Image, error) {
+	return e.SetLayer(part.String(), index)
+}
+
+// SetLayer replaces the part of the layer named layer, e.g. "hat", with the
+// part at index and returns the redrawn avatar. A negative index leaves out
+// an accessory or other extra layer. The avatar is left as it was if
+// SetLayer fails.
+func (e *Editor) SetLayer(layer string, index int) (image.Image, error) {
+	spec := e.spec
+	if i := spec.layer(layer); i != nil {
+		if *i == index {
+			return e.img, nil
+		}
+		*i = index
+	} else {
+		p, err := e.gen.store.person(spec.Gender)
+		if err != nil {
+			return nil, err
+		}
+		if p.layers[layer] == nil {
+			return nil, errInvalidDescriptor
+		}
+		current, ok := spec.Accessories[layer]
+		if ok && current == index || !ok && index < 0 {
+			return e.img, nil
+		}
+		spec.setAccessory(layer, index)
+	}
+	names, layers := e.names, e.layers
+	if err := e.load(spec); err != nil {
+		return nil, err
+	}
+	e.spec = spec
+	if strings.Join(names, " ") != strings.Join(e.names, " ") {
+		// A rule left out or brought back a part
+		e.composite()
+		e.img = e.finish()
+		return e.img, nil
+	}
+	// The changed part and the parts of dependent layers it pulls in
+	from, dirty := -1, image.Rectangle{}
+	for l := range e.layers {
+		if e.layers[l] != layers[l] {
+			if from < 0 {
+				from = l
+			}
+			dirty = dirty.Union(layers[l].covered()).Union(e.layers[l].covered())
+		}
+	}
+	if from < 0 {
+		if layerIndex(e.names, layer) < 0 {
+			// The layer is left out
+			return e.img, nil
+		}
+		from = len(e.layers)
+	}
+	for l := from; l < len(e.layers); l++ {
+		e.redraw(l, dirty)
+	}
+	e.img = e.finish()
+	return e.img, nil
+}
+
+// load decodes the parts of spec into e.layers
+func (e *Editor) load(spec Spec) error {
+	names, assets, err := e.gen.store.drawnLayout(spec)
+	if err != nil {
+		return err
+	}
+	names, assets = e.o.arrange(names, assets)
+	layers := make([]*editorLayer, len(assets))
+	for i, asset := range assets {
+		if i == 0 && !e.o.background {
+			continue
+		}
+		if layers[i], err = e.decode(asset, e.o.tint(spec, names[i])); err != nil {
+			return err
+		}
+	}
+	e.layers, e.names = layers, names
+	return nil
+}
+
+// decode returns asset decoded and recolored by tint if not nil, decoding
+// it on first use
+func (e *Editor) decode(asset string, tint *Tint) (*editorLayer, error) {
+	key := asset
+	if tint != nil {
+		key = fmt.Sprintf("%s %v", asset, *tint)
+	}
+	if l, ok := e.decoded[key]; ok {
+		return l, nil
+	}
+	img, err := e.gen.store.decode(asset)
+	if err != nil {
+		return nil, err
+	}
+	if tint != nil {
+		img = tint.apply(img)
+	}
+	l := &editorLayer{img: img, bounds: opaqueBounds(img)}
+	e.decoded[key] = l
+	return l, nil
+}
+
+// composite draws the composites of all layers
+func (e *Editor) composite() {
+	e.composites = e.composites[:0]
+	for i := range e.layers {
+		e.composites = append(e.composites, image.NewRGBA(image.Rect(0, 0, assetSize, assetSize)))
+		e.redraw(i, e.composites[i].Bounds())
+	}
+}
+
+// redraw draws composite i within r from the composite below it and layer i
+func (e *Editor) redraw(i int, r image.Rectangle) {
+	dst := e.composites[i]
+	if i == 0 {
+		draw.Draw(dst, r, image.Transparent, image.Point{}, draw.Src)
+	} else {
+		draw.Draw(dst, r, e.composites[i-1], r.Min, draw.Src)
+	}
+	if l := e.layers[i]; l != nil {
+		draw.Draw(dst, r, l.img, r.Min, draw.Over)
+	}
+}
+
+// finish scales the top composite to the avatar size, or turns it into
+// pixel art, and fills the background the way compose does
+func (e *Editor) finish() image.Image {
+	top := e.composites[len(e.composites)-1]
+	var img image.Image
+	switch {
+	case e.o.pixelArt > 0:
+		img = e.o.pixelate(top)
+	case e.o.size != assetSize:
+		scaled := image.NewRGBA(image.Rect(0, 0, e.o.size, e.o.size))
+		e.o.filter.scaler().Scale(scaled, scaled.Bounds(), top, top.Bounds(), draw.Src, nil)
+		img = scaled
+	default:
+		// Callers must not see later changes
+		copied := image.NewRGBA(top.Bounds())
+		copy(copied.Pix, top.Pix)
+		img = copied
+	}
+	if e.o.background || e.o.transparent {
+		return e.o.finish(img)
+	}
+	dst := image.NewRGBA(img.Bounds())
+	e.o.backdrop(dst, e.spec)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return e.o.finish(dst)
+}
+
+// covered returns the area l draws on, empty for a left out layer
+func (l *editorLayer) covered() image.Rectangle {
+	if l == nil {
+		return image.Rectangle{}
+	}
+	return l.bounds
+}
+
+// opaqueBounds returns the smallest rectangle holding all pixels of img that
+// are not fully transparent
+func opaqueBounds(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X, b.Min.Y
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a == 0 {
+				continue
+			}
+			if x < minX {
+				minX = x
+			}
+			if x >= maxX {
+				maxX = x + 1
+			}
+			if y < minY {
+				minY = y
+			}
+			maxY = y + 1
+		}
+	}
+	if minX >= maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(minX, minY, maxX, maxY)
+}
@@ -0,0 +1,116 @@
+package govatar
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditor(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithSize(64)},
+		{WithSize(64), WithoutBackground()},
+		{WithTransparent()},
+	} {
+		d, err := Describe(Spec{Gender: FEMALE, Face: 1, Clothes: 2, Hair: 3})
+		assert.NoError(t, err)
+		e, err := NewEditor(d, opts...)
+		assert.NoError(t, err)
+		expected, err := RenderDescriptor(d, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, e.Image())
+
+		for _, change := range []struct {
+			part  Part
+			index int
+		}{{HAIR, 5}, {FACE, 0}, {MOUTH, 4}, {EYE, 2}, {CLOTHES, 7}, {HAIR, 3}} {
+			img, err := e.Set(change.part, change.index)
+			assert.NoError(t, err)
+			d = d.With(change.part, change.index)
+			expected, err := RenderDescriptor(d, opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, img)
+			assert.Equal(t, img, e.Image())
+		}
+		spec, err := std().store.descriptorSpec(e.Descriptor())
+		assert.NoError(t, err)
+		assert.Equal(t, Spec{Gender: FEMALE, Clothes: 7, Mouth: 4, Hair: 3, Eye: 2}, spec)
+	}
+}
+
+func TestEditorErrors(t *testing.T) {
+	e, err := NewEditor(Descriptor{Gender: MALE})
+	assert.NoError(t, err)
+	before := e.Image()
+	_, err = e.Set(HAIR, 10000)
+	assert.Equal(t, errInvalidSpec, err)
+	_, err = e.Set(Part(9), 0)
+	assert.Equal(t, errInvalidDescriptor, err)
+	assert.Equal(t, before, e.Image())
+	img, err := e.Set(HAIR, 1)
+	assert.NoError(t, err)
+	expected, err := GenerateFromSpec(Spec{Gender: MALE, Hair: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	_, err = NewEditor(Descriptor{Gender: MALE}, WithSize(-1))
+	assert.Equal(t, errInvalidSize, err)
+	_, err = NewEditor(Descriptor{Gender: Gender(7)})
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestEditorSetLayer(t *testing.T) {
+	d, err := Describe(Spec{Gender: MALE, Face: 1, Hair: 3})
+	assert.NoError(t, err)
+	e, err := NewEditor(d, WithSize(64))
+	assert.NoError(t, err)
+	for _, change := range []struct {
+		layer string
+		index int
+	}{{"hat", 1}, {"glasses", 0}, {"hat", 0}, {"hair", 5}, {"hat", -1}, {"beard", -1}} {
+		img, err := e.SetLayer(change.layer, change.index)
+		assert.NoError(t, err)
+		d = d.WithLayer(change.layer, change.index)
+		expected, err := RenderDescriptor(d, WithSize(64))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, img, change.layer)
+	}
+	spec, err := std().store.descriptorSpec(e.Descriptor())
+	assert.NoError(t, err)
+	assert.Equal(t, Spec{Gender: MALE, Face: 1, Hair: 5, Accessories: map[string]int{"glasses": 0}}, spec)
+
+	before := e.Image()
+	_, err = e.SetLayer("hat", 100)
+	assert.Equal(t, errInvalidSpec, err)
+	_, err = e.SetLayer("tattoo", 0)
+	assert.Equal(t, errInvalidDescriptor, err)
+	assert.Equal(t, before, e.Image())
+}
+
+func TestOpaqueBounds(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	assert.Equal(t, image.Rectangle{}, opaqueBounds(img))
+	img.Pix[img.PixOffset(2, 3)+3] = 1
+	img.Pix[img.PixOffset(6, 1)+3] = 255
+	assert.Equal(t, image.Rect(2, 1, 7, 4), opaqueBounds(img))
+}
+
+func BenchmarkEditorSet(b *testing.B) {
+	e, err := NewEditor(Descriptor{Gender: MALE})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Set(EYE, i%2)
+	}
+}
+
+func BenchmarkRenderDescriptor(b *testing.B) {
+	d := Descriptor{Gender: MALE}
+	for i := 0; i < b.N; i++ {
+		RenderDescriptor(d.With(EYE, i%2))
+	}
+}
@@ -0,0 +1,34 @@
+package govatar
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed data/background
+var backgroundAssets embed.FS
+
+//go:embed data/accessories
+var accessoryAssets embed.FS
+
+// embeddedAssets holds the asset trees compiled into the binary. Build tags
+// choose which genders are included:
+//
+//	govatar_no_male, govatar_no_female, govatar_no_monster leave one out
+//	govatar_male_only, govatar_female_only, govatar_monster_only keep one
+var embeddedAssets = unionFS{backgroundAssets, accessoryAssets}
+
+// embeddedGenders lists genders whose assets are compiled into the binary
+var embeddedGenders = map[Gender]bool{}
+
+// unionFS serves files from the first of its file systems that has them
+type unionFS []fs.FS
+
+func (u unionFS) Open(name string) (fs.File, error) {
+	for _, fsys := range u {
+		if f, err := fsys.Open(name); err == nil {
+			return f, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
@@ -0,0 +1,19 @@
+//go:build !govatar_no_emoji
+
+package govatar
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed data/emoji
+var emojiEmbed embed.FS
+
+func init() {
+	sub, err := fs.Sub(emojiEmbed, "data/emoji")
+	if err != nil {
+		panic(err)
+	}
+	emojiAssets = sub
+}
@@ -0,0 +1,13 @@
+//go:build !govatar_no_female && !govatar_male_only && !govatar_monster_only
+
+package govatar
+
+import "embed"
+
+//go:embed data/female
+var femaleAssets embed.FS
+
+func init() {
+	embeddedAssets = append(embeddedAssets, femaleAssets)
+	embeddedGenders[FEMALE] = true
+}
@@ -0,0 +1,13 @@
+//go:build !govatar_no_male && !govatar_female_only && !govatar_monster_only
+
+package govatar
+
+import "embed"
+
+//go:embed data/male
+var maleAssets embed.FS
+
+func init() {
+	embeddedAssets = append(embeddedAssets, maleAssets)
+	embeddedGenders[MALE] = true
+}
@@ -0,0 +1,13 @@
+//go:build !govatar_no_monster && !govatar_male_only && !govatar_female_only
+
+package govatar
+
+import "embed"
+
+//go:embed data/monster
+var monsterAssets embed.FS
+
+func init() {
+	embeddedAssets = append(embeddedAssets, monsterAssets)
+	embeddedGenders[MONSTER] = true
+}
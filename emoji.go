@@ -0,0 +1,111 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/fs"
+	"math/rand"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errEmojiNotEmbedded = errors.New("Emoji not embedded")
+
+// emojiAssets holds the curated emoji artwork, one file per emoji named by
+// its code point in hex. It is nil when built with the govatar_no_emoji tag.
+var emojiAssets fs.FS
+
+// emojiScale is the part of the avatar width covered by the emoji
+const emojiScale = 0.7
+
+// Emojis lists the emoji GenerateEmoji picks from
+func Emojis() []string {
+	files := emojiFiles()
+	emojis := make([]string, len(files))
+	for i, file := range files {
+		emojis[i] = emojiRune(file)
+	}
+	return emojis
+}
+
+// emojiFiles returns emoji asset names in code point order
+func emojiFiles() []string {
+	if emojiAssets == nil {
+		return nil
+	}
+	entries, err := fs.ReadDir(emojiAssets, ".")
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		if path.Ext(entry.Name()) == ".png" {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return emojiCode(files[i]) < emojiCode(files[j])
+	})
+	return files
+}
+
+func emojiCode(file string) uint64 {
+	code, _ := strconv.ParseUint(strings.TrimSuffix(file, path.Ext(file)), 16, 32)
+	return code
+}
+
+func emojiRune(file string) string {
+	return string(rune(emojiCode(file)))
+}
+
+// GenerateEmoji draws an emoji picked from the username hash large on a
+// background colored from it, for anonymous commenters and the like
+func GenerateEmoji(username string, opts ...Option) (image.Image, error) {
+	return std().GenerateEmoji(username, opts...)
+}
+
+// GenerateEmoji draws an emoji picked from the username hash large on a
+// background colored from it. WithSeed picks by seed instead. It needs no
+// assets beside the emoji compiled into the binary.
+func (g *Generator) GenerateEmoji(username string, opts ...Option) (image.Image, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	files := emojiFiles()
+	if len(files) == 0 {
+		return nil, errEmojiNotEmbedded
+	}
+	seed := usernameSeed(username)
+	if o.seeded {
+		seed = o.seed
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	f, err := emojiAssets.Open(files[rnd.Intn(len(files))])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	emoji, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	bg := color.Color(NewScheme(seed).Background)
+	switch {
+	case o.transparent:
+		bg = color.Transparent
+	case !o.background:
+		bg = color.White
+	}
+	img := image.NewRGBA(image.Rect(0, 0, o.size, o.size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	side := int(float64(o.size) * emojiScale)
+	at := (o.size - side) / 2
+	o.filter.scaler().Scale(img, image.Rect(at, at, at+side, at+side), emoji, emoji.Bounds(), draw.Over, nil)
+	return o.finish(o.pixelate(img)), nil
+}
@@ -0,0 +1,63 @@
+//go:build !govatar_no_emoji
+
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmojis(t *testing.T) {
+	emojis := Emojis()
+	assert.Len(t, emojis, 16)
+	assert.Contains(t, emojis, "😀")
+	assert.Contains(t, emojis, "❤")
+	assert.Equal(t, "❤", emojis[0])
+}
+
+func TestGenerateEmoji(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	img, err := g.GenerateEmoji("anonymous-42", WithSize(100))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 100, 100), img.Bounds())
+	seed := usernameSeed("anonymous-42")
+	assert.Equal(t, color.Color(NewScheme(seed).Background), img.At(2, 2))
+
+	again, err := g.GenerateEmoji("anonymous-42", WithSize(100))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(img, again))
+	seeded, err := g.GenerateEmoji("someone else", WithSize(100), WithSeed(seed))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(img, seeded))
+
+	// Every emoji is picked by some username
+	picked := map[string]bool{}
+	files := emojiFiles()
+	for i := 0; i < 500; i++ {
+		rnd := rand.New(rand.NewSource(usernameSeed("user" + strconv.Itoa(i))))
+		picked[files[rnd.Intn(len(files))]] = true
+	}
+	assert.Len(t, picked, len(files))
+
+	img, err = g.GenerateEmoji("anonymous-42", WithTransparent())
+	assert.NoError(t, err)
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	_, err = g.GenerateEmoji("anonymous-42", WithSize(0))
+	assert.Equal(t, errInvalidSize, err)
+}
+
+func TestGenerateEmojiNotEmbedded(t *testing.T) {
+	assets := emojiAssets
+	emojiAssets = nil
+	defer func() { emojiAssets = assets }()
+	assert.Empty(t, Emojis())
+	_, err := GenerateEmoji("anonymous-42")
+	assert.Equal(t, errEmojiNotEmbedded, err)
+}
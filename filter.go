@@ -0,0 +1,53 @@
+package govatar
+
+import (
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Filter selects how avatars are resampled from the asset size
+type Filter int
+
+// Resampling filters. CatmullRom is sharp and fast, Lanczos keeps a bit
+// more detail when downsampling and Nearest keeps the pixel art edges
+// crisp when upsampling by whole factors.
+const (
+	CatmullRom Filter = iota
+	LANCZOS
+	NEAREST
+)
+
+// lanczos is the Lanczos kernel with 3 lobes
+var lanczos = &xdraw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t < 0 {
+		t = -t
+	}
+	if t == 0 {
+		return 1
+	}
+	if t >= 3 {
+		return 0
+	}
+	x := math.Pi * t
+	return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+}}
+
+// scaler returns the x/image scaler implementing f
+func (f Filter) scaler() xdraw.Scaler {
+	switch f {
+	case LANCZOS:
+		return lanczos
+	case NEAREST:
+		return xdraw.NearestNeighbor
+	default:
+		return xdraw.CatmullRom
+	}
+}
+
+// WithFilter sets the filter used to resample the avatar to its size
+func WithFilter(f Filter) Option {
+	return func(o *options) {
+		o.filter = f
+	}
+}
@@ -0,0 +1,41 @@
+package govatar
+
+import (
+	"testing"
+
+	"github.com/recoilme/govatar/imgdiff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilters(t *testing.T) {
+	for _, size := range []int{64, 128, 512} {
+		for _, f := range []Filter{CatmullRom, LANCZOS, NEAREST} {
+			avatar, err := GenerateFromUsername(MALE, "username@site.com", WithSize(size), WithFilter(f))
+			assert.NoError(t, err)
+			assert.Equal(t, size, avatar.Bounds().Dx())
+			assert.Equal(t, size, avatar.Bounds().Dy())
+		}
+	}
+
+	// Lanczos and Catmull-Rom agree up to ringing at edges
+	catmullRom, err := GenerateFromUsername(MALE, "username@site.com", WithSize(128))
+	assert.NoError(t, err)
+	lanczos, err := GenerateFromUsername(MALE, "username@site.com", WithSize(128), WithFilter(LANCZOS))
+	assert.NoError(t, err)
+	result, err := imgdiff.Compare(catmullRom, lanczos, 32)
+	assert.NoError(t, err)
+	assert.True(t, result.Ratio() < 0.05)
+
+	_, err = Generate(MALE, WithFilter(Filter(100)))
+	assert.Equal(t, errInvalidFilter, err)
+}
+
+func TestNearestFilter(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	doubled, err := GenerateFromUsername(MALE, "username@site.com", WithSize(800), WithFilter(NEAREST))
+	assert.NoError(t, err)
+	for _, p := range [][2]int{{0, 0}, {100, 150}, {200, 200}, {399, 399}} {
+		assert.Equal(t, avatar.At(p[0], p[1]), doubled.At(2*p[0], 2*p[1]))
+	}
+}
@@ -0,0 +1,111 @@
+package govatar
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+var errInvalidFrame = errors.New("Invalid frame")
+
+// frame is a band drawn along the outline of the avatar
+type frame struct {
+	width  int
+	colors []color.Color
+}
+
+// WithFrame draws a frame of width pixels along the outline of the avatar,
+// a ring on circles and rounded squares. More than one color gives a
+// gradient running from the top left to the bottom right corner, like story
+// rings or badges of paying users.
+func WithFrame(width int, colors ...color.Color) Option {
+	return func(o *options) {
+		o.frame = &frame{width: width, colors: colors}
+		if width < 1 || len(colors) == 0 {
+			o.err = errInvalidFrame
+		}
+		for _, c := range colors {
+			if c == nil {
+				o.err = errInvalidFrame
+			}
+		}
+	}
+}
+
+// at returns the color of f at position t from 0 to 1 along its gradient
+func (f *frame) at(t float64) color.RGBA64 {
+	if len(f.colors) == 1 {
+		return color.RGBA64Model.Convert(f.colors[0]).(color.RGBA64)
+	}
+	pos := t * float64(len(f.colors)-1)
+	i := int(pos)
+	if i >= len(f.colors)-1 {
+		i = len(f.colors) - 2
+	}
+	a := color.RGBA64Model.Convert(f.colors[i]).(color.RGBA64)
+	b := color.RGBA64Model.Convert(f.colors[i+1]).(color.RGBA64)
+	k := pos - float64(i)
+	mix := func(a, b uint16) uint16 { return uint16(float64(a)*(1-k) + float64(b)*k + 0.5) }
+	return color.RGBA64{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// draw draws f over img along the outline of its bounds with corners rounded
+// by radius. The outer edge is left to the shape mask.
+func (f *frame) draw(img *image.RGBA, radius float64) {
+	b := img.Bounds()
+	radius = fitRadius(b, radius)
+	span := float64(b.Dx() + b.Dy() - 2)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			coverage := edgeDistance(b, radius, x, y) + float64(f.width) + 0.5
+			if coverage <= 0 {
+				continue
+			}
+			if coverage > 1 {
+				coverage = 1
+			}
+			c := f.at(float64(x-b.Min.X+y-b.Min.Y) / span)
+			// Source over with the color scaled by coverage
+			i := img.PixOffset(x, y)
+			alpha := float64(c.A) / 0xffff * coverage
+			for j, v := range []uint16{c.R, c.G, c.B, c.A} {
+				src := float64(v) / 0x101 * coverage
+				img.Pix[i+j] = uint8(src + float64(img.Pix[i+j])*(1-alpha) + 0.5)
+			}
+		}
+	}
+}
+
+// svg writes f as an SVG stroke of width along the outline of an svgGrid
+// document with corners rounded by radius, all in grid units
+func (f *frame) svg(b *strings.Builder, radius, width float64) {
+	if radius > svgGrid/2 {
+		radius = svgGrid / 2
+	}
+	rx := radius - width/2
+	if rx < 0 {
+		rx = 0
+	}
+	stroke := svgPaint(f.colors[0])
+	if len(f.colors) > 1 {
+		b.WriteString(`<linearGradient id="` + svgID + `frame" x1="0" y1="0" x2="1" y2="1">`)
+		for i, c := range f.colors {
+			fmt.Fprintf(b, `<stop offset="%g" stop-color="%s"/>`, float64(i)/float64(len(f.colors)-1), svgPaint(c))
+		}
+		b.WriteString(`</linearGradient>`)
+		stroke = "url(#" + svgID + "frame)"
+	}
+	fmt.Fprintf(b, `<rect x="%g" y="%g" width="%g" height="%g" rx="%g" fill="none" stroke="%s" stroke-width="%g" shape-rendering="geometricPrecision"/>`,
+		width/2, width/2, svgGrid-width, svgGrid-width, rx, stroke, width)
+}
+
+// svgPaint returns c as an SVG color, with alpha when translucent
+func svgPaint(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return hexColor(color.RGBA{n.R, n.G, n.B, 0xff})
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3g)", n.R, n.G, n.B, float64(n.A)/0xff)
+}
@@ -0,0 +1,66 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	frameRed  = color.RGBA{0xff, 0, 0, 0xff}
+	frameBlue = color.RGBA{0, 0, 0xff, 0xff}
+)
+
+func TestFrameDraw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	f := &frame{width: 6, colors: []color.Color{frameRed}}
+	f.draw(img, 0)
+	assert.Equal(t, frameRed, img.RGBAAt(0, 0))
+	assert.Equal(t, frameRed, img.RGBAAt(5, 50))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(6, 50))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(50, 50))
+
+	// Gradients run from the top left to the bottom right corner
+	f = &frame{width: 6, colors: []color.Color{frameRed, frameBlue}}
+	assert.Equal(t, color.RGBA64{0xffff, 0, 0, 0xffff}, f.at(0))
+	assert.Equal(t, color.RGBA64{0, 0, 0xffff, 0xffff}, f.at(1))
+	assert.Equal(t, color.RGBA64{0x8000, 0, 0x8000, 0xffff}, f.at(0.5))
+}
+
+func TestWithFrame(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithFrame(4, frameRed))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(frameRed), img.At(0, 50))
+	assert.Equal(t, color.RGBAModel.Convert(frameRed), img.At(99, 99))
+
+	// The frame follows circles
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithFrame(4, frameRed, frameBlue))
+	assert.NoError(t, err)
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	r, _, b, _ := img.At(1, 50).RGBA()
+	assert.True(t, r > b && b > 0x2000, "%x %x", r, b)
+	top := color.RGBAModel.Convert(img.At(50, 1)).(color.RGBA)
+	bottom := color.RGBAModel.Convert(img.At(50, 98)).(color.RGBA)
+	assert.True(t, top.R > bottom.R && top.B < bottom.B)
+
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithFrame(5, frameRed, frameBlue))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `<stop offset="1" stop-color="#0000ff"/>`)
+	id := regexp.MustCompile(`<linearGradient id="(gv[0-9a-f]{8}-frame)"`).FindStringSubmatch(svg)
+	if assert.Len(t, id, 2) {
+		assert.Contains(t, svg, `stroke="url(#`+id[1]+`)" stroke-width="2"`)
+	}
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithFrame(0, frameRed))
+	assert.Equal(t, errInvalidFrame, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithFrame(4))
+	assert.Equal(t, errInvalidFrame, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithFrame(4, nil))
+	assert.Equal(t, errInvalidFrame, err)
+}
@@ -0,0 +1,49 @@
+package govatar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseGender returns the gender named male, female, monster, neutral or a
+// registered style, or m and f for short, ignoring case
+func ParseGender(name string) (Gender, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "male", "m":
+		return MALE, nil
+	case "female", "f":
+		return FEMALE, nil
+	}
+	if g, ok := LookupStyle(strings.ToLower(strings.TrimSpace(name))); ok {
+		return g, nil
+	}
+	return 0, errUnknownGender
+}
+
+// String returns the name of g: male, female, monster, neutral or a registered style
+func (g Gender) String() string {
+	if name := genderName(g); name != "" {
+		return name
+	}
+	return fmt.Sprintf("Gender(%d)", int(g))
+}
+
+// MarshalText encodes g by name, so genders appear as names in JSON, YAML
+// and other text based formats
+func (g Gender) MarshalText() ([]byte, error) {
+	name := genderName(g)
+	if name == "" {
+		return nil, errUnknownGender
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText decodes a gender name accepted by ParseGender
+func (g *Gender) UnmarshalText(text []byte) error {
+	parsed, err := ParseGender(string(text))
+	if err != nil {
+		return err
+	}
+	*g = parsed
+	return nil
+}
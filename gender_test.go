@@ -0,0 +1,58 @@
+package govatar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGender(t *testing.T) {
+	for name, expected := range map[string]Gender{
+		"male": MALE, "m": MALE, "Male": MALE,
+		"female": FEMALE, "f": FEMALE, " FEMALE ": FEMALE,
+		"monster": MONSTER, "neutral": NEUTRAL,
+	} {
+		g, err := ParseGender(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, g, name)
+	}
+	for _, name := range []string{"", "x", "alien"} {
+		_, err := ParseGender(name)
+		assert.Equal(t, errUnknownGender, err, name)
+	}
+}
+
+func TestGenderString(t *testing.T) {
+	assert.Equal(t, "male", MALE.String())
+	assert.Equal(t, "female", FEMALE.String())
+	assert.Equal(t, "monster", MONSTER.String())
+	assert.Equal(t, "neutral", NEUTRAL.String())
+	assert.Equal(t, "Gender(7)", Gender(7).String())
+	for _, g := range []Gender{MALE, FEMALE, MONSTER, NEUTRAL} {
+		parsed, err := ParseGender(g.String())
+		assert.NoError(t, err)
+		assert.Equal(t, g, parsed)
+	}
+}
+
+func TestGenderJSON(t *testing.T) {
+	var v struct {
+		Gender  Gender            `json:"gender"`
+		Genders map[Gender]string `json:"genders"`
+	}
+	v.Gender = MONSTER
+	v.Genders = map[Gender]string{FEMALE: "f"}
+	data, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"gender":"monster","genders":{"female":"f"}}`, string(data))
+
+	v.Gender, v.Genders = MALE, nil
+	assert.NoError(t, json.Unmarshal(data, &v))
+	assert.Equal(t, MONSTER, v.Gender)
+	assert.Equal(t, map[Gender]string{FEMALE: "f"}, v.Genders)
+
+	assert.Equal(t, errUnknownGender, json.Unmarshal([]byte(`{"gender":"alien"}`), &v))
+	_, err = json.Marshal(Gender(7))
+	assert.Error(t, err)
+}
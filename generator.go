@@ -0,0 +1,292 @@
+package govatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errUnknownFormat = errors.New("Unknown image format")
+
+// Generator generates avatars from its own assets and settings.
+// It is safe for concurrent use.
+type Generator struct {
+	store  *store
+	config Config
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New validates c and returns a generator with assets loaded from c.AssetsPath
+func New(c Config) (*Generator, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return newGenerator(loadConfigStore(c), c), nil
+}
+
+// loadConfigStore loads the assets in c.AssetsPath and adds c.Rules
+func loadConfigStore(c Config) *store {
+	s := loadStore(dirSource{}, c.AssetsPath)
+	rules, _ := parseRules(c.Rules)
+	s.addRules(rules)
+	s.accessories = c.Accessories
+	return s
+}
+
+// NewFromFS returns a generator with default settings drawing assets from
+// fsys, which has the layout of the data directory: background, male/face,
+// male/clothes and so on. Use fs.Sub to pass a subdirectory of an embed.FS.
+func NewFromFS(fsys fs.FS) (*Generator, error) {
+	for _, dir := range assetDirs() {
+		info, err := fs.Stat(fsys, dir)
+		if err != nil || !info.IsDir() {
+			return nil, ErrAssetsNotFound
+		}
+	}
+	c := DefaultConfig()
+	c.AssetsPath = ""
+	return newGenerator(loadStore(fsSource{fsys}, "."), c), nil
+}
+
+func newGenerator(s *store, c Config) *Generator {
+	return &Generator{store: s, config: c, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+var (
+	defaultOnce      sync.Once
+	defaultGenerator *Generator
+)
+
+// std returns the generator behind package level functions
+func std() *Generator {
+	defaultOnce.Do(func() {
+		if defaultGenerator == nil {
+			defaultGenerator = newGenerator(loadStore(dirSource{}, DefaultConfig().AssetsPath), DefaultConfig())
+		}
+	})
+	return defaultGenerator
+}
+
+// Config returns the settings of g
+func (g *Generator) Config() Config {
+	return g.config
+}
+
+// Generate generates random avatar
+func (g *Generator) Generate(gender Gender, opts ...Option) (image.Image, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	if !o.seeded {
+		g.mu.Lock()
+		o.seed = g.rnd.Int63()
+		g.mu.Unlock()
+	}
+	spec, err := g.selectParts(gender, o.seed, o)
+	if err != nil {
+		return nil, err
+	}
+	return g.compose(spec, o)
+}
+
+// GenerateFile generates random avatar and save it to specified file.
+// Image format depends on file extension (jpeg, jpg, png, gif, webp, avif). Default is png
+func (g *Generator) GenerateFile(gender Gender, filePath string, opts ...Option) error {
+	_, err := g.GenerateFileWithChecksum(gender, filePath, opts...)
+	return err
+}
+
+// GenerateFromUsername generates avatar from string
+func (g *Generator) GenerateFromUsername(gender Gender, username string, opts ...Option) (image.Image, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	span := o.startSpan("govatar.Hash")
+	if !o.seeded {
+		o.seed = usernameSeed(username)
+	}
+	spec, err := g.selectParts(gender, o.seed, o)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return g.compose(spec, o)
+}
+
+// SpecFromUsername returns the spec of the avatar generated from string
+func (g *Generator) SpecFromUsername(gender Gender, username string) (Spec, error) {
+	return g.store.randomSpec(gender, usernameSeed(username))
+}
+
+// GenerateFromSpec generates avatar from the parts listed in spec.
+// WithSeed has no effect here.
+func (g *Generator) GenerateFromSpec(spec Spec, opts ...Option) (image.Image, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	return g.compose(spec, o)
+}
+
+// GenerateTo generates random avatar and writes it to w encoded in format
+// (png, jpeg, jpg, gif, webp, avif)
+func (g *Generator) GenerateTo(w io.Writer, gender Gender, format string, opts ...Option) error {
+	if !knownFormat(format) {
+		return errUnknownFormat
+	}
+	img, err := g.Generate(gender, opts...)
+	if err != nil {
+		return err
+	}
+	return g.encode(w, img, format, opts)
+}
+
+// GenerateBytes generates random avatar encoded in format (png, jpeg, jpg, gif, webp, avif)
+func (g *Generator) GenerateBytes(gender Gender, format string, opts ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := g.GenerateTo(&buf, gender, format, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateToFromUsername generates avatar from string and writes it to w
+// encoded in format (png, jpeg, jpg, gif, webp, avif)
+func (g *Generator) GenerateToFromUsername(w io.Writer, gender Gender, username string, format string, opts ...Option) error {
+	if !knownFormat(format) {
+		return errUnknownFormat
+	}
+	o, err := g.options(opts)
+	if err != nil {
+		return err
+	}
+	if o.cache != nil {
+		if (o.descriptor != nil || o.traits != nil) && o.renderer == nil {
+			// The avatar may come from the cache without being composed
+			if !o.seeded {
+				o.seed = usernameSeed(username)
+			}
+			spec, err := g.selectParts(gender, o.seed, o)
+			if err != nil {
+				return err
+			}
+			if err = g.describe(spec, o); err != nil {
+				return err
+			}
+		}
+		return g.generateCached(w, o.cache, g.cacheKey(gender, username, format, o), func(w io.Writer) error {
+			img, err := g.GenerateFromUsername(gender, username, opts...)
+			if err != nil {
+				return err
+			}
+			return g.encode(w, img, format, opts)
+		})
+	}
+	img, err := g.GenerateFromUsername(gender, username, opts...)
+	if err != nil {
+		return err
+	}
+	return g.encode(w, img, format, opts)
+}
+
+// generateCached writes the value cached for key to w. On a miss it is
+// generated by write and cached.
+func (g *Generator) generateCached(w io.Writer, c Cache, key string, write func(w io.Writer) error) error {
+	if body, ok := c.Get(key); ok {
+		_, err := w.Write(body)
+		return err
+	}
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	c.Set(key, buf.Bytes())
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GenerateBytesFromUsername generates avatar from string encoded in format
+// (png, jpeg, jpg, gif, webp, avif)
+func (g *Generator) GenerateBytesFromUsername(gender Gender, username string, format string, opts ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := g.GenerateToFromUsername(&buf, gender, username, format, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateFileFromUsername generates avatar from string and save it to specified file.
+// Image format depends on file extension (jpeg, jpg, png, gif, webp, avif). Default is png
+func (g *Generator) GenerateFileFromUsername(gender Gender, username string, filePath string, opts ...Option) error {
+	_, err := g.GenerateFileFromUsernameWithChecksum(gender, username, filePath, opts...)
+	return err
+}
+
+// GenerateFileWithChecksum generates random avatar, saves it to specified file
+// and returns hex encoded SHA-256 of the written bytes
+func (g *Generator) GenerateFileWithChecksum(gender Gender, filePath string, opts ...Option) (string, error) {
+	img, err := g.Generate(gender, opts...)
+	if err != nil {
+		return "", err
+	}
+	return g.saveToFile(img, filePath, opts)
+}
+
+// GenerateFileFromUsernameWithChecksum generates avatar from string, saves it to
+// specified file and returns hex encoded SHA-256 of the written bytes
+func (g *Generator) GenerateFileFromUsernameWithChecksum(gender Gender, username string, filePath string, opts ...Option) (string, error) {
+	img, err := g.GenerateFromUsername(gender, username, opts...)
+	if err != nil {
+		return "", err
+	}
+	return g.saveToFile(img, filePath, opts)
+}
+
+// saveToFile encodes img to filePath with encoder settings from opts and
+// returns hex encoded SHA-256 of the written bytes
+func (g *Generator) saveToFile(img image.Image, filePath string, opts []Option) (string, error) {
+	outFile, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer outFile.Close()
+	h := sha256.New()
+	if err := g.encode(io.MultiWriter(outFile, h), img, filepath.Ext(filePath), opts); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// encode writes img to w in format with encoder settings from opts
+func (g *Generator) encode(w io.Writer, img image.Image, format string, opts []Option) error {
+	o, err := g.options(opts)
+	if err != nil {
+		return err
+	}
+	if err = o.context().Err(); err != nil {
+		return err
+	}
+	span := o.startSpan("govatar.Encode", attribute.String("govatar.format", normalizeFormat(format)))
+	if o.renderer != nil {
+		err = o.renderer.Encode(w, o.flatten(img, format), format)
+	} else {
+		err = encode(w, o.flatten(img, format), format, o.quality, o.lossless)
+	}
+	endSpan(span, err)
+	return err
+}
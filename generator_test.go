@@ -0,0 +1,207 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gen2brain/avif"
+	"github.com/recoilme/govatar/imgdiff"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+func TestNew(t *testing.T) {
+	c := DefaultConfig()
+	c.Size = 0
+	_, err := New(c)
+	assert.Equal(t, errInvalidSize, err)
+
+	c = DefaultConfig()
+	c.Size = 64
+	g, err := New(c)
+	assert.NoError(t, err)
+	assert.Equal(t, c, g.Config())
+
+	avatar, err := g.GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 64, avatar.Bounds().Dx())
+
+	// Package level functions are unaffected
+	avatar, err = GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, avatar.Bounds().Dx())
+}
+
+func TestGeneratorSpec(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+
+	spec, err := g.SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	expected, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, spec)
+
+	_, err = g.Generate(Gender(100))
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestGeneratorConcurrentGenerate(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := g.Generate(MONSTER)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewFromFS(t *testing.T) {
+	g, err := NewFromFS(os.DirFS("data"))
+	assert.NoError(t, err)
+	avatar, err := g.GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	expected, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, avatar)
+
+	sub, err := fs.Sub(embeddedAssets, "data")
+	assert.NoError(t, err)
+	if len(embeddedGenders) == 3 {
+		_, err = NewFromFS(sub)
+		assert.NoError(t, err)
+	}
+
+	_, err = NewFromFS(fstest.MapFS{"background/background1.png": &fstest.MapFile{}})
+	assert.Equal(t, ErrAssetsNotFound, err)
+}
+
+func TestMissingAssets(t *testing.T) {
+	_, err := New(Config{Size: 64, JPEGQuality: 80, AssetsPath: t.TempDir()})
+	assert.Equal(t, ErrAssetsNotFound, err)
+
+	// Package level functions load ./data on first use, without it they fail
+	g := newGenerator(loadStore(dirSource{}, t.TempDir()), DefaultConfig())
+	_, err = g.GenerateFromUsername(MALE, "username@site.com")
+	assert.Equal(t, ErrAssetsNotFound, err)
+	_, err = g.Generate(FEMALE)
+	assert.Equal(t, ErrAssetsNotFound, err)
+	_, err = g.GenerateFromSpec(Spec{Gender: MONSTER})
+	assert.Equal(t, ErrAssetsNotFound, err)
+	_, err = g.GenerateBytesFromUsername(NEUTRAL, "username@site.com", "png")
+	assert.Equal(t, ErrAssetsNotFound, err)
+
+	// Genders left out of a pack fail alone
+	fsys := fstest.MapFS{}
+	assert.NoError(t, fs.WalkDir(os.DirFS("data"), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasPrefix(name, "monster/") {
+			return err
+		}
+		data, err := os.ReadFile("data/" + name)
+		fsys[name] = &fstest.MapFile{Data: data}
+		return err
+	}))
+	g = newGenerator(loadStore(fsSource{fsys}, "."), DefaultConfig())
+	_, err = g.GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	_, err = g.GenerateFromUsername(MONSTER, "username@site.com")
+	assert.Equal(t, ErrAssetsNotFound, err)
+}
+
+func TestGenerateBytes(t *testing.T) {
+	for format, name := range map[string]string{"png": "png", "jpeg": "jpeg", ".jpg": "jpeg", "GIF": "gif"} {
+		b, err := GenerateBytesFromUsername(FEMALE, "username@site.com", format)
+		assert.NoError(t, err)
+		_, decoded, err := image.Decode(bytes.NewReader(b))
+		assert.NoError(t, err)
+		assert.Equal(t, name, decoded)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateToFromUsername(&buf, FEMALE, "username@site.com", "png"))
+	b, err := GenerateBytesFromUsername(FEMALE, "username@site.com", "png")
+	assert.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), b)
+
+	b, err = GenerateBytes(MALE, "png", WithSize(32))
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, 32, img.Bounds().Dx())
+
+	_, err = GenerateBytes(MALE, "tiff")
+	assert.Equal(t, errUnknownFormat, err)
+	assert.Equal(t, errUnknownFormat, GenerateTo(&buf, MALE, "bmp"))
+}
+
+func TestWebP(t *testing.T) {
+	avatar, err := GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+
+	lossless, err := GenerateBytesFromUsername(FEMALE, "username@site.com", "webp", WithLossless())
+	assert.NoError(t, err)
+	img, err := webp.Decode(bytes.NewReader(lossless))
+	assert.NoError(t, err)
+	// Only premultiplication rounding differs
+	result, err := imgdiff.Compare(avatar, img, 1)
+	assert.NoError(t, err)
+	assert.True(t, result.Equal())
+
+	lossy, err := GenerateBytesFromUsername(FEMALE, "username@site.com", "webp", WithQuality(50))
+	assert.NoError(t, err)
+	img, err = webp.Decode(bytes.NewReader(lossy))
+	assert.NoError(t, err)
+	assert.Equal(t, avatar.Bounds(), img.Bounds())
+	assert.True(t, len(lossy) < len(lossless))
+
+	_, err = GenerateBytesFromUsername(FEMALE, "username@site.com", "webp", WithQuality(0))
+	assert.Equal(t, errInvalidQuality, err)
+}
+
+func TestGenerateFileWebP(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "avatar.webp")
+	assert.NoError(t, GenerateFileFromUsername(MALE, "username@site.com", file))
+	f, err := os.Open(file)
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = webp.DecodeConfig(f)
+	assert.NoError(t, err)
+}
+
+func TestAVIF(t *testing.T) {
+	avatar, err := GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+
+	b, err := GenerateBytesFromUsername(FEMALE, "username@site.com", "avif")
+	assert.NoError(t, err)
+	img, err := avif.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+	result, err := imgdiff.Compare(avatar, img, 48)
+	assert.NoError(t, err)
+	assert.True(t, result.Ratio() < 0.05)
+
+	file := filepath.Join(t.TempDir(), "avatar.avif")
+	assert.NoError(t, GenerateFileFromUsername(MALE, "username@site.com", file, WithSize(64)))
+	f, err := os.Open(file)
+	assert.NoError(t, err)
+	defer f.Close()
+	c, err := avif.DecodeConfig(f)
+	assert.NoError(t, err)
+	assert.Equal(t, 64, c.Width)
+}
@@ -1,187 +1,408 @@
+// Package govatar generates avatars from layered image assets.
+//
+// A Generator holds its own assets and settings. Package level functions use
+// a default generator that loads assets from ./data on first use.
+//
+// All functions except Configure are safe for concurrent use: assets are
+// never modified once loaded, and every call draws from its own random source.
 package govatar
 
 import (
+	"context"
 	"errors"
-	"hash/fnv"
+	"fmt"
 	"image"
 	"image/draw"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"io"
+	"io/fs"
 	"io/ioutil"
-	"log"
 	"math/rand"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
+	"sync"
+
+	"github.com/gen2brain/avif"
+	"github.com/gen2brain/webp"
 )
 
-var errUnknownGender = errors.New("Unknown gender")
+var (
+	errUnknownGender = errors.New("Unknown gender")
+	errInvalidSpec   = errors.New("Invalid spec")
+)
 
-type person struct {
-	Clothes []string
-	Eye     []string
-	Face    []string
-	Hair    []string
-	Mouth   []string
-}
+// personLayers lists the layer directories every gender has, in default
+// drawing order
+var personLayers = []string{"face", "clothes", "mouth", "hair", "eye"}
 
 type store struct {
 	Background []string
 	Male       person
 	Female     person
 	Monster    person
+	Neutral    person
+	source     assetSource
+	// backgroundWeights weigh the backgrounds like person.weights
+	backgroundWeights []float64
+	// accessories puts shared accessories on random avatars
+	accessories bool
+	// license is the artwork license of the manifest, nil if none
+	license *License
+	// vectors caches traced assets by path
+	vectors sync.Map
+
+	fingerprintOnce sync.Once
+	fingerprintHash string
+}
+
+// assetSource lists and opens asset files
+type assetSource interface {
+	list(dir string) []string
+	// dirs returns names of the subdirectories of dir
+	dirs(dir string) []string
+	open(name string) (io.ReadCloser, error)
+}
+
+// dirSource reads assets from the filesystem
+type dirSource struct{}
+
+func (dirSource) list(dir string) []string { return readAssetsFrom(dir) }
+
+func (dirSource) open(name string) (io.ReadCloser, error) { return os.Open(name) }
+
+func (dirSource) dirs(dir string) (names []string) {
+	files, _ := ioutil.ReadDir(dir)
+	for _, file := range files {
+		if file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names
 }
 
-var assetsStore *store
+// fsSource reads assets from a file system
+type fsSource struct {
+	fsys fs.FS
+}
+
+func (s fsSource) list(dir string) (assets []string) {
+	dir = filepath.ToSlash(dir)
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".DS_Store" {
+			continue
+		}
+		assets = append(assets, path.Join(dir, entry.Name()))
+	}
+	sort.Sort(naturalSort(assets))
+	return assets
+}
+
+func (s fsSource) dirs(dir string) (names []string) {
+	entries, _ := fs.ReadDir(s.fsys, filepath.ToSlash(dir))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func (s fsSource) open(name string) (io.ReadCloser, error) {
+	return s.fsys.Open(filepath.ToSlash(name))
+}
+
+// Spec describes an avatar by the index of the asset chosen for every layer
+type Spec struct {
+	Gender     Gender
+	Background int
+	Face       int
+	Clothes    int
+	Mouth      int
+	Hair       int
+	Eye        int
+	// Accessories holds the index of the asset chosen for layers beyond the
+	// five above by layer name: the accessory layers earrings, beard, glasses
+	// and hat, or any other layer of the asset pack. Layers left out are not
+	// drawn.
+	Accessories map[string]int
+}
 
 // Gender represents gender type
 type Gender int
 
-// Male and female constants
+// Male and female constants. NEUTRAL draws from the parts of both male and
+// female, for applications that do not collect gender.
 const (
 	MALE Gender = iota
 	FEMALE
 	MONSTER
+	NEUTRAL
 )
 
-func init() {
-	male := getPerson(MALE)
-	female := getPerson(FEMALE)
-	monster := getPerson(MONSTER)
-	assetsStore = &store{Background: readAssetsFrom("data/background"), Male: male, Female: female, Monster: monster}
-	rand.Seed(time.Now().UTC().UnixNano())
+func loadStore(src assetSource, assetsPath string) *store {
+	manifest := readManifest(src, assetsPath)
+	male := getPerson(src, assetsPath, MALE, manifest)
+	female := getPerson(src, assetsPath, FEMALE, manifest)
+	monster := getPerson(src, assetsPath, MONSTER, manifest)
+	s := &store{Background: src.list(filepath.Join(assetsPath, "background")), Male: male, Female: female, Monster: monster, Neutral: mixPeople(male, female), source: src}
+	if manifest != nil {
+		s.backgroundWeights = loadWeights(s.Background, manifest.Weights["background"])
+		s.license = manifest.License
+	}
+	return s
 }
 
 // Generate generates random avatar
-func Generate(gender Gender) (image.Image, error) {
-	switch gender {
-	case MALE:
-		return randomAvatar(assetsStore.Male, time.Now().UnixNano())
-	case FEMALE:
-		return randomAvatar(assetsStore.Female, time.Now().UnixNano())
-	case MONSTER:
-		return randomAvatar(assetsStore.Monster, time.Now().UnixNano())
+func Generate(gender Gender, opts ...Option) (image.Image, error) {
+	return std().Generate(gender, opts...)
+}
+
+// GenerateFile generates random avatar and save it to specified file.
+// Image format depends on file extension (jpeg, jpg, png, gif, webp, avif). Default is png
+func GenerateFile(gender Gender, filePath string, opts ...Option) error {
+	return std().GenerateFile(gender, filePath, opts...)
+}
+
+// GenerateFromUsername generates avatar from string
+func GenerateFromUsername(gender Gender, username string, opts ...Option) (image.Image, error) {
+	return std().GenerateFromUsername(gender, username, opts...)
+}
+
+// SpecFromUsername returns the spec of the avatar generated from string
+func SpecFromUsername(gender Gender, username string) (Spec, error) {
+	return std().SpecFromUsername(gender, username)
+}
+
+// GenerateFromSpec generates avatar from the parts listed in spec
+func GenerateFromSpec(spec Spec, opts ...Option) (image.Image, error) {
+	return std().GenerateFromSpec(spec, opts...)
+}
+
+// GenerateTo generates random avatar and writes it to w encoded in format
+// (png, jpeg, jpg, gif, webp, avif)
+func GenerateTo(w io.Writer, gender Gender, format string, opts ...Option) error {
+	return std().GenerateTo(w, gender, format, opts...)
+}
+
+// GenerateBytes generates random avatar encoded in format (png, jpeg, jpg, gif, webp, avif)
+func GenerateBytes(gender Gender, format string, opts ...Option) ([]byte, error) {
+	return std().GenerateBytes(gender, format, opts...)
+}
+
+// GenerateToFromUsername generates avatar from string and writes it to w
+// encoded in format (png, jpeg, jpg, gif, webp, avif)
+func GenerateToFromUsername(w io.Writer, gender Gender, username string, format string, opts ...Option) error {
+	return std().GenerateToFromUsername(w, gender, username, format, opts...)
+}
+
+// GenerateBytesFromUsername generates avatar from string encoded in format
+// (png, jpeg, jpg, gif, webp, avif)
+func GenerateBytesFromUsername(gender Gender, username string, format string, opts ...Option) ([]byte, error) {
+	return std().GenerateBytesFromUsername(gender, username, format, opts...)
+}
+
+// GenerateFileFromUsername generates avatar from string and save it to specified file.
+// Image format depends on file extension (jpeg, jpg, png, gif, webp, avif). Default is png
+func GenerateFileFromUsername(gender Gender, username string, filePath string, opts ...Option) error {
+	return std().GenerateFileFromUsername(gender, username, filePath, opts...)
+}
+
+// GenerateFileWithChecksum generates random avatar, saves it to specified file
+// and returns hex encoded SHA-256 of the written bytes
+func GenerateFileWithChecksum(gender Gender, filePath string, opts ...Option) (string, error) {
+	return std().GenerateFileWithChecksum(gender, filePath, opts...)
+}
+
+// GenerateFileFromUsernameWithChecksum generates avatar from string, saves it to
+// specified file and returns hex encoded SHA-256 of the written bytes
+func GenerateFileFromUsernameWithChecksum(gender Gender, username string, filePath string, opts ...Option) (string, error) {
+	return std().GenerateFileFromUsernameWithChecksum(gender, username, filePath, opts...)
+}
+
+// render draws assets over each other and scales the result to size with filter
+func (s *store) render(ctx context.Context, assets []string, tints []*Tint, size int, filter Filter) (image.Image, error) {
+	avatar := image.NewRGBA(image.Rect(0, 0, assetSize, assetSize))
+	for i, asset := range assets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var tint *Tint
+		if tints != nil {
+			tint = tints[i]
+		}
+		if err := s.drawImg(avatar, asset, tint); err != nil {
+			return nil, err
+		}
+	}
+	if size == assetSize {
+		return avatar, nil
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, size, size))
+	filter.scaler().Scale(scaled, scaled.Bounds(), avatar, avatar.Bounds(), draw.Src, nil)
+	return scaled, nil
+}
+
+// encode writes img to w in format named by file extension with or without
+// the dot. quality applies to jpeg, lossy webp and avif. Unknown formats
+// are written as png
+func encode(w io.Writer, img image.Image, format string, quality int, lossless bool) error {
+	switch normalizeFormat(format) {
+	case "jpeg", "jpg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "gif":
+		return gif.Encode(w, img, nil)
+	case "avif":
+		return avif.Encode(w, img, avif.Options{Quality: quality, QualityAlpha: quality, Speed: avif.DefaultSpeed, ChromaSubsampling: image.YCbCrSubsampleRatio420, Lossless: lossless})
+	case "webp":
+		// The encoder takes pixels as non-premultiplied
+		if _, ok := img.(*image.NRGBA); !ok {
+			nrgba := image.NewNRGBA(img.Bounds())
+			draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+			img = nrgba
+		}
+		return webp.Encode(w, img, webp.Options{Quality: quality, Lossless: lossless, Method: 4, Exact: lossless})
 	default:
-		return nil, errUnknownGender
+		return png.Encode(w, img)
 	}
 }
 
-// GenerateFile generates random avatar and save it to specified file.
-// Image format depends on file extension (jpeg, jpg, png, gif). Default is png
-func GenerateFile(gender Gender, filePath string) error {
-	img, err := Generate(gender)
-	if err != nil {
-		return err
+// knownFormat reports whether encode has an encoder for format
+func knownFormat(format string) bool {
+	switch normalizeFormat(format) {
+	case "png", "jpeg", "jpg", "gif", "webp", "avif":
+		return true
 	}
-	return saveToFile(img, filePath)
+	return false
 }
 
-// GenerateFromUsername generates avatar from string
-func GenerateFromUsername(gender Gender, username string) (image.Image, error) {
-	h := fnv.New32a()
-	_, err := h.Write([]byte(username))
+func normalizeFormat(format string) string {
+	return strings.TrimPrefix(strings.ToLower(format), ".")
+}
+
+func (s *store) randomSpec(gender Gender, seed int64) (Spec, error) {
+	p, err := s.person(gender)
 	if err != nil {
-		return nil, err
+		return Spec{}, err
 	}
+	rnd := rand.New(rand.NewSource(seed))
+	spec := Spec{
+		Gender:     gender,
+		Background: weightedIndex(rnd, len(s.Background), s.backgroundWeights),
+		Face:       p.randomPart(rnd, "face"),
+		Clothes:    p.randomPart(rnd, "clothes"),
+		Mouth:      p.randomPart(rnd, "mouth"),
+		Hair:       p.randomPart(rnd, "hair"),
+		Eye:        p.randomPart(rnd, "eye"),
+	}
+	randomAccessories(rnd, p, &spec, s.accessories)
+	applyRules(rnd, p, &spec)
+	return spec, nil
+}
+
+// person returns the parts of gender, ErrAssetsNotFound if its assets or
+// the backgrounds are missing
+func (s *store) person(gender Gender) (person, error) {
+	var p person
 	switch gender {
 	case MALE:
-		return randomAvatar(assetsStore.Male, int64(h.Sum32()))
+		p = s.Male
 	case FEMALE:
-		return randomAvatar(assetsStore.Female, int64(h.Sum32()))
+		p = s.Female
 	case MONSTER:
-		return randomAvatar(assetsStore.Monster, int64(h.Sum32()))
+		p = s.Monster
+	case NEUTRAL:
+		p = s.Neutral
 	default:
-		return nil, errUnknownGender
+		st, ok := style(gender)
+		if !ok {
+			return person{}, errUnknownGender
+		}
+		p = st.person
+	}
+	if len(s.Background) == 0 {
+		return person{}, ErrAssetsNotFound
 	}
+	for _, layer := range personLayers {
+		if len(p.layers[layer]) == 0 {
+			return person{}, ErrAssetsNotFound
+		}
+	}
+	return p, nil
 }
 
-// GenerateFileFromUsername generates avatar from string and save it to specified file.
-// Image format depends on file extension (jpeg, jpg, png, gif). Default is png
-func GenerateFileFromUsername(gender Gender, username string, filePath string) error {
-	img, err := GenerateFromUsername(gender, username)
-	if err != nil {
-		return err
-	}
-	return saveToFile(img, filePath)
+// specAssets returns asset paths of spec in drawing order, without the
+// parts rules leave out
+func (s *store) specAssets(spec Spec) ([]string, error) {
+	_, assets, err := s.drawnLayout(spec)
+	return assets, err
 }
 
-func saveToFile(img image.Image, filePath string) error {
-	outFile, err := os.Create(filePath)
-	defer outFile.Close()
+// drawImg draws asset recolored by tint if not nil over dst
+func (s *store) drawImg(dst draw.Image, asset string, tint *Tint) error {
+	src, err := s.decode(asset)
 	if err != nil {
 		return err
 	}
-	switch strings.ToLower(filepath.Ext(filePath)) {
-	case ".jpeg", ".jpg":
-		err = jpeg.Encode(outFile, img, &jpeg.Options{Quality: 80})
-	case ".gif":
-		err = gif.Encode(outFile, img, nil)
-	default:
-		err = png.Encode(outFile, img)
+	if tint != nil {
+		src = tint.apply(src)
 	}
-	return err
+	draw.Draw(dst, dst.Bounds(), src, image.Point{0, 0}, draw.Over)
+	return nil
 }
 
-func randomAvatar(p person, seed int64) (image.Image, error) {
-	rnd := rand.New(rand.NewSource(seed))
-	avatar := image.NewRGBA(image.Rect(0, 0, 400, 400))
-	var err error
-	err = drawImg(avatar, randSliceString(rnd, assetsStore.Background), err)
-	err = drawImg(avatar, randSliceString(rnd, p.Face), err)
-	err = drawImg(avatar, randSliceString(rnd, p.Clothes), err)
-	err = drawImg(avatar, randSliceString(rnd, p.Mouth), err)
-	err = drawImg(avatar, randSliceString(rnd, p.Hair), err)
-	err = drawImg(avatar, randSliceString(rnd, p.Eye), err)
-	return avatar, err
-}
-
-func drawImg(dst draw.Image, asset string, err error) error {
-	if err != nil {
-		return err
-	}
-	infile, err := os.Open(asset)
+// decode opens and decodes asset. Errors name the asset, so a missing or
+// corrupt file can be told apart from other failures.
+func (s *store) decode(asset string) (image.Image, error) {
+	f, err := s.open(asset)
 	if err != nil {
-		// replace this with real error handling
-		panic(err)
+		return nil, fmt.Errorf("asset %s: %w", asset, err)
 	}
-	defer infile.Close()
-	src, _, err := image.Decode(infile) //bindata.MustAsset(asset)))
+	defer f.Close()
+	img, _, err := image.Decode(f)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("asset %s: %w", asset, err)
 	}
-	draw.Draw(dst, dst.Bounds(), src, image.Point{0, 0}, draw.Over)
-	return nil
+	return img, nil
 }
 
-func getPerson(gender Gender) person {
-	var genderPath string
+func getPerson(src assetSource, assetsPath string, gender Gender, manifest *Manifest) person {
+	return loadPerson(src, assetsPath, genderName(gender), manifest)
+}
 
+// genderName returns name of gender assets directory, or the name of the
+// registered style of gender
+func genderName(gender Gender) string {
 	switch gender {
 	case FEMALE:
-		genderPath = "female"
+		return "female"
 	case MALE:
-		genderPath = "male"
+		return "male"
 	case MONSTER:
-		genderPath = "monster"
+		return "monster"
+	case NEUTRAL:
+		return "neutral"
 	}
-
-	return person{
-		Clothes: readAssetsFrom("data/" + genderPath + "/clothes"),
-		Eye:     readAssetsFrom("data/" + genderPath + "/eye"),
-		Face:    readAssetsFrom("data/" + genderPath + "/face"),
-		Hair:    readAssetsFrom("data/" + genderPath + "/hair"),
-		Mouth:   readAssetsFrom("data/" + genderPath + "/mouth"),
+	if st, ok := style(gender); ok {
+		return st.name
 	}
+	return ""
 }
 
+// readAssetsFrom returns the sorted paths of the assets in dir, none if dir
+// can't be read. Generators report missing assets as ErrAssetsNotFound.
 func readAssetsFrom(dir string) (assets []string) {
-
-	files, err := ioutil.ReadDir("./" + dir)
+	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		log.Fatal(err)
+		return nil
 	}
 
 	for _, asset := range files {
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/recoilme/govatar"
+	"github.com/urfave/cli"
+)
+
+var batchCommand = cli.Command{
+	Name:      "batch",
+	ArgsUsage: "[(male|m)|(female|f)|neutral]",
+	Usage:     "Generates avatars for every username of a list",
+	Description: "Input has one username per line, or is CSV with username and gender columns\n" +
+		"   when named *.csv or with --csv. Gender argument is required unless every CSV\n" +
+		"   row has a gender. A header row naming the columns is optional.",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "input,i",
+			Value: "-",
+			Usage: "File with one username per line, - for stdin",
+		},
+		cli.BoolFlag{
+			Name:  "csv",
+			Usage: "Read input as CSV with username and gender columns",
+		},
+		cli.StringFlag{
+			Name:  "out,o",
+			Value: ".",
+			Usage: "Output directory",
+		},
+		cli.StringFlag{
+			Name:  "format,f",
+			Value: "png",
+			Usage: "Image format (png|jpg|gif|webp|avif|svg)",
+		},
+		cli.IntFlag{
+			Name:  "size,s",
+			Usage: "Width and height in pixels (default 400)",
+		},
+		cli.StringFlag{
+			Name:  "style",
+			Value: "human",
+			Usage: "Avatar style (human|monster|robot|animal)",
+		},
+		cli.IntFlag{
+			Name:  "jobs,j",
+			Value: runtime.NumCPU(),
+			Usage: "Number of avatars rendered concurrently",
+		},
+		cli.BoolFlag{
+			Name:  "checksum",
+			Usage: "Write SHA-256 of every image to <file>.sha256",
+		},
+	},
+	Action: func(c *cli.Context) {
+		var defaultGender *govatar.Gender
+		if arg := c.Args().First(); arg != "" {
+			g := parseGender(arg, "batch")
+			defaultGender = &g
+		}
+		// Styles other than human replace the gender of every entry
+		styled := c.String("style") != "human"
+		style := parseStyle(c.String("style"), govatar.MALE, "batch")
+		format := c.String("format")
+		if govatar.MIMEType(format) == "" {
+			fmt.Println("Incorrect format param. Run `govatar help batch`")
+			os.Exit(1)
+		}
+		if c.Int("jobs") < 1 {
+			fmt.Println("Incorrect jobs param. Run `govatar help batch`")
+			os.Exit(1)
+		}
+		configure(c)
+
+		input := c.String("input")
+		entries, err := readBatch(input, c.Bool("csv") || strings.EqualFold(filepath.Ext(input), ".csv"), defaultGender)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out := c.String("out")
+		if err = os.MkdirAll(out, 0755); err != nil {
+			log.Fatal(err)
+		}
+
+		failed := runBatch(entries, c.Int("jobs"), func(e batchEntry) error {
+			file := filepath.Join(out, batchFileName(e.username, format))
+			g := e.gender
+			if styled {
+				g = style
+			}
+			sum, err := generateFile(g, e.username, file, format, avatarOptions(c))
+			if err == nil && c.Bool("checksum") {
+				err = writeChecksum(file, sum)
+			}
+			return err
+		})
+		fmt.Printf("Generated %d avatars in %s\n", len(entries)-failed, out)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// batchEntry is a username to generate avatar for
+type batchEntry struct {
+	username string
+	gender   govatar.Gender
+}
+
+// readBatch reads entries from file, - reads stdin. Lines are usernames of
+// defaultGender unless isCSV, then rows are username and gender. Repeated
+// entries are kept once, different entries sharing a file name are an error.
+func readBatch(file string, isCSV bool, defaultGender *govatar.Gender) ([]batchEntry, error) {
+	var entries []batchEntry
+	if !isCSV {
+		if defaultGender == nil {
+			return nil, errors.New("Gender argument is required for plain username lists")
+		}
+		usernames, err := readLines(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, username := range usernames {
+			entries = append(entries, batchEntry{username, *defaultGender})
+		}
+		return uniqueEntries(entries)
+	}
+
+	in, err := openInput(file)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	usernameCol, genderCol := 0, 1
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && isBatchHeader(record) {
+			usernameCol, genderCol = findColumn(record, "username"), findColumn(record, "gender")
+			continue
+		}
+		var username, genderName string
+		if usernameCol < len(record) {
+			username = strings.TrimSpace(record[usernameCol])
+		}
+		if genderCol >= 0 && genderCol < len(record) {
+			genderName = strings.TrimSpace(record[genderCol])
+		}
+		if username == "" {
+			continue
+		}
+		e := batchEntry{username: username}
+		switch g, err := govatar.ParseGender(genderName); {
+		case err == nil:
+			e.gender = g
+		case genderName == "" && defaultGender != nil:
+			e.gender = *defaultGender
+		default:
+			return nil, fmt.Errorf("line %d: incorrect gender %q", line, genderName)
+		}
+		entries = append(entries, e)
+	}
+	return uniqueEntries(entries)
+}
+
+// isBatchHeader reports whether record names the username column
+func isBatchHeader(record []string) bool {
+	return findColumn(record, "username") >= 0
+}
+
+func findColumn(record []string, name string) int {
+	for i, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// uniqueEntries drops repeated entries and fails on entries whose avatar
+// files would overwrite the file of another one, e.g. a/b and a_b
+func uniqueEntries(entries []batchEntry) ([]batchEntry, error) {
+	seen := make(map[string]batchEntry, len(entries))
+	unique := entries[:0]
+	for _, e := range entries {
+		name := batchFileName(e.username, "")
+		if first, ok := seen[name]; ok {
+			if first != e {
+				return nil, fmt.Errorf("%q and %q would be written to the same file", first.username, e.username)
+			}
+			continue
+		}
+		seen[name] = e
+		unique = append(unique, e)
+	}
+	return unique, nil
+}
+
+// runBatch calls generate for every entry from jobs goroutines, logs failures
+// and returns their number
+func runBatch(entries []batchEntry, jobs int, generate func(batchEntry) error) int {
+	queue := make(chan batchEntry)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range queue {
+				if err := generate(e); err != nil {
+					log.Printf("%s: %v", e.username, err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, e := range entries {
+		queue <- e
+	}
+	close(queue)
+	wg.Wait()
+	return failed
+}
+
+// batchFileName returns name of the avatar file of username, with path
+// separators replaced so every file lands in the output directory
+func batchFileName(username, format string) string {
+	name := strings.NewReplacer("/", "_", `\`, "_").Replace(username)
+	return name + "." + strings.TrimPrefix(strings.ToLower(format), ".")
+}
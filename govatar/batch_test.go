@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeInput(t *testing.T, name, data string) string {
+	file := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, ioutil.WriteFile(file, []byte(data), 0644))
+	return file
+}
+
+func TestReadBatch(t *testing.T) {
+	female := govatar.FEMALE
+
+	file := writeInput(t, "users.txt", "alice\n\n bob \nalice\n")
+	entries, err := readBatch(file, false, &female)
+	assert.NoError(t, err)
+	assert.Equal(t, []batchEntry{{"alice", govatar.FEMALE}, {"bob", govatar.FEMALE}}, entries)
+
+	_, err = readBatch(file, false, nil)
+	assert.Error(t, err)
+
+	file = writeInput(t, "users.csv", "gender,username\nm,alice\n,bob\nfemale, carol\n")
+	entries, err = readBatch(file, true, &female)
+	assert.NoError(t, err)
+	assert.Equal(t, []batchEntry{{"alice", govatar.MALE}, {"bob", govatar.FEMALE}, {"carol", govatar.FEMALE}}, entries)
+
+	file = writeInput(t, "users.csv", "alice,male\nbob\n")
+	entries, err = readBatch(file, true, &female)
+	assert.NoError(t, err)
+	assert.Equal(t, []batchEntry{{"alice", govatar.MALE}, {"bob", govatar.FEMALE}}, entries)
+
+	_, err = readBatch(file, true, nil)
+	assert.Error(t, err)
+
+	file = writeInput(t, "users.csv", "alice,alien\n")
+	_, err = readBatch(file, true, &female)
+	assert.Error(t, err)
+
+	// Different entries must not overwrite each other
+	file = writeInput(t, "users.txt", "a/b\na_b\n")
+	_, err = readBatch(file, false, &female)
+	assert.EqualError(t, err, `"a/b" and "a_b" would be written to the same file`)
+	file = writeInput(t, "users.csv", "alice,male\nalice,female\n")
+	_, err = readBatch(file, true, &female)
+	assert.Error(t, err)
+}
+
+func TestRunBatch(t *testing.T) {
+	var entries []batchEntry
+	for _, username := range []string{"a", "b", "c", "d", "e"} {
+		entries = append(entries, batchEntry{username: username})
+	}
+	var calls int32
+	failed := runBatch(entries, 3, func(e batchEntry) error {
+		atomic.AddInt32(&calls, 1)
+		if e.username == "c" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.Equal(t, int32(5), calls)
+	assert.Equal(t, 1, failed)
+}
+
+func TestBatchFileName(t *testing.T) {
+	assert.Equal(t, "alice.png", batchFileName("alice", "png"))
+	assert.Equal(t, "a_b_c.webp", batchFileName(`a/b\c`, ".WEBP"))
+}
@@ -1,9 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/recoilme/govatar"
 	"github.com/urfave/cli"
@@ -17,49 +28,450 @@ func main() {
 	app.Usage = "Avatar generator service."
 	app.Version = version
 	app.Author = "Oleg Lobanov"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "assets",
+			Value:  govatar.DefaultConfig().AssetsPath,
+			Usage:  "Directory with background and per gender assets",
+			EnvVar: "GOVATAR_ASSETS",
+		},
+	}
 	app.Commands = []cli.Command{
 		{
 			Name:      "generate",
-			ArgsUsage: "<(male|m)|(female|f)>",
+			ArgsUsage: "<(male|m)|(female|f)|neutral>",
 			Aliases:   []string{"g"},
 			Usage:     "Generates random avatar",
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "output,o",
 					Value: "avatar.png",
-					Usage: "Output file name",
+					Usage: "Output file name, - for stdout",
 				},
 				cli.StringFlag{
 					Name:  "username,u",
 					Value: "",
 					Usage: "Username",
 				},
+				cli.StringFlag{
+					Name:  "style",
+					Value: "human",
+					Usage: "Avatar style (human|monster|robot|animal)",
+				},
+				cli.IntFlag{
+					Name:  "size,s",
+					Usage: "Width and height in pixels (default 400)",
+				},
+				cli.StringFlag{
+					Name:  "format,f",
+					Usage: "Image format (png|jpg|gif|webp|avif|svg), defaults to output extension",
+				},
+				cli.Int64Flag{
+					Name:  "seed",
+					Usage: "Seed of a reproducible random avatar",
+				},
+				cli.IntFlag{
+					Name:  "pixel-art",
+					Usage: "Draw as pixel art of this many pixels across, e.g. 20",
+				},
+				cli.StringFlag{
+					Name:  "shape",
+					Value: "square",
+					Usage: "Avatar shape (square|circle)",
+				},
+				cli.IntFlag{
+					Name:  "corner-radius",
+					Usage: "Round corners by this many pixels",
+				},
+				cli.IntFlag{
+					Name:  "supersample",
+					Usage: "Draw at 2 to 4 times the size and scale down for smoother edges",
+				},
+				cli.BoolFlag{
+					Name:  "solid-background",
+					Usage: "Fill the background with a color picked from the avatar",
+				},
+				cli.StringFlag{
+					Name:  "theme",
+					Usage: "Color theme (" + strings.Join(govatar.Themes(), "|") + ")",
+				},
+				cli.StringFlag{
+					Name:  "layer-order",
+					Usage: "Comma separated layers to draw first, e.g. hair,face",
+				},
+				cli.StringFlag{
+					Name:  "without",
+					Usage: "Comma separated layers to leave out, e.g. clothes,background",
+				},
+				cli.StringFlag{
+					Name:  "renderer,r",
+					Value: govatar.DefaultRenderer,
+					Usage: "Registered renderer to draw the avatar with",
+				},
+				cli.BoolFlag{
+					Name:  "checksum",
+					Usage: "Write SHA-256 of the image to <output>.sha256",
+				},
+				cli.BoolFlag{
+					Name:  "traits",
+					Usage: "Write parts of the avatar and their rarity as JSON to <output>.traits.json",
+				},
 			},
 			Action: func(c *cli.Context) {
-				var g govatar.Gender
-				var err error
-				switch c.Args().First() {
-				case "male", "m":
-					g = govatar.MONSTER
-				case "female", "f":
-					g = govatar.FEMALE
+				g := parseStyle(c.String("style"), parseGender(c.Args().First(), "generate"), "generate")
+				configure(c)
 
-				default:
-					fmt.Println("Incorrect gender param. Run `govatar help generate`")
+				output := c.String("output")
+				format := outputFormat(c.String("format"), output)
+				if govatar.MIMEType(format) == "" {
+					fmt.Println("Incorrect format param. Run `govatar help generate`")
 					os.Exit(1)
 				}
-
 				username := c.String("username")
-				if username != "" {
-					err = govatar.GenerateFileFromUsername(g, username, c.String("output"))
+				opts := append(avatarOptions(c), govatar.WithRenderer(c.String("renderer")))
+				var traits govatar.Traits
+				if c.Bool("traits") {
+					opts = append(opts, govatar.WithTraits(&traits))
+				}
+				write := func(w io.Writer) error {
+					return writeAvatar(w, g, username, format, opts)
+				}
+
+				if output == "-" {
+					if c.Bool("checksum") || c.Bool("traits") {
+						log.Fatal("Checksum and traits need an output file")
+					}
+					if err := writeStdout(write); err != nil {
+						log.Fatal(err)
+					}
+					return
+				}
+				sum, err := createFile(output, write)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if c.Bool("checksum") {
+					if err = writeChecksum(output, sum); err != nil {
+						log.Fatal(err)
+					}
+				}
+				if c.Bool("traits") {
+					if err = writeTraits(output, traits); err != nil {
+						log.Fatal(err)
+					}
+				}
+			},
+		},
+		{
+			Name:      "preview",
+			ArgsUsage: "<(male|m)|(female|f)|neutral>",
+			Usage:     "Prints avatar to the terminal as Braille patterns",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "username,u",
+					Value: "",
+					Usage: "Username",
+				},
+				cli.IntFlag{
+					Name:  "width,w",
+					Value: 40,
+					Usage: "Preview width in characters",
+				},
+				cli.BoolFlag{
+					Name:  "invert",
+					Usage: "Draw dark pixels, for light terminals",
+				},
+			},
+			Action: func(c *cli.Context) {
+				g := parseGender(c.Args().First(), "preview")
+				configure(c)
+				var img image.Image
+				var err error
+				if username := c.String("username"); username != "" {
+					img, err = govatar.GenerateFromUsername(g, username)
 				} else {
-					err = govatar.GenerateFile(g, c.String("output"))
+					img, err = govatar.Generate(g)
+				}
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Print(govatar.Braille(img, c.Int("width"), c.Bool("invert")))
+			},
+		},
+		{
+			Name:      "audit",
+			ArgsUsage: "<(male|m)|(female|f)|neutral>",
+			Usage:     "Reports parts chosen for every username",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "input,i",
+					Value: "-",
+					Usage: "File with one username per line, - for stdin",
+				},
+				cli.StringFlag{
+					Name:  "format,f",
+					Value: "csv",
+					Usage: "Report format (csv|json)",
+				},
+			},
+			Action: func(c *cli.Context) {
+				g := parseGender(c.Args().First(), "audit")
+				configure(c)
+				usernames, err := readLines(c.String("input"))
+				if err != nil {
+					log.Fatal(err)
+				}
+				records, err := govatar.Audit(g, usernames)
+				if err != nil {
+					log.Fatal(err)
+				}
+				switch c.String("format") {
+				case "json":
+					err = govatar.WriteAuditJSON(os.Stdout, records)
+				default:
+					err = govatar.WriteAuditCSV(os.Stdout, records)
 				}
 				if err != nil {
 					log.Fatal(err)
 				}
 			},
 		},
+		batchCommand,
+		serveCommand,
+		{
+			Name:  "pack",
+			Usage: "Asset pack tools",
+			Subcommands: []cli.Command{
+				{
+					Name:      "build",
+					ArgsUsage: "<dir>",
+					Usage:     "Normalizes artwork in dir and writes manifest and preview sheet",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "output,o",
+							Usage: "Write the pack to this directory and leave dir as it is",
+						},
+					},
+					Action: func(c *cli.Context) {
+						dir := c.Args().First()
+						if dir == "" {
+							fmt.Println("Missing pack directory. Run `govatar help pack build`")
+							os.Exit(1)
+						}
+						out := dir
+						if c.IsSet("output") {
+							out = c.String("output")
+						}
+						m, err := govatar.BuildPackTo(dir, out)
+						if problems, ok := err.(govatar.PackProblems); ok {
+							for _, p := range problems {
+								fmt.Fprintln(os.Stderr, p)
+							}
+							os.Exit(1)
+						}
+						if err != nil {
+							log.Fatal(err)
+						}
+						n := len(m.Background)
+						for _, layers := range m.Genders {
+							for _, assets := range layers {
+								n += len(assets)
+							}
+						}
+						fmt.Printf("Built pack of %d assets in %s\n", n, out)
+					},
+				},
+			},
+		},
 	}
 	app.Run(os.Args)
 }
+
+// parseGender returns gender named by arg or exits pointing to help of command
+func parseGender(arg, command string) govatar.Gender {
+	if g, err := govatar.ParseGender(arg); err == nil {
+		return g
+	}
+	fmt.Printf("Incorrect gender param. Run `govatar help %s`\n", command)
+	os.Exit(1)
+	return 0
+}
+
+// parseStyle returns the gender drawing avatars of style or exits pointing
+// to help of command
+func parseStyle(style string, g govatar.Gender, command string) govatar.Gender {
+	if s, err := govatar.ParseStyle(style, g); err == nil {
+		return s
+	}
+	fmt.Printf("Incorrect style param. Run `govatar help %s`\n", command)
+	os.Exit(1)
+	return 0
+}
+
+// parseShape returns the shape named by arg or exits pointing to help of
+// command
+func parseShape(arg, command string) govatar.Shape {
+	switch arg {
+	case "square":
+		return govatar.Square
+	case "circle":
+		return govatar.Circle
+	}
+	fmt.Printf("Incorrect shape param. Run `govatar help %s`\n", command)
+	os.Exit(1)
+	return 0
+}
+
+// configure loads assets from the directory given by the global assets flag
+func configure(c *cli.Context) {
+	config := govatar.DefaultConfig()
+	config.AssetsPath = c.GlobalString("assets")
+	if err := govatar.Configure(config); err != nil {
+		log.Fatalf("%s: %v", config.AssetsPath, err)
+	}
+}
+
+// avatarOptions returns generation options set by size, seed, pixel art,
+// shape, corner radius, supersampling, background, theme and layer flags of c
+func avatarOptions(c *cli.Context) []govatar.Option {
+	var opts []govatar.Option
+	if c.IsSet("size") {
+		opts = append(opts, govatar.WithSize(c.Int("size")))
+	}
+	if c.IsSet("seed") {
+		opts = append(opts, govatar.WithSeed(c.Int64("seed")))
+	}
+	if c.IsSet("pixel-art") {
+		opts = append(opts, govatar.WithPixelArt(c.Int("pixel-art")))
+	}
+	if c.IsSet("shape") {
+		opts = append(opts, govatar.WithShape(parseShape(c.String("shape"), c.Command.Name)))
+	}
+	if c.IsSet("corner-radius") {
+		opts = append(opts, govatar.WithCornerRadius(c.Int("corner-radius")))
+	}
+	if c.IsSet("supersample") {
+		opts = append(opts, govatar.WithSupersample(c.Int("supersample")))
+	}
+	if c.Bool("solid-background") {
+		opts = append(opts, govatar.WithSolidBackground())
+	}
+	if c.IsSet("theme") {
+		opts = append(opts, govatar.WithTheme(c.String("theme")))
+	}
+	if c.IsSet("layer-order") {
+		opts = append(opts, govatar.WithLayerOrder(strings.Split(c.String("layer-order"), ",")...))
+	}
+	if c.IsSet("without") {
+		opts = append(opts, govatar.WithoutLayers(strings.Split(c.String("without"), ",")...))
+	}
+	return opts
+}
+
+// outputFormat returns format, or the format named by extension of file.
+// Files without known extension are png.
+func outputFormat(format, file string) string {
+	if format != "" {
+		return format
+	}
+	if ext := filepath.Ext(file); govatar.MIMEType(ext) != "" {
+		return ext
+	}
+	return "png"
+}
+
+// readLines returns non-empty lines of file, - reads stdin
+func readLines(file string) ([]string, error) {
+	in, err := openInput(file)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	var lines []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// openInput opens file for reading, - opens stdin
+func openInput(file string) (io.ReadCloser, error) {
+	if file == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+	return os.Open(file)
+}
+
+// writeAvatar writes avatar for username to w encoded in format. Empty
+// username gives random avatar.
+func writeAvatar(w io.Writer, g govatar.Gender, username, format string, opts []govatar.Option) error {
+	if govatar.MIMEType(format) == "image/svg+xml" {
+		if username == "" {
+			return errors.New("SVG avatars need a username")
+		}
+		svg, err := govatar.GenerateSVGFromUsername(g, username, opts...)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, svg)
+		return err
+	}
+	if username == "" {
+		return govatar.GenerateTo(w, g, format, opts...)
+	}
+	return govatar.GenerateToFromUsername(w, g, username, format, opts...)
+}
+
+// generateFile writes avatar to file and returns hex encoded SHA-256 of the
+// written bytes
+func generateFile(g govatar.Gender, username, file, format string, opts []govatar.Option) (string, error) {
+	return createFile(file, func(w io.Writer) error {
+		return writeAvatar(w, g, username, format, opts)
+	})
+}
+
+// writeStdout writes contents written by write to stdout. Nothing is
+// written if write fails, so pipelines never see a partial image.
+func writeStdout(write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	_, err := os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// createFile creates file with contents written by write and returns hex
+// encoded SHA-256 of them. The file is removed if write fails.
+func createFile(file string, write func(w io.Writer) error) (string, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if err = write(io.MultiWriter(f, h)); err != nil {
+		f.Close()
+		os.Remove(file)
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksum writes sum to a sha256sum compatible sidecar of file
+func writeChecksum(file, sum string) error {
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(file))
+	return ioutil.WriteFile(file+".sha256", []byte(line), 0644)
+}
+
+// writeTraits writes traits as JSON to file.traits.json
+func writeTraits(file string, traits govatar.Traits) error {
+	data, err := json.MarshalIndent(traits, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file+".traits.json", append(data, '\n'), 0644)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/golang/groupcache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/recoilme/govatar"
+	"github.com/recoilme/govatar/groupavatar"
+	"github.com/recoilme/govatar/grpcsvc"
+	"github.com/recoilme/govatar/httpavatar"
+	"github.com/recoilme/govatar/promavatar"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+)
+
+// shutdownTimeout is how long serve waits for requests in flight on exit
+const shutdownTimeout = 10 * time.Second
+
+var serveCommand = cli.Command{
+	Name:  "serve",
+	Usage: "Serves avatars over HTTP at /avatar/{username}.{png,jpg,gif,webp,avif,svg}",
+	Description: "Every flag can be set with the environment variable in brackets.\n" +
+		"   /healthz answers 200 once assets are loaded, /metrics serves Prometheus metrics.",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:   "addr,a",
+			Value:  ":8080",
+			Usage:  "Listen address",
+			EnvVar: "GOVATAR_ADDR",
+		},
+		cli.StringFlag{
+			Name:   "grpc-addr",
+			Usage:  "Listen address of the gRPC avatar service, empty disables it",
+			EnvVar: "GOVATAR_GRPC_ADDR",
+		},
+		cli.StringFlag{
+			Name:   "gender,g",
+			Value:  "male",
+			Usage:  "Gender of avatars unless requested otherwise (male|female)",
+			EnvVar: "GOVATAR_GENDER",
+		},
+		cli.StringFlag{
+			Name:   "style",
+			Value:  "human",
+			Usage:  "Style of avatars unless requested otherwise (human|monster|robot|animal)",
+			EnvVar: "GOVATAR_STYLE",
+		},
+		cli.IntFlag{
+			Name:   "max-size",
+			Value:  httpavatar.DefaultMaxSize,
+			Usage:  "Largest avatar size clients may request",
+			EnvVar: "GOVATAR_MAX_SIZE",
+		},
+		cli.IntFlag{
+			Name:   "max-renders",
+			Value:  0,
+			Usage:  "Number of avatars drawn at once, 0 for no limit",
+			EnvVar: "GOVATAR_MAX_RENDERS",
+		},
+		cli.IntFlag{
+			Name:   "cache-size",
+			Value:  httpavatar.DefaultCacheSize,
+			Usage:  "Number of encoded avatars kept in memory, 0 disables the cache",
+			EnvVar: "GOVATAR_CACHE_SIZE",
+		},
+		cli.StringFlag{
+			Name:   "disk-cache",
+			Usage:  "Directory caching encoded avatars instead of memory",
+			EnvVar: "GOVATAR_DISK_CACHE",
+		},
+		cli.Int64Flag{
+			Name:   "disk-cache-bytes",
+			Value:  1 << 30,
+			Usage:  "Size limit of the disk cache",
+			EnvVar: "GOVATAR_DISK_CACHE_BYTES",
+		},
+		cli.DurationFlag{
+			Name:   "session-ttl",
+			Usage:  "Lifetime of anonymous avatars issued by POST /session, 0 disables them",
+			EnvVar: "GOVATAR_SESSION_TTL",
+		},
+		cli.IntFlag{
+			Name:   "max-batch",
+			Usage:  "Number of usernames POST /batch may zip at once, 0 disables it",
+			EnvVar: "GOVATAR_MAX_BATCH",
+		},
+		cli.StringSliceFlag{
+			Name:   "themes",
+			Usage:  "Themes clients may pick with the theme query parameter, comma separated or repeated",
+			EnvVar: "GOVATAR_THEMES",
+		},
+		cli.StringFlag{
+			Name:   "webhook",
+			Usage:  "URL notified with a JSON payload of every avatar drawn",
+			EnvVar: "GOVATAR_WEBHOOK",
+		},
+		cli.StringFlag{
+			Name:   "webhook-secret",
+			Usage:  "Key signing webhook payloads in the X-Govatar-Signature header",
+			EnvVar: "GOVATAR_WEBHOOK_SECRET",
+		},
+		cli.StringFlag{
+			Name:   "self",
+			Usage:  "Base URL other instances reach this one at, e.g. http://10.0.0.1:8080",
+			EnvVar: "GOVATAR_SELF",
+		},
+		cli.StringSliceFlag{
+			Name:   "peer",
+			Usage:  "Base URLs of instances sharing rendered avatars, comma separated or repeated, including self",
+			EnvVar: "GOVATAR_PEERS",
+		},
+		cli.Int64Flag{
+			Name:   "peer-cache-bytes",
+			Value:  64 << 20,
+			Usage:  "Memory kept for avatars shared with peers",
+			EnvVar: "GOVATAR_PEER_CACHE_BYTES",
+		},
+	},
+	Action: func(c *cli.Context) {
+		g := parseStyle(c.String("style"), parseGender(c.String("gender"), "serve"), "serve")
+		if c.Int("max-size") < 1 || c.Int("max-renders") < 0 || c.Int("cache-size") < 0 || c.Duration("session-ttl") < 0 || c.Int("max-batch") < 0 {
+			fmt.Println("Incorrect limit param. Run `govatar help serve`")
+			os.Exit(1)
+		}
+		configure(c)
+
+		cacheSize := c.Int("cache-size")
+		if cacheSize == 0 {
+			cacheSize = -1
+		}
+		var cache govatar.Cache
+		if dir := c.String("disk-cache"); dir != "" {
+			diskCache, err := govatar.NewDiskCache(dir, c.Int64("disk-cache-bytes"))
+			if err != nil {
+				log.Fatal(err)
+			}
+			cache = diskCache
+		}
+		metrics := promavatar.New()
+		prometheus.MustRegister(metrics)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		opts := httpavatar.Options{
+			Metrics:    metrics,
+			Gender:     g,
+			MaxSize:    c.Int("max-size"),
+			MaxRenders: c.Int("max-renders"),
+			Cache:      cache,
+			CacheSize:  cacheSize,
+			SessionTTL: c.Duration("session-ttl"),
+			MaxBatch:   c.Int("max-batch"),
+		}
+		for _, themes := range c.StringSlice("themes") {
+			for _, theme := range strings.Split(themes, ",") {
+				if _, ok := govatar.LookupTheme(theme); !ok {
+					fmt.Println("Incorrect themes param. Run `govatar help serve`")
+					os.Exit(1)
+				}
+				opts.Themes = append(opts.Themes, theme)
+			}
+		}
+		if url := c.String("webhook"); url != "" {
+			opts.Webhook = &httpavatar.Webhook{URL: url, Secret: []byte(c.String("webhook-secret"))}
+		}
+		var peers []string
+		for _, peer := range c.StringSlice("peer") {
+			peers = append(peers, strings.Split(peer, ",")...)
+		}
+		if len(peers) > 0 {
+			if c.String("self") == "" {
+				fmt.Println("Missing self param for peers. Run `govatar help serve`")
+				os.Exit(1)
+			}
+			pool := groupcache.NewHTTPPoolOpts(c.String("self"), nil)
+			pool.Set(peers...)
+			mux.Handle("/_groupcache/", pool)
+			mux.Handle("/", groupavatar.NewHandler("govatar", c.Int64("peer-cache-bytes"), opts))
+		} else {
+			mux.Handle("/", httpavatar.New(opts))
+		}
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "ok")
+		})
+		srv := &http.Server{
+			Addr:              c.String("addr"),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		}
+
+		var grpcSrv *grpc.Server
+		if addr := c.String("grpc-addr"); addr != "" {
+			lis, err := net.Listen("tcp", addr)
+			if err != nil {
+				log.Fatal(err)
+			}
+			grpcCache := cache
+			if grpcCache == nil && cacheSize > 0 {
+				grpcCache = govatar.NewMemoryCache(cacheSize)
+			}
+			grpcSrv = grpc.NewServer()
+			grpcsvc.RegisterAvatarServer(grpcSrv, grpcsvc.NewServer(grpcsvc.Options{Gender: g, MaxSize: c.Int("max-size"), Cache: grpcCache}))
+			go func() {
+				if err := grpcSrv.Serve(lis); err != nil {
+					log.Fatal(err)
+				}
+			}()
+			log.Printf("Serving gRPC avatars on %s", addr)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			<-stop
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if grpcSrv != nil {
+				grpcSrv.GracefulStop()
+			}
+			if err := srv.Shutdown(ctx); err != nil {
+				log.Print(err)
+			}
+		}()
+
+		log.Printf("Serving avatars on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+		<-done
+	},
+}
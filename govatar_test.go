@@ -1,12 +1,21 @@
 package govatar
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
+	"io/fs"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"sync"
 	"testing"
+	"testing/fstest"
 
+	"github.com/recoilme/govatar/govatartest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,6 +75,21 @@ func generateFileTest(t *testing.T, gender Gender) {
 	}
 }
 
+func TestGenerateFileWithChecksum(t *testing.T) {
+	os.Remove("avatar.png")
+	sum, err := GenerateFileFromUsernameWithChecksum(MALE, "username@site.com", "avatar.png")
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile("avatar.png")
+	assert.NoError(t, err)
+	expected := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(expected[:]), sum)
+
+	sum, err = GenerateFileWithChecksum(FEMALE, "avatar.png")
+	assert.NoError(t, err)
+	assert.Len(t, sum, 64)
+}
+
 func TestGenerateFromString(t *testing.T) {
 	// Male test
 	avatar1, err := GenerateFromUsername(MALE, "username@site.com")
@@ -97,6 +121,59 @@ func TestMonster(t *testing.T) {
 	generateFileFromStringTest(t, MONSTER)
 	//generateFileFromStringTest(t, FEMALE)
 }
+func TestNeutral(t *testing.T) {
+	s := std().store
+	assert.Len(t, s.Neutral.assets("hair"), len(s.Male.assets("hair"))+len(s.Female.assets("hair")))
+	assert.Equal(t, s.Male.assets("eye")[0], s.Neutral.assets("eye")[0])
+	assert.Equal(t, s.Female.assets("eye")[0], s.Neutral.assets("eye")[len(s.Male.assets("eye"))])
+
+	// Usernames get parts of both pools
+	var male, female bool
+	for i := 0; i < 50; i++ {
+		spec, err := SpecFromUsername(NEUTRAL, fmt.Sprint("user", i))
+		assert.NoError(t, err)
+		male = male || spec.Hair < len(s.Male.assets("hair"))
+		female = female || spec.Hair >= len(s.Male.assets("hair"))
+	}
+	assert.True(t, male)
+	assert.True(t, female)
+	generateFileFromStringTest(t, NEUTRAL)
+}
+
+func TestBrokenAssets(t *testing.T) {
+	fsys := fstest.MapFS{}
+	assert.NoError(t, fs.WalkDir(os.DirFS("data"), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile("data/" + name)
+		fsys[name] = &fstest.MapFile{Data: data}
+		return err
+	}))
+	g, err := NewFromFS(fsys)
+	assert.NoError(t, err)
+	fsys["male/face/face1.png"] = &fstest.MapFile{Data: []byte("not a png")}
+	delete(fsys, "male/hair/hair1.png")
+
+	spec := NewBuilder(MALE).Spec()
+	_, err = g.GenerateFromSpec(spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "male/face/face1.png")
+	assert.True(t, errors.Is(err, image.ErrFormat))
+
+	spec.Face = 1
+	_, err = g.GenerateFromSpec(spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "male/hair/hair1.png")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	_, err = g.GenerateSVGFromSpec(spec)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	d, err := g.Describe(spec)
+	assert.NoError(t, err)
+	_, err = g.NewEditor(d)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
 func TestGenerateFileFromString(t *testing.T) {
 	generateFileFromStringTest(t, MALE)
 	//generateFileFromStringTest(t, FEMALE)
@@ -128,6 +205,32 @@ func generateFileFromStringTest(t *testing.T, gender Gender) {
 	}
 }
 
+func TestConcurrentGenerate(t *testing.T) {
+	usernames := []string{"a", "b", "c", "username@site.com", "username2@site.com"}
+	expected := make(map[string]image.Image)
+	for _, username := range usernames {
+		avatar, err := GenerateFromUsername(FEMALE, username)
+		assert.NoError(t, err)
+		expected[username] = avatar
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			username := usernames[i%len(usernames)]
+			avatar, err := GenerateFromUsername(FEMALE, username)
+			assert.NoError(t, err)
+			assert.True(t, areImagesEquals(expected[username], avatar))
+
+			_, err = Generate(Gender(i % 3))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
 func areImagesEquals(a, b image.Image) bool {
 	ab, bb := a.Bounds(), b.Bounds()
 	w, h := ab.Dx(), ab.Dy()
@@ -165,3 +268,11 @@ func abs(x int64) int64 {
 	}
 	return x
 }
+
+func TestGolden(t *testing.T) {
+	for _, g := range []Gender{MALE, FEMALE, MONSTER} {
+		img, err := GenerateFromUsername(g, "username@site.com")
+		assert.NoError(t, err)
+		govatartest.AssertGolden(t, genderName(g), img)
+	}
+}
@@ -0,0 +1,112 @@
+// Package govatartest provides golden image assertions for testing code
+// that generates avatars.
+//
+// Golden files live in the testdata directory of the package under test.
+// Run tests with GOVATAR_UPDATE=1 to write the actual images as new golden
+// files. An environment variable leaves the -update flag to the package
+// under test.
+package govatartest
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/recoilme/govatar/imgdiff"
+)
+
+// updateEnv names the environment variable asking to rewrite golden files
+const updateEnv = "GOVATAR_UPDATE"
+
+// Dir is the directory golden files are read from and written to
+var Dir = "testdata"
+
+// Tolerance controls how different an image may be from its golden file.
+// It is not a perceptual metric: pixels whose color differs by more than
+// Delta in any channel count as changed, and the assertion fails when the
+// share of changed pixels exceeds Ratio.
+type Tolerance struct {
+	Delta uint8
+	Ratio float64
+}
+
+// DefaultTolerance absorbs resampling and encoder noise but catches swapped parts
+var DefaultTolerance = Tolerance{Delta: 8, Ratio: 0.001}
+
+// AssertGolden compares img with the golden file name using DefaultTolerance
+func AssertGolden(t testing.TB, name string, img image.Image) bool {
+	t.Helper()
+	return AssertGoldenWithin(t, name, img, DefaultTolerance)
+}
+
+// AssertGoldenWithin compares img with the golden file name using tol.
+// On failure it writes a visual diff next to the golden file.
+// With GOVATAR_UPDATE set it writes img as the golden file instead.
+func AssertGoldenWithin(t testing.TB, name string, img image.Image, tol Tolerance) bool {
+	t.Helper()
+	path := filepath.Join(Dir, name+".png")
+	if os.Getenv(updateEnv) != "" {
+		if err := writePNG(path, img); err != nil {
+			t.Errorf("govatartest: %v", err)
+			return false
+		}
+		return true
+	}
+
+	golden, err := readPNG(path)
+	if os.IsNotExist(err) {
+		t.Errorf("govatartest: missing golden file %s, run tests with %s=1", path, updateEnv)
+		return false
+	}
+	if err != nil {
+		t.Errorf("govatartest: %v", err)
+		return false
+	}
+
+	if golden.Bounds().Size() != img.Bounds().Size() {
+		t.Errorf("govatartest: %s is %v, got %v", path, golden.Bounds().Size(), img.Bounds().Size())
+		return false
+	}
+	result, err := imgdiff.Compare(golden, img, tol.Delta)
+	if err != nil {
+		t.Errorf("govatartest: %v", err)
+		return false
+	}
+	if result.Ratio() <= tol.Ratio {
+		return true
+	}
+
+	diffPath := filepath.Join(Dir, name+".diff.png")
+	if diff, err := imgdiff.Visualize(golden, img, tol.Delta); err == nil && writePNG(diffPath, diff) == nil {
+		t.Errorf("govatartest: %d of %d pixels differ from %s, see %s", result.Changed, result.Total, path, diffPath)
+	} else {
+		t.Errorf("govatartest: %d of %d pixels differ from %s", result.Changed, result.Total, path)
+	}
+	return false
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
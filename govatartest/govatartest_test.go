@@ -0,0 +1,71 @@
+package govatartest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder captures assertion failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func square(c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func withGolden(t *testing.T, name string, img image.Image) {
+	Dir = t.TempDir()
+	assert.NoError(t, os.Setenv(updateEnv, "1"))
+	assert.True(t, AssertGolden(t, name, img))
+	assert.NoError(t, os.Unsetenv(updateEnv))
+}
+
+func TestAssertGolden(t *testing.T) {
+	withGolden(t, "square", square(color.NRGBA{100, 150, 200, 255}))
+
+	// Small color noise is tolerated
+	r := &recorder{TB: t}
+	assert.True(t, AssertGolden(r, "square", square(color.NRGBA{104, 146, 200, 255})))
+	assert.Empty(t, r.errors)
+
+	// A clearly different color is not
+	r = &recorder{TB: t}
+	assert.False(t, AssertGolden(r, "square", square(color.NRGBA{200, 150, 200, 255})))
+	assert.Len(t, r.errors, 1)
+	assert.FileExists(t, filepath.Join(Dir, "square.diff.png"))
+}
+
+func TestAssertGoldenRatio(t *testing.T) {
+	img := square(color.White)
+	withGolden(t, "ratio", img)
+
+	changed := square(color.White)
+	changed.Set(0, 0, color.Black)
+	r := &recorder{TB: t}
+	assert.False(t, AssertGolden(r, "ratio", changed))
+	assert.True(t, AssertGoldenWithin(r, "ratio", changed, Tolerance{Delta: 8, Ratio: 0.01}))
+}
+
+func TestAssertGoldenMismatch(t *testing.T) {
+	withGolden(t, "size", square(color.White))
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertGolden(r, "size", image.NewNRGBA(image.Rect(0, 0, 10, 10))))
+	assert.False(t, AssertGolden(r, "missing", square(color.White)))
+	assert.Len(t, r.errors, 2)
+}
@@ -0,0 +1,51 @@
+// Package groupavatar shares rendered avatars between instances of a
+// service with groupcache. Every avatar is rendered by the one instance
+// owning its key and fetched from it by the others.
+//
+//	pool := groupcache.NewHTTPPool("http://10.0.0.1:8080")
+//	pool.Set("http://10.0.0.1:8080", "http://10.0.0.2:8080")
+//	http.Handle("/avatar/", groupavatar.NewHandler("avatars", 64<<20, httpavatar.Options{}))
+//
+// NewHTTPPool serves peer requests at /_groupcache/ of http.DefaultServeMux.
+package groupavatar
+
+import (
+	"context"
+
+	"github.com/golang/groupcache"
+	"github.com/recoilme/govatar/httpavatar"
+)
+
+// NewHandler returns a handler loading avatars through groupcache group
+// name, which keeps up to cacheBytes of avatars in memory. The handler's own
+// cache is disabled unless opts.Cache is set. Like groupcache.NewGroup it
+// panics if name is already used.
+func NewHandler(name string, cacheBytes int64, opts httpavatar.Options) *httpavatar.Handler {
+	l := &loader{}
+	opts.Loader = l
+	if opts.Cache == nil {
+		opts.CacheSize = -1
+	}
+	h := httpavatar.New(opts)
+	l.group = groupcache.NewGroup(name, cacheBytes, groupcache.GetterFunc(func(ctx context.Context, key string, dest groupcache.Sink) error {
+		body, err := h.Render(ctx, key)
+		if err != nil {
+			return err
+		}
+		return dest.SetBytes(body)
+	}))
+	return h
+}
+
+// loader loads avatars from a groupcache group
+type loader struct {
+	group *groupcache.Group
+}
+
+func (l *loader) Load(ctx context.Context, key string) ([]byte, error) {
+	var body []byte
+	if err := l.group.Get(ctx, key, groupcache.AllocatingByteSliceSink(&body)); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,51 @@
+package groupavatar
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/recoilme/govatar"
+	"github.com/recoilme/govatar/httpavatar"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingMetrics struct {
+	renders int
+}
+
+func (m *countingMetrics) Served(string, int) {}
+
+func (m *countingMetrics) Rendered(string, time.Duration, error) { m.renders++ }
+
+func (m *countingMetrics) CacheLookup(bool) {}
+
+func TestNewHandler(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+
+	metrics := &countingMetrics{}
+	h := NewHandler("test", 1<<20, httpavatar.Options{Generator: g, Metrics: metrics})
+	var bodies [][]byte
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/avatar/username.png?s=32", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		bodies = append(bodies, w.Body.Bytes())
+	}
+	assert.Equal(t, 1, metrics.renders)
+	assert.Equal(t, bodies[0], bodies[2])
+	img, err := png.Decode(bytes.NewReader(bodies[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, 32, img.Bounds().Dx())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/avatar/username.png?s=2000", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
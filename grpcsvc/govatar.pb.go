@@ -0,0 +1,286 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: govatar.proto
+
+package grpcsvc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Gender int32
+
+const (
+	// Server default
+	Gender_GENDER_UNSPECIFIED Gender = 0
+	Gender_GENDER_MALE        Gender = 1
+	Gender_GENDER_FEMALE      Gender = 2
+	// Parts of both male and female
+	Gender_GENDER_NEUTRAL Gender = 3
+)
+
+// Enum value maps for Gender.
+var (
+	Gender_name = map[int32]string{
+		0: "GENDER_UNSPECIFIED",
+		1: "GENDER_MALE",
+		2: "GENDER_FEMALE",
+		3: "GENDER_NEUTRAL",
+	}
+	Gender_value = map[string]int32{
+		"GENDER_UNSPECIFIED": 0,
+		"GENDER_MALE":        1,
+		"GENDER_FEMALE":      2,
+		"GENDER_NEUTRAL":     3,
+	}
+)
+
+func (x Gender) Enum() *Gender {
+	p := new(Gender)
+	*p = x
+	return p
+}
+
+func (x Gender) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Gender) Descriptor() protoreflect.EnumDescriptor {
+	return file_govatar_proto_enumTypes[0].Descriptor()
+}
+
+func (Gender) Type() protoreflect.EnumType {
+	return &file_govatar_proto_enumTypes[0]
+}
+
+func (x Gender) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Gender.Descriptor instead.
+func (Gender) EnumDescriptor() ([]byte, []int) {
+	return file_govatar_proto_rawDescGZIP(), []int{0}
+}
+
+type GenerateAvatarRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Username string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Gender   Gender                 `protobuf:"varint,2,opt,name=gender,proto3,enum=govatar.v1.Gender" json:"gender,omitempty"`
+	// human, monster or a style added with govatar.Register, empty for the server default
+	Style string `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`
+	// Width and height in pixels, 0 for the server default
+	Size int32 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	// png, jpeg, jpg, gif, webp, avif or svg, empty for png
+	Format        string `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateAvatarRequest) Reset() {
+	*x = GenerateAvatarRequest{}
+	mi := &file_govatar_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateAvatarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateAvatarRequest) ProtoMessage() {}
+
+func (x *GenerateAvatarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_govatar_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateAvatarRequest.ProtoReflect.Descriptor instead.
+func (*GenerateAvatarRequest) Descriptor() ([]byte, []int) {
+	return file_govatar_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GenerateAvatarRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *GenerateAvatarRequest) GetGender() Gender {
+	if x != nil {
+		return x.Gender
+	}
+	return Gender_GENDER_UNSPECIFIED
+}
+
+func (x *GenerateAvatarRequest) GetStyle() string {
+	if x != nil {
+		return x.Style
+	}
+	return ""
+}
+
+func (x *GenerateAvatarRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *GenerateAvatarRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type GenerateAvatarResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Image []byte                 `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	// MIME type of image
+	ContentType   string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateAvatarResponse) Reset() {
+	*x = GenerateAvatarResponse{}
+	mi := &file_govatar_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateAvatarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateAvatarResponse) ProtoMessage() {}
+
+func (x *GenerateAvatarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_govatar_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateAvatarResponse.ProtoReflect.Descriptor instead.
+func (*GenerateAvatarResponse) Descriptor() ([]byte, []int) {
+	return file_govatar_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GenerateAvatarResponse) GetImage() []byte {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *GenerateAvatarResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+var File_govatar_proto protoreflect.FileDescriptor
+
+const file_govatar_proto_rawDesc = "" +
+	"\n" +
+	"\rgovatar.proto\x12\n" +
+	"govatar.v1\"\xa1\x01\n" +
+	"\x15GenerateAvatarRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12*\n" +
+	"\x06gender\x18\x02 \x01(\x0e2\x12.govatar.v1.GenderR\x06gender\x12\x14\n" +
+	"\x05style\x18\x03 \x01(\tR\x05style\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x05R\x04size\x12\x16\n" +
+	"\x06format\x18\x05 \x01(\tR\x06format\"Q\n" +
+	"\x16GenerateAvatarResponse\x12\x14\n" +
+	"\x05image\x18\x01 \x01(\fR\x05image\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType*X\n" +
+	"\x06Gender\x12\x16\n" +
+	"\x12GENDER_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vGENDER_MALE\x10\x01\x12\x11\n" +
+	"\rGENDER_FEMALE\x10\x02\x12\x12\n" +
+	"\x0eGENDER_NEUTRAL\x10\x032a\n" +
+	"\x06Avatar\x12W\n" +
+	"\x0eGenerateAvatar\x12!.govatar.v1.GenerateAvatarRequest\x1a\".govatar.v1.GenerateAvatarResponseB%Z#github.com/recoilme/govatar/grpcsvcb\x06proto3"
+
+var (
+	file_govatar_proto_rawDescOnce sync.Once
+	file_govatar_proto_rawDescData []byte
+)
+
+func file_govatar_proto_rawDescGZIP() []byte {
+	file_govatar_proto_rawDescOnce.Do(func() {
+		file_govatar_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_govatar_proto_rawDesc), len(file_govatar_proto_rawDesc)))
+	})
+	return file_govatar_proto_rawDescData
+}
+
+var file_govatar_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_govatar_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_govatar_proto_goTypes = []any{
+	(Gender)(0),                    // 0: govatar.v1.Gender
+	(*GenerateAvatarRequest)(nil),  // 1: govatar.v1.GenerateAvatarRequest
+	(*GenerateAvatarResponse)(nil), // 2: govatar.v1.GenerateAvatarResponse
+}
+var file_govatar_proto_depIdxs = []int32{
+	0, // 0: govatar.v1.GenerateAvatarRequest.gender:type_name -> govatar.v1.Gender
+	1, // 1: govatar.v1.Avatar.GenerateAvatar:input_type -> govatar.v1.GenerateAvatarRequest
+	2, // 2: govatar.v1.Avatar.GenerateAvatar:output_type -> govatar.v1.GenerateAvatarResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_govatar_proto_init() }
+func file_govatar_proto_init() {
+	if File_govatar_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_govatar_proto_rawDesc), len(file_govatar_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_govatar_proto_goTypes,
+		DependencyIndexes: file_govatar_proto_depIdxs,
+		EnumInfos:         file_govatar_proto_enumTypes,
+		MessageInfos:      file_govatar_proto_msgTypes,
+	}.Build()
+	File_govatar_proto = out.File
+	file_govatar_proto_goTypes = nil
+	file_govatar_proto_depIdxs = nil
+}
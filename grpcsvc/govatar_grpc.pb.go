@@ -0,0 +1,127 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: govatar.proto
+
+package grpcsvc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Avatar_GenerateAvatar_FullMethodName = "/govatar.v1.Avatar/GenerateAvatar"
+)
+
+// AvatarClient is the client API for Avatar service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Avatar generates avatars for services that do not link govatar
+type AvatarClient interface {
+	// GenerateAvatar returns the encoded avatar of a username
+	GenerateAvatar(ctx context.Context, in *GenerateAvatarRequest, opts ...grpc.CallOption) (*GenerateAvatarResponse, error)
+}
+
+type avatarClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAvatarClient(cc grpc.ClientConnInterface) AvatarClient {
+	return &avatarClient{cc}
+}
+
+func (c *avatarClient) GenerateAvatar(ctx context.Context, in *GenerateAvatarRequest, opts ...grpc.CallOption) (*GenerateAvatarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GenerateAvatarResponse)
+	err := c.cc.Invoke(ctx, Avatar_GenerateAvatar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AvatarServer is the server API for Avatar service.
+// All implementations must embed UnimplementedAvatarServer
+// for forward compatibility.
+//
+// Avatar generates avatars for services that do not link govatar
+type AvatarServer interface {
+	// GenerateAvatar returns the encoded avatar of a username
+	GenerateAvatar(context.Context, *GenerateAvatarRequest) (*GenerateAvatarResponse, error)
+	mustEmbedUnimplementedAvatarServer()
+}
+
+// UnimplementedAvatarServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAvatarServer struct{}
+
+func (UnimplementedAvatarServer) GenerateAvatar(context.Context, *GenerateAvatarRequest) (*GenerateAvatarResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GenerateAvatar not implemented")
+}
+func (UnimplementedAvatarServer) mustEmbedUnimplementedAvatarServer() {}
+func (UnimplementedAvatarServer) testEmbeddedByValue()                {}
+
+// UnsafeAvatarServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AvatarServer will
+// result in compilation errors.
+type UnsafeAvatarServer interface {
+	mustEmbedUnimplementedAvatarServer()
+}
+
+func RegisterAvatarServer(s grpc.ServiceRegistrar, srv AvatarServer) {
+	// If the following call panics, it indicates UnimplementedAvatarServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Avatar_ServiceDesc, srv)
+}
+
+func _Avatar_GenerateAvatar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateAvatarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AvatarServer).GenerateAvatar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Avatar_GenerateAvatar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AvatarServer).GenerateAvatar(ctx, req.(*GenerateAvatarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Avatar_ServiceDesc is the grpc.ServiceDesc for Avatar service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Avatar_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "govatar.v1.Avatar",
+	HandlerType: (*AvatarServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GenerateAvatar",
+			Handler:    _Avatar_GenerateAvatar_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "govatar.proto",
+}
@@ -0,0 +1,129 @@
+// Package grpcsvc serves govatar avatars over gRPC, so services in other
+// languages generate avatars from govatar.proto without linking Go code.
+//
+//	s := grpc.NewServer()
+//	grpcsvc.RegisterAvatarServer(s, grpcsvc.NewServer(grpcsvc.Options{}))
+package grpcsvc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative govatar.proto
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/recoilme/govatar"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultMaxSize is the largest avatar size a Server renders by default
+const DefaultMaxSize = 1024
+
+// Options configures a Server
+type Options struct {
+	// Generator draws avatars, nil uses the package level functions of govatar
+	Generator *govatar.Generator
+	// Gender of avatars whose request leaves it unspecified
+	Gender govatar.Gender
+	// MaxSize is the largest size that may be requested, zero means DefaultMaxSize
+	MaxSize int
+	// Renderer is the registered renderer drawing avatars, empty for
+	// govatar.DefaultRenderer. SVG avatars need the default renderer.
+	Renderer string
+	// Cache stores encoded avatars, nil draws every request
+	Cache govatar.Cache
+}
+
+// Server implements AvatarServer
+type Server struct {
+	UnimplementedAvatarServer
+	gen      *govatar.Generator
+	gender   govatar.Gender
+	renderer string
+	cache    govatar.Cache
+	maxSize  int
+}
+
+// NewServer returns a server configured by opts
+func NewServer(opts Options) *Server {
+	s := &Server{gen: opts.Generator, gender: opts.Gender, renderer: opts.Renderer, cache: opts.Cache, maxSize: opts.MaxSize}
+	if s.maxSize <= 0 {
+		s.maxSize = DefaultMaxSize
+	}
+	return s
+}
+
+// GenerateAvatar implements AvatarServer
+func (s *Server) GenerateAvatar(ctx context.Context, req *GenerateAvatarRequest) (*GenerateAvatarResponse, error) {
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Missing username")
+	}
+	format := strings.ToLower(req.GetFormat())
+	if format == "" {
+		format = "png"
+	}
+	contentType := govatar.MIMEType(format)
+	if contentType == "" {
+		return nil, status.Error(codes.InvalidArgument, "Invalid format")
+	}
+	if req.GetSize() < 0 || int(req.GetSize()) > s.maxSize {
+		return nil, status.Error(codes.InvalidArgument, "Invalid size")
+	}
+
+	gender := s.gender
+	switch req.GetGender() {
+	case Gender_GENDER_UNSPECIFIED:
+	case Gender_GENDER_MALE:
+		gender = govatar.MALE
+	case Gender_GENDER_FEMALE:
+		gender = govatar.FEMALE
+	case Gender_GENDER_NEUTRAL:
+		gender = govatar.NEUTRAL
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Invalid gender")
+	}
+	gender, err := govatar.ParseStyle(req.GetStyle(), gender)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid style")
+	}
+
+	opts := []govatar.Option{govatar.WithContext(ctx)}
+	if req.GetSize() > 0 {
+		opts = append(opts, govatar.WithSize(int(req.GetSize())))
+	}
+	if s.renderer != "" {
+		opts = append(opts, govatar.WithRenderer(s.renderer))
+	}
+	if s.cache != nil {
+		opts = append(opts, govatar.WithCache(s.cache))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	image, err := s.generate(gender, req.GetUsername(), format, opts)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, status.FromContextError(err).Err()
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &GenerateAvatarResponse{Image: image, ContentType: contentType}, nil
+}
+
+func (s *Server) generate(gender govatar.Gender, username, format string, opts []govatar.Option) ([]byte, error) {
+	if format == "svg" {
+		var svg string
+		var err error
+		if s.gen != nil {
+			svg, err = s.gen.GenerateSVGFromUsername(gender, username, opts...)
+		} else {
+			svg, err = govatar.GenerateSVGFromUsername(gender, username, opts...)
+		}
+		return []byte(svg), err
+	}
+	if s.gen != nil {
+		return s.gen.GenerateBytesFromUsername(gender, username, format, opts...)
+	}
+	return govatar.GenerateBytesFromUsername(gender, username, format, opts...)
+}
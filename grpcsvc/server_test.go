@@ -0,0 +1,117 @@
+package grpcsvc
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) (AvatarClient, *govatar.Generator) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterAvatarServer(s, NewServer(Options{Generator: g, Gender: govatar.FEMALE, MaxSize: 256}))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewAvatarClient(conn), g
+}
+
+func TestGenerateAvatar(t *testing.T) {
+	client, g := newClient(t)
+	ctx := context.Background()
+
+	resp, err := client.GenerateAvatar(ctx, &GenerateAvatarRequest{Username: "username@site.com", Size: 32})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", resp.GetContentType())
+	img, err := png.Decode(bytes.NewReader(resp.GetImage()))
+	assert.NoError(t, err)
+	assert.Equal(t, 32, img.Bounds().Dx())
+
+	expected, err := g.GenerateBytesFromUsername(govatar.FEMALE, "username@site.com", "png", govatar.WithSize(32))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, resp.GetImage())
+
+	resp, err = client.GenerateAvatar(ctx, &GenerateAvatarRequest{Username: "username@site.com", Style: "monster", Format: "SVG"})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", resp.GetContentType())
+	assert.True(t, bytes.HasPrefix(resp.GetImage(), []byte("<svg")))
+
+	male, err := client.GenerateAvatar(ctx, &GenerateAvatarRequest{Username: "username@site.com", Gender: Gender_GENDER_MALE, Format: "webp"})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/webp", male.GetContentType())
+
+	neutral, err := client.GenerateAvatar(ctx, &GenerateAvatarRequest{Username: "username@site.com", Gender: Gender_GENDER_NEUTRAL})
+	assert.NoError(t, err)
+	expected, err = g.GenerateBytesFromUsername(govatar.NEUTRAL, "username@site.com", "png")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, neutral.GetImage())
+
+	for _, req := range []*GenerateAvatarRequest{
+		{},
+		{Username: "u", Format: "bmp"},
+		{Username: "u", Size: 257},
+		{Username: "u", Size: -1},
+		{Username: "u", Gender: 7},
+		{Username: "u", Style: "alien"},
+		{Username: "u", Style: "male"},
+	} {
+		_, err = client.GenerateAvatar(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+	}
+}
+
+func TestGenerateAvatarContext(t *testing.T) {
+	s := NewServer(Options{})
+	req := &GenerateAvatarRequest{Username: "username@site.com"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.GenerateAvatar(ctx, req)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	_, err = s.GenerateAvatar(ctx, req)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestGenerateAvatarCache(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	cache := govatar.NewMemoryCache(8)
+	s := NewServer(Options{Generator: g, Cache: cache})
+
+	req := &GenerateAvatarRequest{Username: "username@site.com"}
+	first, err := s.GenerateAvatar(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cache.Len())
+	second, err := s.GenerateAvatar(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, first.GetImage(), second.GetImage())
+	assert.Equal(t, 1, cache.Len())
+}
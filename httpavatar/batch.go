@@ -0,0 +1,113 @@
+package httpavatar
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidBatch = errors.New("Invalid batch")
+	errBatchTooBig  = errors.New("Too many usernames in batch")
+)
+
+// batch is the body of POST /batch. Options apply to every avatar and take
+// the values of the query parameters of the same name.
+type batch struct {
+	Usernames []string `json:"usernames"`
+	Size      int      `json:"s,omitempty"`
+	Format    string   `json:"format,omitempty"`
+	Gender    string   `json:"gender,omitempty"`
+	Style     string   `json:"style,omitempty"`
+	Theme     string   `json:"theme,omitempty"`
+	Palette   string   `json:"palette,omitempty"`
+	Version   string   `json:"v,omitempty"`
+}
+
+// query returns the options of b as query parameters
+func (b batch) query() url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if b.Size != 0 {
+		set("s", strconv.Itoa(b.Size))
+	}
+	set("format", b.Format)
+	set("gender", b.Gender)
+	set("style", b.Style)
+	set("theme", b.Theme)
+	set("palette", b.Palette)
+	set("v", b.Version)
+	return query
+}
+
+// serveBatch streams a zip of the avatars of the usernames listed in the
+// body, named {username}.{format}. Avatars listed twice are written once.
+func (h *Handler) serveBatch(w http.ResponseWriter, r *http.Request) {
+	var b batch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&b); err != nil || len(b.Usernames) == 0 {
+		http.Error(w, errInvalidBatch.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(b.Usernames) > h.maxBatch {
+		http.Error(w, errBatchTooBig.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	template := request{format: "png", gender: h.gender}
+	if err := h.parseQuery(&template, b.query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reqs := make([]request, 0, len(b.Usernames))
+	for _, username := range b.Usernames {
+		if username == "" || strings.Contains(username, "/") {
+			http.Error(w, errInvalidBatch.Error(), http.StatusBadRequest)
+			return
+		}
+		req := template
+		req.username = username
+		if !h.before(w, r, &req) {
+			return
+		}
+		reqs = append(reqs, req)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="avatars.zip"`)
+	zw := zip.NewWriter(w)
+	written := map[string]bool{}
+	for _, req := range reqs {
+		name := req.username + "." + req.format
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		body, err := h.avatar(r.Context(), req, h.avatarID(req), false)
+		if err != nil {
+			// The status is sent, leaving the archive without its directory
+			// tells the client it is incomplete
+			return
+		}
+		// Raster formats are compressed already
+		method := zip.Store
+		if req.format == "svg" {
+			method = zip.Deflate
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			return
+		}
+		if _, err = f.Write(body); err != nil {
+			return
+		}
+		h.after(r, req, body)
+	}
+	zw.Close()
+}
@@ -0,0 +1,57 @@
+package httpavatar
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func post(h http.Handler, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+	return w
+}
+
+func TestServeBatch(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	h := New(Options{Generator: g, Gender: govatar.FEMALE, MaxBatch: 3})
+
+	w := post(h, "/batch", `{"usernames": ["alice", "bob", "alice"], "format": "webp", "s": 32}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, zr.File, 2)
+	assert.Equal(t, "alice.webp", zr.File[0].Name)
+	assert.Equal(t, "bob.webp", zr.File[1].Name)
+	f, err := zr.File[1].Open()
+	assert.NoError(t, err)
+	avatar, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, get(h, http.MethodGet, "/avatar/bob.webp?s=32").Body.Bytes(), avatar)
+
+	for body, status := range map[string]int{
+		`{"usernames": []}`:                      http.StatusBadRequest,
+		`{"usernames": ["a/b"]}`:                 http.StatusBadRequest,
+		`{"usernames": ["a"], "format": "tiff"}`: http.StatusBadRequest,
+		`not json`:                               http.StatusBadRequest,
+		`{"usernames": ["a", "b", "c", "d"]}`:    http.StatusRequestEntityTooLarge,
+	} {
+		assert.Equal(t, status, post(h, "/batch", body).Code, body)
+	}
+	assert.Equal(t, http.StatusMethodNotAllowed, get(h, http.MethodGet, "/batch").Code)
+
+	// Batches are off by default
+	assert.Equal(t, http.StatusMethodNotAllowed, post(newHandler(t, 0), "/batch", `{"usernames": ["a"]}`).Code)
+}
@@ -0,0 +1,57 @@
+package httpavatar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip, br": true,
+		"GZIP;q=0.5":        true,
+		"gzip;q=0, deflate": false,
+		"*":                 true,
+		"identity, deflate": false,
+	} {
+		assert.Equal(t, want, acceptsGzip(header), header)
+	}
+}
+
+func TestServeCompressed(t *testing.T) {
+	h := newHandler(t, 0)
+	plain := get(h, http.MethodGet, "/avatar/username.svg")
+	assert.Equal(t, http.StatusOK, plain.Code)
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", plain.Header().Get("Vary"))
+
+	r := httptest.NewRequest(http.MethodGet, "/avatar/username.svg", nil)
+	r.Header.Set("Accept-Encoding", "gzip, br")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.NotEqual(t, plain.Header().Get("ETag"), w.Header().Get("ETag"))
+	assert.Less(t, w.Body.Len(), plain.Body.Len())
+	zr, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	svg, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(plain.Body.Bytes(), svg))
+
+	// Raster formats are sent as they are
+	r = httptest.NewRequest(http.MethodGet, "/avatar/username.png", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
@@ -0,0 +1,55 @@
+package httpavatar
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/recoilme/govatar"
+)
+
+// gravatarDefault returns the d parameter of Gravatar URLs, or its long
+// form default
+func gravatarDefault(query url.Values) string {
+	if d := query.Get("d"); d != "" {
+		return d
+	}
+	return query.Get("default")
+}
+
+// parseDefault draws the avatar of req in the govatar mode matching the
+// Gravatar default d: identicons, monsters, animals for wavatar and robots for
+// robohash. Other defaults leave req as is, styles left out of the build too.
+func parseDefault(req *request, d string) {
+	switch d {
+	case "identicon":
+		// Identicons have no SVG form
+		if req.format != "svg" {
+			req.renderer = govatar.IdenticonRenderer
+		}
+	case "monsterid":
+		req.gender = govatar.MONSTER
+	case "wavatar", "robohash":
+		style := map[string]string{"wavatar": "animal", "robohash": "robot"}[d]
+		if g, ok := govatar.LookupStyle(style); ok {
+			req.gender = g
+		}
+	}
+}
+
+// serveDefault answers requests with Gravatar defaults asking for no
+// generated avatar the way Gravatar answers them for emails without one:
+// d=404 with 404 Not Found and d set to an URL with a redirect to it. It
+// reports whether the request was answered.
+func serveDefault(w http.ResponseWriter, r *http.Request) bool {
+	d := gravatarDefault(r.URL.Query())
+	if d == "404" {
+		http.NotFound(w, r)
+		return true
+	}
+	u, err := url.Parse(d)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	http.Redirect(w, r, u.String(), http.StatusFound)
+	return true
+}
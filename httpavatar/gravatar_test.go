@@ -0,0 +1,38 @@
+package httpavatar
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGravatarDefaults(t *testing.T) {
+	h := newHandler(t, 0)
+	w := get(h, http.MethodGet, "/avatar/username.png?d=404")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	w = get(h, http.MethodGet, "/avatar/username.png?d="+url.QueryEscape("https://example.com/avatar.png?s=80"))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/avatar.png?s=80", w.Header().Get("Location"))
+	for _, d := range []string{"javascript:alert(1)", "/local.png", "mp"} {
+		assert.Equal(t, http.StatusOK, get(h, http.MethodGet, "/avatar/username.png?d="+url.QueryEscape(d)).Code, d)
+	}
+
+	identicon := get(h, http.MethodGet, "/avatar/username.png?d=identicon")
+	assert.Equal(t, http.StatusOK, identicon.Code)
+	assert.NotEqual(t, get(h, http.MethodGet, "/avatar/username.png").Header().Get("ETag"), identicon.Header().Get("ETag"))
+}
+
+func TestParseDefault(t *testing.T) {
+	robot, ok := govatar.LookupStyle("robot")
+	if !ok {
+		t.Skip("built without the robot style")
+	}
+	req := request{gender: govatar.FEMALE, format: "png"}
+	parseDefault(&req, "robohash")
+	assert.Equal(t, robot, req.gender)
+}
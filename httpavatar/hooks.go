@@ -0,0 +1,108 @@
+package httpavatar
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/recoilme/govatar"
+)
+
+var errRejected = errors.New("Forbidden")
+
+// Hooks let integrators add auth, quotas or per-user overrides without
+// reimplementing the handler. Both are called for avatars of /avatar,
+// /session and /batch.
+type Hooks struct {
+	// Before is called once the query is parsed, before the avatar is looked
+	// up. It may change the avatar requested. A non-nil error vetoes the
+	// request, answered with the status of a Rejection or 403 Forbidden.
+	Before func(r *http.Request, avatar *Avatar) error
+	// After is called once the avatar is served, with its spec and encoded
+	// bytes. Specs are empty for renderers other than the default.
+	After func(r *http.Request, avatar Avatar, spec govatar.Spec, body []byte)
+}
+
+// Avatar is the avatar of a request as seen by hooks
+type Avatar struct {
+	Username string
+	Gender   govatar.Gender
+	// Format is png, jpeg, jpg, gif, webp, avif or svg
+	Format string
+	// Size is zero for the size of the generator
+	Size int
+	// Theme is empty or a registered theme, not only those of Options.Themes
+	Theme string
+	// Palette is empty or a palette of Options.Palettes
+	Palette string
+	// Version is the cache busting parameter v
+	Version string
+}
+
+// Rejection vetoes a request from Hooks.Before with Status
+type Rejection struct {
+	Status  int
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// avatar returns the avatar of req as seen by hooks
+func (req request) avatar() Avatar {
+	return Avatar{Username: req.username, Gender: req.gender, Format: req.format, Size: req.size, Theme: req.theme, Palette: req.palette, Version: req.bust}
+}
+
+// before lets Hooks.Before adjust or veto req, answering vetoed requests
+// and requests changed into invalid ones. It reports whether to go on.
+func (h *Handler) before(w http.ResponseWriter, r *http.Request, req *request) bool {
+	if h.hooks.Before == nil {
+		return true
+	}
+	a := req.avatar()
+	if err := h.hooks.Before(r, &a); err != nil {
+		var rejection *Rejection
+		if errors.As(err, &rejection) {
+			http.Error(w, rejection.Message, rejection.Status)
+		} else {
+			http.Error(w, errRejected.Error(), http.StatusForbidden)
+		}
+		return false
+	}
+	switch {
+	case a.Username == "" || strings.Contains(a.Username, "/"):
+		http.NotFound(w, r)
+		return false
+	case a.Size < 0 || a.Size > h.maxSize:
+		http.Error(w, errInvalidSize.Error(), http.StatusInternalServerError)
+		return false
+	case govatar.MIMEType(a.Format) == "":
+		http.Error(w, errInvalidFormat.Error(), http.StatusInternalServerError)
+		return false
+	case !validGender(a.Gender) || !h.validTheme(a.Theme) || !h.validPalette(a.Palette) || !validBust(a.Version):
+		http.Error(w, errInvalidKey.Error(), http.StatusInternalServerError)
+		return false
+	}
+	req.username, req.gender, req.format, req.size = a.Username, a.Gender, a.Format, a.Size
+	req.theme, req.palette, req.bust = a.Theme, a.Palette, a.Version
+	return true
+}
+
+// validGender reports whether g is a builtin or registered gender
+func validGender(g govatar.Gender) bool {
+	parsed, err := govatar.ParseGender(g.String())
+	return err == nil && parsed == g
+}
+
+// after passes the served avatar of req encoded as body to Hooks.After
+func (h *Handler) after(r *http.Request, req request, body []byte) {
+	if h.hooks.After == nil {
+		return
+	}
+	var spec govatar.Spec
+	if req.renderer == "" && h.renderer == "" {
+		spec, _ = h.gen.SpecFromUsername(req.gender, req.username)
+	}
+	h.hooks.After(r, req.avatar(), spec, body)
+}
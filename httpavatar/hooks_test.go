@@ -0,0 +1,69 @@
+package httpavatar
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var served []Avatar
+	var specs []govatar.Spec
+	hooks := Hooks{
+		Before: func(r *http.Request, a *Avatar) error {
+			switch {
+			case a.Username == "banned":
+				return errors.New("banned")
+			case a.Username == "quota":
+				return &Rejection{Status: http.StatusTooManyRequests, Message: "Quota exceeded"}
+			case strings.HasPrefix(a.Username, "admin"):
+				// Per-user overrides
+				a.Gender = govatar.MONSTER
+				a.Size = 32
+			case a.Username == "broken":
+				a.Format = "tiff"
+			}
+			return nil
+		},
+		After: func(r *http.Request, a Avatar, spec govatar.Spec, body []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			served = append(served, a)
+			specs = append(specs, spec)
+		},
+	}
+	h := New(Options{Generator: g, Gender: govatar.FEMALE, Hooks: hooks, MaxBatch: 10})
+
+	assert.Equal(t, http.StatusForbidden, get(h, http.MethodGet, "/avatar/banned.png").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get(h, http.MethodGet, "/avatar/quota.png").Code)
+	assert.Equal(t, http.StatusInternalServerError, get(h, http.MethodGet, "/avatar/broken.png").Code)
+	assert.Empty(t, served)
+
+	w := get(h, http.MethodGet, "/avatar/admin.png")
+	assert.Equal(t, http.StatusOK, w.Code)
+	expected, err := g.GenerateBytesFromUsername(govatar.MONSTER, "admin", "png", govatar.WithSize(32))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, w.Body.Bytes())
+	assert.Equal(t, []Avatar{{Username: "admin", Gender: govatar.MONSTER, Format: "png", Size: 32}}, served)
+	spec, err := g.SpecFromUsername(govatar.MONSTER, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, []govatar.Spec{spec}, specs)
+
+	// Batches are checked before streaming
+	assert.Equal(t, http.StatusForbidden, post(h, "/batch", `{"usernames": ["admin", "banned"]}`).Code)
+	assert.Len(t, served, 1)
+	assert.Equal(t, http.StatusOK, post(h, "/batch", `{"usernames": ["admin", "admin-2"]}`).Code)
+	assert.Len(t, served, 3)
+}
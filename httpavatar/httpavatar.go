@@ -0,0 +1,450 @@
+// Package httpavatar serves govatar avatars over HTTP.
+//
+// A Handler answers
+//
+//	GET /avatar/{username}.{png,jpg,gif,webp,avif,svg}  the avatar of username
+//	GET /avatar/{username}?s=128&format=webp&gender=female&style=monster
+//	GET /catalog.json                                   the asset catalog
+//	GET /license.json                                   the artwork license
+//	POST /session                                       a token for an anonymous avatar
+//	GET /session/{token}.{png,...}                      the avatar of the token until it expires
+//	POST /batch                                         a zip of the avatars of many usernames
+//
+// Query parameters override the size (s), format, gender (male, female) and
+// style (human, monster, robot, animal or one added with govatar.Register) of
+// the avatar, theme and palette pick themes and background palettes listed in
+// Options.Themes and Options.Palettes. Avatars without extension or format parameter come in the
+// smallest format the Accept header names, avif, webp or svg, png otherwise.
+// Session avatars take the same parameters and are served with
+// Options.SessionTTL set, batches with Options.MaxBatch set. The cache
+// busting parameter v changes the ETag and cache key of the avatar, f=y draws
+// it again ignoring caches. The Gravatar parameter d (default) answers 404
+// for d=404, redirects to URLs and maps identicon, monsterid, wavatar and
+// robohash onto govatar styles.
+//
+// Mount it under a prefix with http.StripPrefix.
+package httpavatar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/recoilme/govatar"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultCacheSize is the number of encoded avatars a Handler keeps by default
+const DefaultCacheSize = 1024
+
+// DefaultMaxAge is how long clients may reuse a served avatar by default
+const DefaultMaxAge = 365 * 24 * time.Hour
+
+// Options configures a Handler
+type Options struct {
+	// Generator draws avatars, nil uses the package level functions of govatar
+	Generator *govatar.Generator
+	// Gender of served avatars unless requested otherwise
+	Gender govatar.Gender
+	// MaxSize is the largest size that may be requested, zero means DefaultMaxSize
+	MaxSize int
+	// Cache stores encoded avatars, e.g. shared by instances of a service.
+	// Nil keeps CacheSize avatars in memory.
+	Cache govatar.Cache
+	// CacheSize is the number of encoded avatars kept in memory without Cache.
+	// Zero means DefaultCacheSize, negative disables caching.
+	CacheSize int
+	// MaxRenders limits the number of avatars drawn at once, zero means no
+	// limit. Requests wait for a free slot until they are canceled.
+	MaxRenders int
+	// Loader fetches avatars missing from the cache, e.g. from the instance
+	// of a cluster responsible for them. Nil renders them.
+	Loader Loader
+	// Metrics receives request, render and cache events, nil discards them
+	Metrics Metrics
+	// MaxAge sets Cache-Control of avatars. Zero means DefaultMaxAge,
+	// negative makes clients revalidate every request.
+	MaxAge time.Duration
+	// Renderer is the registered renderer drawing avatars, empty for
+	// govatar.DefaultRenderer. SVG avatars need the default renderer.
+	Renderer string
+	// Version is mixed into ETags and cache keys. Change it when Generator starts drawing
+	// different avatars for the same username, e.g. after changing assets.
+	Version string
+	// SessionTTL enables POST /session, issuing tokens of random avatars
+	// valid for this long, e.g. for anonymous chat rooms. Tokens are kept in
+	// Cache, or in memory without it, and end early when the cache drops them.
+	SessionTTL time.Duration
+	// MaxBatch enables POST /batch, streaming a zip of the avatars of up to
+	// this many usernames, e.g. for admin tools exporting avatars
+	MaxBatch int
+	// Webhook is notified of every avatar drawn, nil notifies nobody
+	Webhook *Webhook
+	// Hooks adjust, veto and inspect avatar requests
+	Hooks Hooks
+	// Themes lists the registered themes clients may pick with the theme
+	// query parameter, nil allows none
+	Themes []string
+	// Palettes are the background colors clients may pick by name with the
+	// palette query parameter, nil allows none
+	Palettes map[string][]color.Color
+}
+
+// Loader loads encoded avatars by key. Implementations get the avatar of a
+// key from Handler.Render, on this or another instance.
+type Loader interface {
+	Load(ctx context.Context, key string) ([]byte, error)
+}
+
+// tracer records a span for every avatar request
+var tracer = otel.Tracer("github.com/recoilme/govatar/httpavatar")
+
+// generator is the part of govatar.Generator the handler uses
+type generator interface {
+	GenerateBytesFromUsername(gender govatar.Gender, username string, format string, opts ...govatar.Option) ([]byte, error)
+	GenerateSVGFromUsername(gender govatar.Gender, username string, opts ...govatar.Option) (string, error)
+	SpecFromUsername(gender govatar.Gender, username string) (govatar.Spec, error)
+	Catalog() govatar.Catalog
+	License() (govatar.License, bool)
+}
+
+// defaultGenerator forwards to the package level functions
+type defaultGenerator struct{}
+
+func (defaultGenerator) GenerateBytesFromUsername(gender govatar.Gender, username string, format string, opts ...govatar.Option) ([]byte, error) {
+	return govatar.GenerateBytesFromUsername(gender, username, format, opts...)
+}
+
+func (defaultGenerator) GenerateSVGFromUsername(gender govatar.Gender, username string, opts ...govatar.Option) (string, error) {
+	return govatar.GenerateSVGFromUsername(gender, username, opts...)
+}
+
+func (defaultGenerator) SpecFromUsername(gender govatar.Gender, username string) (govatar.Spec, error) {
+	return govatar.SpecFromUsername(gender, username)
+}
+
+func (defaultGenerator) Catalog() govatar.Catalog { return govatar.GetCatalog() }
+
+func (defaultGenerator) License() (govatar.License, bool) { return govatar.AssetsLicense() }
+
+// Handler generates avatars on request and caches the encoded bytes
+type Handler struct {
+	gen          generator
+	gender       govatar.Gender
+	cache        govatar.Cache
+	cacheControl string
+	version      string
+	renderer     string
+	maxSize      int
+	metrics      Metrics
+	loader       Loader
+	sessionTTL   time.Duration
+	maxBatch     int
+	webhook      *Webhook
+	hooks        Hooks
+	themes       map[string]bool
+	palettes     map[string][]color.Color
+	// sessions holds the expiry of session tokens, nil without sessions
+	sessions govatar.Cache
+	// renders holds a token for every avatar being drawn, nil if unlimited
+	renders chan struct{}
+}
+
+// New returns a handler configured by opts
+func New(opts Options) *Handler {
+	h := &Handler{gen: defaultGenerator{}, gender: opts.Gender, version: opts.Version, renderer: opts.Renderer, maxSize: opts.MaxSize, maxBatch: opts.MaxBatch, webhook: opts.Webhook, hooks: opts.Hooks}
+	if h.maxSize <= 0 {
+		h.maxSize = DefaultMaxSize
+	}
+	h.metrics = opts.Metrics
+	h.loader = opts.Loader
+	h.themes = make(map[string]bool, len(opts.Themes))
+	for _, theme := range opts.Themes {
+		h.themes[theme] = true
+	}
+	h.palettes = make(map[string][]color.Color, len(opts.Palettes))
+	for name, palette := range opts.Palettes {
+		h.palettes[name] = append([]color.Color(nil), palette...)
+	}
+	if h.metrics == nil {
+		h.metrics = noMetrics{}
+	}
+	if opts.MaxRenders > 0 {
+		h.renders = make(chan struct{}, opts.MaxRenders)
+	}
+	if opts.Generator != nil {
+		h.gen = opts.Generator
+	}
+	switch {
+	case opts.Cache != nil:
+		h.cache = opts.Cache
+	case opts.CacheSize == 0:
+		h.cache = govatar.NewMemoryCache(DefaultCacheSize)
+	case opts.CacheSize > 0:
+		h.cache = govatar.NewMemoryCache(opts.CacheSize)
+	}
+	if opts.SessionTTL > 0 {
+		h.sessionTTL = opts.SessionTTL
+		h.sessions = opts.Cache
+		if h.sessions == nil {
+			h.sessions = govatar.NewMemoryCache(DefaultCacheSize)
+		}
+	}
+	switch {
+	case opts.MaxAge == 0:
+		h.cacheControl = cacheControl(DefaultMaxAge)
+	case opts.MaxAge > 0:
+		h.cacheControl = cacheControl(opts.MaxAge)
+	default:
+		h.cacheControl = "no-cache"
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var post http.HandlerFunc
+	switch {
+	case r.URL.Path == "/session" && h.sessions != nil:
+		post = h.newSession
+	case r.URL.Path == "/batch" && h.maxBatch > 0:
+		post = h.serveBatch
+	}
+	if post != nil {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		post(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case r.URL.Path == "/catalog.json":
+		writeJSON(w, h.gen.Catalog())
+	case r.URL.Path == "/license.json":
+		license, ok := h.gen.License()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, license)
+	case strings.HasPrefix(r.URL.Path, "/avatar/"):
+		h.serveAvatar(w, r, strings.TrimPrefix(r.URL.Path, "/avatar/"), h.cacheControl)
+	case strings.HasPrefix(r.URL.Path, "/session/") && h.sessions != nil:
+		h.serveSession(w, r, strings.TrimPrefix(r.URL.Path, "/session/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveAvatar writes the avatar named by file, a username with optional
+// format extension, with Cache-Control set to cacheControl
+func (h *Handler) serveAvatar(w http.ResponseWriter, r *http.Request, file, cacheControl string) {
+	username, format := parseFile(file)
+	if username == "" || strings.Contains(username, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	req := request{username: username, format: format, gender: h.gender}
+	// Avatars without extension or format parameter come in the format the
+	// client prefers
+	if !strings.EqualFold(path.Ext(file), "."+format) && r.URL.Query().Get("format") == "" {
+		req.format = negotiate(r.Header.Get("Accept"), h.renderer == "")
+		w.Header().Add("Vary", "Accept")
+	}
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	w = sw
+	ctx, span := tracer.Start(r.Context(), "httpavatar.ServeAvatar")
+	defer func() {
+		h.metrics.Served(req.format, sw.status)
+		span.SetAttributes(attribute.String("govatar.format", req.format), attribute.Int("http.response.status_code", sw.status))
+		span.End()
+	}()
+	if err := h.parseQuery(&req, r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.before(w, r, &req) {
+		return
+	}
+	if serveDefault(w, r) {
+		return
+	}
+
+	// f=y draws the avatar again, e.g. after changing assets, and refreshes
+	// the cache with it
+	force := r.URL.Query().Get("f") == "y"
+	id := h.avatarID(req)
+	etag := `"` + id + `"`
+	// SVG compresses well, clients accepting gzip get it compressed and
+	// tagged apart
+	var gz bool
+	if req.format == "svg" {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if gz = acceptsGzip(r.Header.Get("Accept-Encoding")); gz {
+			etag = `"` + id + `-gzip"`
+		}
+	}
+	if !force && noneMatch(r.Header.Get("If-None-Match"), etag) {
+		setCaching(w, etag, cacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := h.avatar(ctx, req, id, force)
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if gz {
+		body = gzipBytes(body)
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	setCaching(w, etag, cacheControl)
+	w.Header().Set("Content-Type", govatar.MIMEType(req.format))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodGet {
+		w.Write(body)
+	}
+	h.after(r, req, body)
+}
+
+// avatar returns the encoded avatar of req identified by id from the cache,
+// the loader or drawn. force draws it whatever the cache holds.
+func (h *Handler) avatar(ctx context.Context, req request, id string, force bool) ([]byte, error) {
+	if h.cache != nil && !force {
+		body, ok := h.cache.Get(id)
+		h.metrics.CacheLookup(ok)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("govatar.cache_hit", ok))
+		if ok {
+			return body, nil
+		}
+	}
+	var body []byte
+	var err error
+	if h.loader != nil && !force {
+		body, err = h.loader.Load(ctx, req.key())
+	} else {
+		body, err = h.render(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.cache != nil {
+		h.cache.Set(id, body)
+	}
+	return body, nil
+}
+
+// setCaching lets clients and proxies cache the avatar tagged etag as set by
+// cacheControl. Only avatars are cached, errors are retried on the next request
+func setCaching(w http.ResponseWriter, etag, cacheControl string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+}
+
+// Render returns the avatar identified by key, as passed to Loader.Load
+func (h *Handler) Render(ctx context.Context, key string) ([]byte, error) {
+	req, err := h.parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return h.render(ctx, req)
+}
+
+// render draws and encodes the avatar of req once a render slot is free
+func (h *Handler) render(ctx context.Context, req request) ([]byte, error) {
+	if h.renders != nil {
+		select {
+		case h.renders <- struct{}{}:
+			defer func() { <-h.renders }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	start := time.Now()
+	body, err := h.generate(ctx, req)
+	h.metrics.Rendered(req.format, time.Since(start), err)
+	if err == nil && h.webhook != nil {
+		h.notify(req, body)
+	}
+	return body, err
+}
+
+func (h *Handler) generate(ctx context.Context, req request) ([]byte, error) {
+	opts := []govatar.Option{govatar.WithContext(ctx)}
+	if req.size > 0 {
+		opts = append(opts, govatar.WithSize(req.size))
+	}
+	switch {
+	case req.renderer != "":
+		opts = append(opts, govatar.WithRenderer(req.renderer))
+	case h.renderer != "":
+		opts = append(opts, govatar.WithRenderer(h.renderer))
+	}
+	if req.theme != "" {
+		opts = append(opts, govatar.WithTheme(req.theme))
+	}
+	// Palettes replace the backgrounds of themes
+	if req.palette != "" {
+		opts = append(opts, govatar.WithSolidBackground(h.palettes[req.palette]...))
+	}
+	if req.format == "svg" {
+		svg, err := h.gen.GenerateSVGFromUsername(req.gender, req.username, opts...)
+		return []byte(svg), err
+	}
+	return h.gen.GenerateBytesFromUsername(req.gender, req.username, req.format, opts...)
+}
+
+// avatarID returns the hex encoded ETag and cache key of the requested
+// avatar. Avatars are deterministic, so it is derived from what the avatar is
+// drawn from rather than its bytes.
+func (h *Handler) avatarID(req request) string {
+	id := fmt.Sprintf("%d\x00%s\x00%s", govatar.MappingVersion, h.version, req.key())
+	if h.renderer != "" {
+		id += "\x00" + h.renderer
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:16])
+}
+
+// noneMatch reports whether If-None-Match header lists etag
+func noneMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheControl(maxAge time.Duration) string {
+	return "public, max-age=" + strconv.Itoa(int(maxAge/time.Second))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,293 @@
+package httpavatar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newHandler(t *testing.T, cacheSize int) *Handler {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	return New(Options{Generator: g, Gender: govatar.FEMALE, CacheSize: cacheSize})
+}
+
+func get(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestServeAvatar(t *testing.T) {
+	h := newHandler(t, 0)
+
+	w := get(h, http.MethodGet, "/avatar/username@site.com.png")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 64, img.Bounds().Dx())
+
+	// Served from cache
+	again := get(h, http.MethodGet, "/avatar/username@site.com.png")
+	assert.Equal(t, w.Body.Bytes(), again.Body.Bytes())
+	_, ok := h.cache.Get(h.avatarID(request{username: "username@site.com", format: "png", gender: govatar.FEMALE}))
+	assert.True(t, ok)
+
+	w = get(h, http.MethodGet, "/avatar/username@site.com.svg")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+
+	w = get(h, http.MethodHead, "/avatar/username@site.com.webp")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Content-Length"))
+	assert.Empty(t, w.Body.Bytes())
+}
+
+func TestServeErrors(t *testing.T) {
+	h := newHandler(t, -1)
+
+	for _, target := range []string{"/avatar/.png", "/avatar/a/b.png", "/avatar/", "/other"} {
+		assert.Equal(t, http.StatusNotFound, get(h, http.MethodGet, target).Code, target)
+	}
+
+	w := get(h, http.MethodPost, "/avatar/username.png")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+
+	// Caching disabled
+	assert.Equal(t, http.StatusOK, get(h, http.MethodGet, "/avatar/username.png").Code)
+	assert.Nil(t, h.cache)
+}
+
+func TestServeCustomCache(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	cache := govatar.NewMemoryCache(10)
+	h := New(Options{Generator: g, Cache: cache, CacheSize: -1})
+
+	w := get(h, http.MethodGet, "/avatar/username.png")
+	assert.Equal(t, 1, cache.Len())
+	cached, ok := cache.Get(strings.Trim(w.Header().Get("ETag"), `"`))
+	assert.True(t, ok)
+	assert.Equal(t, w.Body.Bytes(), cached)
+}
+
+func TestServeQuery(t *testing.T) {
+	h := newHandler(t, 0)
+
+	w := get(h, http.MethodGet, "/avatar/username?s=32")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 32, img.Bounds().Dx())
+
+	w = get(h, http.MethodGet, "/avatar/username.png?format=webp")
+	assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+	w = get(h, http.MethodGet, "/avatar/User.SVG")
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+
+	female := get(h, http.MethodGet, "/avatar/username.png")
+	male := get(h, http.MethodGet, "/avatar/username.png?gender=male")
+	monster := get(h, http.MethodGet, "/avatar/username.png?gender=female&style=monster")
+	assert.NotEqual(t, female.Body.Bytes(), male.Body.Bytes())
+	assert.NotEqual(t, female.Header().Get("ETag"), male.Header().Get("ETag"))
+	assert.NotEqual(t, female.Body.Bytes(), monster.Body.Bytes())
+	assert.Equal(t, female.Body.Bytes(), get(h, http.MethodGet, "/avatar/username.png?gender=f&style=human").Body.Bytes())
+
+	// A file extension that is not a format is part of the username
+	assert.Equal(t, http.StatusOK, get(h, http.MethodGet, "/avatar/username@site.com").Code)
+
+	for _, query := range []string{"s=0", "s=-1", "s=1025", "s=big", "format=bmp", "gender=x", "style=alien", "theme=dark", "palette=night"} {
+		assert.Equal(t, http.StatusBadRequest, get(h, http.MethodGet, "/avatar/username.png?"+query).Code, query)
+	}
+}
+
+func TestServeTheme(t *testing.T) {
+	h := newHandler(t, 0)
+	h.themes = map[string]bool{"dark": true}
+	h.palettes = map[string][]color.Color{"night": {color.Black}}
+	plain := get(h, http.MethodGet, "/avatar/username.png")
+	dark := get(h, http.MethodGet, "/avatar/username.png?theme=dark")
+	night := get(h, http.MethodGet, "/avatar/username.png?theme=dark&palette=night")
+	for _, w := range []*httptest.ResponseRecorder{dark, night} {
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, plain.Body.Bytes(), w.Body.Bytes())
+		assert.NotEqual(t, plain.Header().Get("ETag"), w.Header().Get("ETag"))
+	}
+	assert.NotEqual(t, dark.Body.Bytes(), night.Body.Bytes())
+
+	img, err := png.Decode(bytes.NewReader(night.Body.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, [3]uint32{0, 0, 0}, [3]uint32{r, g, b})
+
+	assert.Equal(t, http.StatusBadRequest, get(h, http.MethodGet, "/avatar/username.png?theme=neon").Code)
+}
+
+func TestServeCatalog(t *testing.T) {
+	h := newHandler(t, 0)
+
+	w := get(h, http.MethodGet, "/catalog.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var catalog govatar.Catalog
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &catalog))
+	assert.Equal(t, govatar.MappingVersion, catalog.MappingVersion)
+	assert.Len(t, catalog.Genders, len(govatar.Styles()))
+
+	// The data directory is not a pack declaring a license
+	assert.Equal(t, http.StatusNotFound, get(h, http.MethodGet, "/license.json").Code)
+}
+
+func TestConditionalGet(t *testing.T) {
+	h := newHandler(t, 0)
+
+	w := get(h, http.MethodGet, "/avatar/username.png")
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "public, max-age=31536000", w.Header().Get("Cache-Control"))
+	assert.Equal(t, etag, get(h, http.MethodGet, "/avatar/username.png").Header().Get("ETag"))
+	assert.NotEqual(t, etag, get(h, http.MethodGet, "/avatar/username.jpg").Header().Get("ETag"))
+	assert.NotEqual(t, etag, get(h, http.MethodGet, "/avatar/other.png").Header().Get("ETag"))
+
+	for _, match := range []string{etag, `"x", ` + etag, "W/" + etag, "*"} {
+		r := httptest.NewRequest(http.MethodGet, "/avatar/username.png", nil)
+		r.Header.Set("If-None-Match", match)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotModified, w.Code, match)
+		assert.Empty(t, w.Body.Bytes())
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/avatar/username.png", nil)
+	r.Header.Set("If-None-Match", `"stale"`)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	versioned := New(Options{Generator: h.gen.(*govatar.Generator), Gender: govatar.FEMALE, Version: "2", MaxAge: -1})
+	w = get(versioned, http.MethodGet, "/avatar/username.png")
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+}
+
+func TestCacheBusting(t *testing.T) {
+	cache := govatar.NewMemoryCache(10)
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	h := New(Options{Generator: g, Cache: cache})
+
+	etag := get(h, http.MethodGet, "/avatar/username.png").Header().Get("ETag")
+	busted := get(h, http.MethodGet, "/avatar/username.png?v=theme-2").Header().Get("ETag")
+	assert.NotEqual(t, etag, busted)
+	assert.Equal(t, busted, get(h, http.MethodGet, "/avatar/username.png?v=theme-2").Header().Get("ETag"))
+	assert.Equal(t, http.StatusBadRequest, get(h, http.MethodGet, "/avatar/username.png?v=a/b").Code)
+
+	// f=y ignores the cache and conditional requests and refreshes the cache
+	id := strings.Trim(etag, `"`)
+	cache.Set(id, []byte("stale"))
+	assert.Equal(t, []byte("stale"), get(h, http.MethodGet, "/avatar/username.png").Body.Bytes())
+	r := httptest.NewRequest(http.MethodGet, "/avatar/username.png?f=y", nil)
+	r.Header.Set("If-None-Match", etag)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	_, err = png.Decode(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	cached, ok := cache.Get(id)
+	assert.True(t, ok)
+	assert.Equal(t, w.Body.Bytes(), cached)
+}
+
+func TestMaxRenders(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	h := New(Options{Generator: g, MaxRenders: 1})
+
+	// Occupy the only slot
+	h.renders <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/avatar/username.png", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+
+	<-h.renders
+	assert.Equal(t, http.StatusOK, get(h, http.MethodGet, "/avatar/username.png").Code)
+	assert.Len(t, h.renders, 0)
+}
+
+// failingLoader fails to load every avatar
+type failingLoader struct{}
+
+func (failingLoader) Load(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestServeRenderError(t *testing.T) {
+	h := newHandler(t, 0)
+	h.loader = failingLoader{}
+
+	w := get(h, http.MethodGet, "/avatar/username.png")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	h := newHandler(t, 0)
+	get(h, http.MethodGet, "/avatar/username.png")
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	request := spans["httpavatar.ServeAvatar"]
+	if assert.NotNil(t, request) {
+		assert.Contains(t, request.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+		for _, name := range []string{"govatar.Hash", "govatar.Compose", "govatar.Encode"} {
+			if assert.Contains(t, spans, name) {
+				assert.Equal(t, request.SpanContext().SpanID(), spans[name].Parent().SpanID(), name)
+			}
+		}
+	}
+}
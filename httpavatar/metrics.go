@@ -0,0 +1,35 @@
+package httpavatar
+
+import (
+	"net/http"
+	"time"
+)
+
+// Metrics receives events of a Handler, e.g. to export them to a monitoring
+// system. Methods are called concurrently.
+type Metrics interface {
+	// Served is called once an avatar request is answered with status
+	Served(format string, status int)
+	// Rendered is called after drawing and encoding an avatar
+	Rendered(format string, duration time.Duration, err error)
+	// CacheLookup is called when the cache is checked for an avatar
+	CacheLookup(hit bool)
+}
+
+// noMetrics discards events
+type noMetrics struct{}
+
+func (noMetrics) Served(string, int)                    {}
+func (noMetrics) Rendered(string, time.Duration, error) {}
+func (noMetrics) CacheLookup(bool)                      {}
+
+// statusWriter remembers the status code written to a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
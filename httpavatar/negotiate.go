@@ -0,0 +1,69 @@
+package httpavatar
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/recoilme/govatar"
+)
+
+// negotiated lists the formats picked by the Accept header, smallest first
+var negotiated = []string{"avif", "webp", "png", "svg"}
+
+// negotiate returns the format of avatars requested without extension or
+// format parameter: the smallest of negotiated the Accept header prefers,
+// png without header or for clients accepting none of them. Formats other
+// than png must be named, so clients accepting */* get png. svg is left out
+// unless set.
+func negotiate(accept string, svg bool) string {
+	if accept == "" {
+		return "png"
+	}
+	best, bestQ := "png", 0.0
+	for _, format := range negotiated {
+		if format == "svg" && !svg {
+			continue
+		}
+		if q := acceptQuality(accept, govatar.MIMEType(format), format == "png"); q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality the Accept header gives mimeType, the
+// most specific of exact, image/* and */* ranges counting. Wildcards count
+// only if set.
+func acceptQuality(accept, mimeType string, wildcards bool) float64 {
+	q, specificity := 0.0, -1
+	for _, r := range strings.Split(accept, ",") {
+		params := strings.Split(r, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		var s int
+		switch {
+		case mediaRange == mimeType:
+			s = 2
+		case !wildcards:
+			continue
+		case mediaRange == "image/*" && strings.HasPrefix(mimeType, "image/"):
+			s = 1
+		case mediaRange == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+		rq := 1.0
+		for _, p := range params[1:] {
+			if v := strings.TrimSpace(p); strings.HasPrefix(v, "q=") {
+				if f, err := strconv.ParseFloat(v[2:], 64); err == nil {
+					rq = f
+				}
+			}
+		}
+		q, specificity = rq, s
+	}
+	return q
+}
@@ -0,0 +1,55 @@
+package httpavatar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":    "png",
+		"*/*": "png",
+		"image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8": "avif",
+		"image/webp,*/*":                 "webp",
+		"image/avif;q=0.5, image/webp":   "webp",
+		"image/avif;q=0, image/png":      "png",
+		"image/svg+xml, image/png;q=0.9": "svg",
+		"image/*;q=0.9, image/png;q=0.1": "png",
+		"text/html":                      "png",
+		"IMAGE/WEBP":                     "webp",
+	} {
+		assert.Equal(t, want, negotiate(accept, true), accept)
+	}
+	assert.Equal(t, "png", negotiate("image/svg+xml, image/png;q=0.9", false))
+}
+
+func TestServeNegotiated(t *testing.T) {
+	h := newHandler(t, 0)
+	serve := func(target, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/avatar/username", "image/webp,*/*")
+	assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	png := serve("/avatar/username", "*/*")
+	assert.Equal(t, "image/png", png.Header().Get("Content-Type"))
+	assert.NotEqual(t, w.Header().Get("ETag"), png.Header().Get("ETag"))
+
+	// Extensions and the format parameter win
+	w = serve("/avatar/username.png", "image/webp")
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Vary"))
+	w = serve("/avatar/username?format=jpeg", "image/webp")
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Vary"))
+	w = serve("/avatar/user@site.com", "image/webp")
+	assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+}
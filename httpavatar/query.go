@@ -0,0 +1,165 @@
+package httpavatar
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/recoilme/govatar"
+)
+
+// DefaultMaxSize is the largest avatar size a Handler serves by default
+const DefaultMaxSize = 1024
+
+var (
+	errInvalidSize    = errors.New("Invalid size")
+	errInvalidFormat  = errors.New("Invalid format")
+	errInvalidGender  = errors.New("Invalid gender")
+	errInvalidStyle   = errors.New("Invalid style")
+	errInvalidKey     = errors.New("Invalid avatar key")
+	errInvalidBust    = errors.New("Invalid cache busting version")
+	errInvalidTheme   = errors.New("Invalid theme")
+	errInvalidPalette = errors.New("Invalid palette")
+)
+
+// request describes a requested avatar
+type request struct {
+	username string
+	format   string
+	gender   govatar.Gender
+	// size is zero for the size of the generator
+	size int
+	// renderer is empty for the renderer of the handler
+	renderer string
+	// theme is empty or a registered theme of govatar.WithTheme
+	theme string
+	// palette is empty or the name of a palette of the handler
+	palette string
+	// bust is the cache busting parameter v, changing the ETag and cache key
+	bust string
+}
+
+// key identifies the avatar in caches and ETags. The gender is named, as
+// numbers of registered styles differ between builds and registration orders.
+func (req request) key() string {
+	return req.gender.String() + "/" + strconv.Itoa(req.size) + "/" + req.format + "/" + req.renderer + "/" + req.theme + "/" + req.palette + "/" + req.bust + "/" + req.username
+}
+
+// parseKey returns the request identified by key
+func (h *Handler) parseKey(key string) (request, error) {
+	fields := strings.SplitN(key, "/", 8)
+	if len(fields) != 8 || fields[7] == "" {
+		return request{}, errInvalidKey
+	}
+	gender, err := govatar.ParseGender(fields[0])
+	if err != nil || gender.String() != fields[0] {
+		return request{}, errInvalidKey
+	}
+	size, err := strconv.Atoi(fields[1])
+	if err != nil || size < 0 || size > h.maxSize {
+		return request{}, errInvalidKey
+	}
+	if govatar.MIMEType(fields[2]) == "" {
+		return request{}, errInvalidKey
+	}
+	if fields[3] != "" && fields[3] != govatar.IdenticonRenderer {
+		return request{}, errInvalidKey
+	}
+	if !h.validTheme(fields[4]) || !h.validPalette(fields[5]) || !validBust(fields[6]) {
+		return request{}, errInvalidKey
+	}
+	return request{username: fields[7], format: fields[2], gender: gender, size: size, renderer: fields[3], theme: fields[4], palette: fields[5], bust: fields[6]}, nil
+}
+
+// validTheme reports whether theme is empty or registered. Hooks may pick
+// themes clients may not.
+func (h *Handler) validTheme(theme string) bool {
+	if theme == "" {
+		return true
+	}
+	_, ok := govatar.LookupTheme(theme)
+	return ok
+}
+
+// validPalette reports whether palette is empty or a palette of the handler
+func (h *Handler) validPalette(palette string) bool {
+	_, ok := h.palettes[palette]
+	return palette == "" || ok
+}
+
+// validBust reports whether v may bust caches: up to 64 letters, digits,
+// -, _ and .
+func validBust(v string) bool {
+	if len(v) > 64 {
+		return false
+	}
+	for _, r := range v {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFile splits file into username and format extension. Files without
+// extension of a known format are taken whole as png.
+func parseFile(file string) (username, format string) {
+	ext := path.Ext(file)
+	if format = strings.ToLower(strings.TrimPrefix(ext, ".")); govatar.MIMEType(format) == "" {
+		return file, "png"
+	}
+	return strings.TrimSuffix(file, ext), format
+}
+
+// parseQuery applies query parameters s, format, gender, style, the
+// allow-listed theme and palette, the cache busting version v and the
+// Gravatar default d to req
+func (h *Handler) parseQuery(req *request, query url.Values) error {
+	if s := query.Get("s"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 || size > h.maxSize {
+			return errInvalidSize
+		}
+		req.size = size
+	}
+	if format := strings.ToLower(query.Get("format")); format != "" {
+		if govatar.MIMEType(format) == "" {
+			return errInvalidFormat
+		}
+		req.format = format
+	}
+	if name := query.Get("gender"); name != "" {
+		g, err := govatar.ParseGender(name)
+		if err != nil {
+			return errInvalidGender
+		}
+		req.gender = g
+	}
+	g, err := govatar.ParseStyle(query.Get("style"), req.gender)
+	if err != nil {
+		return errInvalidStyle
+	}
+	req.gender = g
+	if theme := query.Get("theme"); theme != "" {
+		if !h.themes[theme] || !h.validTheme(theme) {
+			return errInvalidTheme
+		}
+		req.theme = theme
+	}
+	if palette := query.Get("palette"); palette != "" {
+		if !h.validPalette(palette) {
+			return errInvalidPalette
+		}
+		req.palette = palette
+	}
+	if v := query.Get("v"); v != "" {
+		if !validBust(v) {
+			return errInvalidBust
+		}
+		req.bust = v
+	}
+	parseDefault(req, gravatarDefault(query))
+	return nil
+}
@@ -0,0 +1,114 @@
+package httpavatar
+
+import (
+	"image/color"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFile(t *testing.T) {
+	for file, want := range map[string][2]string{
+		"username":          {"username", "png"},
+		"username.jpg":      {"username", "jpg"},
+		"username.WEBP":     {"username", "webp"},
+		"user@site.com":     {"user@site.com", "png"},
+		"user@site.com.svg": {"user@site.com", "svg"},
+	} {
+		username, format := parseFile(file)
+		assert.Equal(t, want, [2]string{username, format}, file)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	h := New(Options{Gender: govatar.FEMALE, MaxSize: 256})
+	for query, want := range map[string]request{
+		"":                          {gender: govatar.FEMALE, format: "png"},
+		"s=256&format=AVIF":         {gender: govatar.FEMALE, format: "avif", size: 256},
+		"gender=m":                  {gender: govatar.MALE, format: "png"},
+		"style=monster":             {gender: govatar.MONSTER, format: "png"},
+		"gender=male&style=monster": {gender: govatar.MONSTER, format: "png"},
+		"d=identicon":               {gender: govatar.FEMALE, format: "png", renderer: govatar.IdenticonRenderer},
+		"default=monsterid":         {gender: govatar.MONSTER, format: "png"},
+		"d=mp":                      {gender: govatar.FEMALE, format: "png"},
+		"d=identicon&format=svg":    {gender: govatar.FEMALE, format: "svg"},
+		"v=2024.1":                  {gender: govatar.FEMALE, format: "png", bust: "2024.1"},
+	} {
+		values, err := url.ParseQuery(query)
+		assert.NoError(t, err)
+		req := request{gender: h.gender, format: "png"}
+		assert.NoError(t, h.parseQuery(&req, values), query)
+		assert.Equal(t, want, req, query)
+	}
+
+	req := request{gender: govatar.MONSTER}
+	assert.NoError(t, h.parseQuery(&req, url.Values{"style": {"human"}}))
+	assert.Equal(t, govatar.MALE, req.gender)
+
+	assert.Equal(t, errInvalidSize, h.parseQuery(&request{}, url.Values{"s": {"257"}}))
+	assert.Equal(t, errInvalidFormat, h.parseQuery(&request{}, url.Values{"format": {"tiff"}}))
+	assert.Equal(t, errInvalidGender, h.parseQuery(&request{}, url.Values{"gender": {"x"}}))
+	assert.Equal(t, errInvalidStyle, h.parseQuery(&request{}, url.Values{"style": {"x"}}))
+	assert.Equal(t, errInvalidStyle, h.parseQuery(&request{}, url.Values{"style": {"female"}}))
+	assert.Equal(t, errInvalidBust, h.parseQuery(&request{}, url.Values{"v": {"a b"}}))
+
+	assert.NoError(t, govatar.Register("httpavatar-test", os.DirFS("../data/monster")))
+	style, _ := govatar.LookupStyle("httpavatar-test")
+	req = request{}
+	assert.NoError(t, h.parseQuery(&req, url.Values{"style": {"httpavatar-test"}}))
+	assert.Equal(t, style, req.gender)
+}
+
+func TestParseTheme(t *testing.T) {
+	h := New(Options{Themes: []string{"dark", "unknown"}, Palettes: map[string][]color.Color{"night": {color.Black}}})
+	req := request{}
+	assert.NoError(t, h.parseQuery(&req, url.Values{"theme": {"dark"}, "palette": {"night"}}))
+	assert.Equal(t, request{theme: "dark", palette: "night"}, req)
+
+	// Themes must be allow-listed and registered
+	for _, theme := range []string{"neon", "unknown"} {
+		assert.Equal(t, errInvalidTheme, h.parseQuery(&request{}, url.Values{"theme": {theme}}), theme)
+	}
+	assert.Equal(t, errInvalidPalette, h.parseQuery(&request{}, url.Values{"palette": {"day"}}))
+	assert.Equal(t, errInvalidTheme, New(Options{}).parseQuery(&request{}, url.Values{"theme": {"dark"}}))
+}
+
+func TestParseKey(t *testing.T) {
+	h := New(Options{MaxSize: 256})
+	req := request{username: "user/name", format: "webp", gender: govatar.MONSTER, size: 128}
+	parsed, err := h.parseKey(req.key())
+	assert.NoError(t, err)
+	assert.Equal(t, req, parsed)
+
+	assert.Equal(t, "monster/128/webp/////user/name", req.key())
+
+	// Registered styles are keyed by name
+	assert.NoError(t, govatar.Register("httpavatar-key", os.DirFS("../data/monster")))
+	style, _ := govatar.LookupStyle("httpavatar-key")
+	req = request{username: "username", format: "png", gender: style}
+	assert.Equal(t, "httpavatar-key/0/png/////username", req.key())
+	parsed, err = h.parseKey(req.key())
+	assert.NoError(t, err)
+	assert.Equal(t, req, parsed)
+
+	req = request{username: "username", format: "png", gender: govatar.MALE, renderer: govatar.IdenticonRenderer, bust: "2"}
+	assert.Equal(t, "male/0/png/identicon///2/username", req.key())
+	parsed, err = h.parseKey(req.key())
+	assert.NoError(t, err)
+	assert.Equal(t, req, parsed)
+
+	h = New(Options{MaxSize: 256, Palettes: map[string][]color.Color{"night": {color.Black}}})
+	req = request{username: "username", format: "png", gender: govatar.MALE, theme: "neon", palette: "night"}
+	assert.Equal(t, "male/0/png//neon/night//username", req.key())
+	parsed, err = h.parseKey(req.key())
+	assert.NoError(t, err)
+	assert.Equal(t, req, parsed)
+
+	for _, key := range []string{"", "male/0/png", "male/0/png/////", "male/0/png////a", "0/0/png/////a", "m/0/png/////a", "Male/0/png/////a", "alien/0/png/////a", "male/257/png/////a", "male/-1/png/////a", "male/0/bmp/////a", "male/0/png/alien////a", "male/0/png////v:1/a", "male/0/png//alien///a", "male/0/png///day//a"} {
+		_, err = h.parseKey(key)
+		assert.Equal(t, errInvalidKey, err, key)
+	}
+}
@@ -0,0 +1,59 @@
+package httpavatar
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sessionPrefix starts the cache keys of session tokens
+const sessionPrefix = "session/"
+
+// session is the answer to POST /session
+type session struct {
+	// Token names the avatar of the session: /session/{token}.png
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// newSession issues a token drawing a random avatar until it expires
+func (h *Handler) newSession(w http.ResponseWriter, r *http.Request) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	s := session{Token: hex.EncodeToString(b[:]), Expires: time.Now().Add(h.sessionTTL).UTC().Truncate(time.Second)}
+	var expires [8]byte
+	binary.BigEndian.PutUint64(expires[:], uint64(s.Expires.Unix()))
+	h.sessions.Set(sessionPrefix+s.Token, expires[:])
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, s)
+}
+
+// serveSession writes the avatar of the session token named by file, with
+// optional format extension, until the session expires. Tokens are random, so
+// session avatars are stable for the session but tell nothing of the user.
+func (h *Handler) serveSession(w http.ResponseWriter, r *http.Request, file string) {
+	token, _ := parseFile(file)
+	expires, ok := h.sessionExpiry(token)
+	ttl := time.Until(expires)
+	if !ok || ttl <= 0 {
+		http.NotFound(w, r)
+		return
+	}
+	h.serveAvatar(w, r, file, "private, "+strings.TrimPrefix(cacheControl(ttl), "public, "))
+}
+
+// sessionExpiry returns the expiry of token, ok is false for tokens that were
+// never issued or dropped by the cache
+func (h *Handler) sessionExpiry(token string) (expires time.Time, ok bool) {
+	value, ok := h.sessions.Get(sessionPrefix + token)
+	if !ok || len(value) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(binary.BigEndian.Uint64(value)), 0), true
+}
@@ -0,0 +1,60 @@
+package httpavatar
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSessionHandler(t *testing.T) *Handler {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	return New(Options{Generator: g, SessionTTL: time.Hour})
+}
+
+func TestSession(t *testing.T) {
+	h := newSessionHandler(t)
+	w := get(h, http.MethodPost, "/session")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var s session
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+	assert.Len(t, s.Token, 32)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), s.Expires, time.Minute)
+
+	w = get(h, http.MethodGet, "/session/"+s.Token+".png")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Regexp(t, `^private, max-age=3[56]\d\d$`, w.Header().Get("Cache-Control"))
+	// Stable for the session
+	assert.Equal(t, w.Body.Bytes(), get(h, http.MethodGet, "/session/"+s.Token+".png").Body.Bytes())
+
+	// Every session draws another avatar
+	w = get(h, http.MethodPost, "/session")
+	var other session
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &other))
+	assert.NotEqual(t, s.Token, other.Token)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, get(h, http.MethodGet, "/session").Code)
+	assert.Equal(t, http.StatusNotFound, get(h, http.MethodGet, "/session/unknown.png").Code)
+
+	// Expired sessions are gone
+	var expires [8]byte
+	binary.BigEndian.PutUint64(expires[:], uint64(time.Now().Add(-time.Second).Unix()))
+	h.sessions.Set(sessionPrefix+s.Token, expires[:])
+	assert.Equal(t, http.StatusNotFound, get(h, http.MethodGet, "/session/"+s.Token+".png").Code)
+}
+
+func TestSessionsDisabled(t *testing.T) {
+	h := newHandler(t, 0)
+	assert.Equal(t, http.StatusMethodNotAllowed, get(h, http.MethodPost, "/session").Code)
+	assert.Equal(t, http.StatusNotFound, get(h, http.MethodGet, "/session/token.png").Code)
+}
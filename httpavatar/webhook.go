@@ -0,0 +1,110 @@
+package httpavatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/recoilme/govatar"
+)
+
+// webhookTimeout is how long a webhook delivery may take
+const webhookTimeout = 10 * time.Second
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of webhook payloads
+// keyed by Webhook.Secret, prefixed by sha256=
+const SignatureHeader = "X-Govatar-Signature"
+
+// Webhook is notified of every avatar the handler draws, e.g. so downstream
+// systems index or mirror them. Avatars served from caches are not drawn
+// again, so an avatar is notified once until its cache entry is dropped.
+type Webhook struct {
+	// URL payloads are posted to
+	URL string
+	// Secret signs payloads, receivers check SignatureHeader with it
+	Secret []byte
+	// Client posts payloads, nil uses http.DefaultClient
+	Client *http.Client
+}
+
+// WebhookPayload is the JSON body posted to a Webhook
+type WebhookPayload struct {
+	Username string `json:"username"`
+	Gender   string `json:"gender"`
+	// Spec is the avatar spec as formatted by govatar.FormatSpec, empty for
+	// renderers other than the default
+	Spec string `json:"spec,omitempty"`
+	// URL is the path and query of the avatar below the handler
+	URL string `json:"url"`
+	// Hash is the hex encoded SHA-256 of the encoded avatar
+	Hash string `json:"hash"`
+}
+
+// notify posts the payload describing the avatar of req encoded as body to
+// the webhook in the background. Failed deliveries are dropped.
+func (h *Handler) notify(req request, body []byte) {
+	payload := WebhookPayload{Username: req.username, Gender: req.gender.String(), URL: avatarURL(req)}
+	sum := sha256.Sum256(body)
+	payload.Hash = hex.EncodeToString(sum[:])
+	if req.renderer == "" && h.renderer == "" {
+		if spec, err := h.gen.SpecFromUsername(req.gender, req.username); err == nil {
+			payload.Spec = govatar.FormatSpec(spec)
+		}
+	}
+	go h.webhook.post(payload)
+}
+
+// post delivers payload signed with the secret of wh
+func (wh *Webhook) post(payload WebhookPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	mac := hmac.New(sha256.New, wh.Secret)
+	mac.Write(data)
+	r.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	client := wh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// avatarURL returns the path and query requesting the avatar of req
+func avatarURL(req request) string {
+	query := url.Values{"gender": {req.gender.String()}}
+	if req.size > 0 {
+		query.Set("s", strconv.Itoa(req.size))
+	}
+	if req.theme != "" {
+		query.Set("theme", req.theme)
+	}
+	if req.palette != "" {
+		query.Set("palette", req.palette)
+	}
+	if req.bust != "" {
+		query.Set("v", req.bust)
+	}
+	if req.renderer == govatar.IdenticonRenderer {
+		query.Set("d", "identicon")
+	}
+	return "/avatar/" + url.PathEscape(req.username) + "." + req.format + "?" + query.Encode()
+}
@@ -0,0 +1,74 @@
+package httpavatar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	type delivery struct {
+		payload   WebhookPayload
+		signature string
+		body      []byte
+	}
+	deliveries := make(chan delivery, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var d delivery
+		d.body = body
+		d.signature = r.Header.Get(SignatureHeader)
+		json.Unmarshal(body, &d.payload)
+		deliveries <- d
+	}))
+	defer srv.Close()
+
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+	secret := []byte("secret")
+	h := New(Options{Generator: g, Gender: govatar.FEMALE, Webhook: &Webhook{URL: srv.URL, Secret: secret}})
+
+	w := get(h, http.MethodGet, "/avatar/user@site.com.png?s=32")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var d delivery
+	select {
+	case d = <-deliveries:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "webhook not called")
+	}
+	spec, err := g.SpecFromUsername(govatar.FEMALE, "user@site.com")
+	assert.NoError(t, err)
+	sum := sha256.Sum256(w.Body.Bytes())
+	assert.Equal(t, WebhookPayload{
+		Username: "user@site.com",
+		Gender:   "female",
+		Spec:     govatar.FormatSpec(spec),
+		URL:      "/avatar/user@site.com.png?gender=female&s=32",
+		Hash:     hex.EncodeToString(sum[:]),
+	}, d.payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(d.body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), d.signature)
+
+	// The payload URL requests the same avatar
+	assert.Equal(t, w.Body.Bytes(), get(h, http.MethodGet, d.payload.URL).Body.Bytes())
+
+	// Cached avatars are not drawn again
+	select {
+	case d = <-deliveries:
+		assert.Fail(t, "webhook called for cached avatar", d.payload.URL)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,293 @@
+package govatar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"sort"
+)
+
+var errUnsupportedProfile = errors.New("unsupported color profile")
+
+// iccProfile converts colors of an ICC matrix/TRC profile to sRGB
+type iccProfile struct {
+	// trc are the tone curves of red, green and blue, or of gray alone
+	trc []toneCurve
+	// toXYZ maps linear red, green and blue to PCS XYZ (D50)
+	toXYZ [3][3]float64
+}
+
+// toneCurve maps an encoded channel value from 0 to 1 to linear light
+type toneCurve func(float64) float64
+
+// xyzD50ToSRGB maps PCS XYZ to linear sRGB, Bradford adapted to D65
+var xyzD50ToSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// embeddedProfile returns the ICC profile embedded in png or jpeg data,
+// nil if there is none
+func embeddedProfile(data []byte, format string) ([]byte, error) {
+	switch format {
+	case "png":
+		return pngProfile(data)
+	case "jpeg":
+		return jpegProfile(data), nil
+	}
+	return nil, nil
+}
+
+// pngProfile returns the profile of the iCCP chunk of png data
+func pngProfile(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, nil
+	}
+	for p := len(signature); p+8 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[p:]))
+		typ := string(data[p+4 : p+8])
+		if n < 0 || p+12+n > len(data) || typ == "IDAT" {
+			return nil, nil
+		}
+		if typ == "iCCP" {
+			chunk := data[p+8 : p+8+n]
+			// Profile name, null separator and compression method
+			i := bytes.IndexByte(chunk, 0)
+			if i < 0 || i+2 > len(chunk) {
+				return nil, errUnsupportedProfile
+			}
+			r, err := zlib.NewReader(bytes.NewReader(chunk[i+2:]))
+			if err != nil {
+				return nil, errUnsupportedProfile
+			}
+			defer r.Close()
+			return ioutil.ReadAll(r)
+		}
+		p += 12 + n
+	}
+	return nil, nil
+}
+
+// jpegProfile returns the profile split over the APP2 segments of jpeg data
+func jpegProfile(data []byte) []byte {
+	const marker = "ICC_PROFILE\x00"
+	chunks := map[byte][]byte{}
+	for p := 2; p+4 <= len(data) && data[p] == 0xff; {
+		kind := data[p+1]
+		if kind == 0xda || kind == 0xd9 {
+			break
+		}
+		n := int(binary.BigEndian.Uint16(data[p+2:]))
+		if p+2+n > len(data) {
+			break
+		}
+		segment := data[p+4 : p+2+n]
+		if kind == 0xe2 && len(segment) > len(marker)+2 && string(segment[:len(marker)]) == marker {
+			chunks[segment[len(marker)]] = segment[len(marker)+2:]
+		}
+		p += 2 + n
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	seqs := make([]int, 0, len(chunks))
+	for seq := range chunks {
+		seqs = append(seqs, int(seq))
+	}
+	sort.Ints(seqs)
+	var profile []byte
+	for _, seq := range seqs {
+		profile = append(profile, chunks[byte(seq)]...)
+	}
+	return profile
+}
+
+// parseICC reads an RGB or gray matrix/TRC profile. sRGB profiles of other
+// kinds are returned as nil, as their colors need no conversion.
+func parseICC(data []byte) (*iccProfile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, errUnsupportedProfile
+	}
+	tags := map[string][]byte{}
+	count := int(binary.BigEndian.Uint32(data[128:]))
+	for i := 0; i < count && 132+12*i+12 <= len(data); i++ {
+		entry := data[132+12*i:]
+		offset, size := int(binary.BigEndian.Uint32(entry[4:])), int(binary.BigEndian.Uint32(entry[8:]))
+		if offset < 0 || size < 0 || offset+size > len(data) {
+			return nil, errUnsupportedProfile
+		}
+		tags[string(entry[:4])] = data[offset : offset+size]
+	}
+	p := &iccProfile{}
+	switch string(data[16:20]) {
+	case "RGB ":
+		for i, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+			trc, err := parseCurve(tags[sig])
+			if err != nil {
+				return srgbByName(tags["desc"])
+			}
+			p.trc = append(p.trc, trc)
+			xyz, err := parseXYZ(tags[[]string{"rXYZ", "gXYZ", "bXYZ"}[i]])
+			if err != nil {
+				return srgbByName(tags["desc"])
+			}
+			for j := range xyz {
+				p.toXYZ[j][i] = xyz[j]
+			}
+		}
+	case "GRAY":
+		trc, err := parseCurve(tags["kTRC"])
+		if err != nil {
+			return nil, err
+		}
+		p.trc = []toneCurve{trc}
+	default:
+		return nil, errUnsupportedProfile
+	}
+	return p, nil
+}
+
+// srgbByName returns no profile if desc names sRGB, which LUT based sRGB
+// profiles do, and an error otherwise
+func srgbByName(desc []byte) (*iccProfile, error) {
+	// Descriptions of version 4 profiles are UTF-16
+	if bytes.Contains(bytes.ReplaceAll(desc, []byte{0}, nil), []byte("sRGB")) {
+		return nil, nil
+	}
+	return nil, errUnsupportedProfile
+}
+
+// parseXYZ reads an XYZType tag
+func parseXYZ(tag []byte) ([3]float64, error) {
+	if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+		return [3]float64{}, errUnsupportedProfile
+	}
+	return [3]float64{s15Fixed16(tag[8:]), s15Fixed16(tag[12:]), s15Fixed16(tag[16:])}, nil
+}
+
+// parseCurve reads a curveType or parametricCurveType tag
+func parseCurve(tag []byte) (toneCurve, error) {
+	if len(tag) < 12 {
+		return nil, errUnsupportedProfile
+	}
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+2*n {
+			return nil, errUnsupportedProfile
+		}
+		switch n {
+		case 0:
+			return func(v float64) float64 { return v }, nil
+		case 1:
+			gamma := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(v float64) float64 { return math.Pow(v, gamma) }, nil
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 0xffff
+		}
+		return func(v float64) float64 {
+			x := v * float64(n-1)
+			i := int(x)
+			if i >= n-1 {
+				return table[n-1]
+			}
+			return table[i] + (table[i+1]-table[i])*(x-float64(i))
+		}, nil
+	case "para":
+		kind := binary.BigEndian.Uint16(tag[8:])
+		counts := []int{1, 3, 4, 5, 7}
+		if int(kind) >= len(counts) || len(tag) < 12+4*counts[kind] {
+			return nil, errUnsupportedProfile
+		}
+		// g, a, b, c, d, e, f as in ICC.1 parametricCurveType
+		var k [7]float64
+		k[1] = 1
+		for i := 0; i < counts[kind]; i++ {
+			k[i] = s15Fixed16(tag[12+4*i:])
+		}
+		g, a, b, c, d, e, f := k[0], k[1], k[2], k[3], k[4], k[5], k[6]
+		pow := func(v float64) float64 { return math.Pow(math.Max(0, a*v+b), g) }
+		return func(v float64) float64 {
+			switch kind {
+			case 0:
+				return math.Pow(v, g)
+			case 1:
+				if v < -b/a {
+					return 0
+				}
+				return pow(v)
+			case 2:
+				if v < -b/a {
+					return c
+				}
+				return pow(v) + c
+			case 3:
+				if v < d {
+					return c * v
+				}
+				return pow(v)
+			}
+			if v < d {
+				return c*v + f
+			}
+			return pow(v) + e
+		}, nil
+	}
+	return nil, errUnsupportedProfile
+}
+
+// s15Fixed16 reads a signed 15.16 fixed point number
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+// convert returns img with colors converted from p to sRGB
+func (p *iccProfile) convert(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewNRGBA64(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			in := [3]float64{float64(c.R) / 0xffff, float64(c.G) / 0xffff, float64(c.B) / 0xffff}
+			var out [3]float64
+			if len(p.trc) == 1 {
+				// Gray is linear luminance, shared by all sRGB channels
+				l := p.trc[0](in[0])
+				out = [3]float64{l, l, l}
+			} else {
+				var lin, xyz [3]float64
+				for i := range lin {
+					lin[i] = p.trc[i](in[i])
+				}
+				for i := range xyz {
+					xyz[i] = p.toXYZ[i][0]*lin[0] + p.toXYZ[i][1]*lin[1] + p.toXYZ[i][2]*lin[2]
+				}
+				for i := range out {
+					out[i] = xyzD50ToSRGB[i][0]*xyz[0] + xyzD50ToSRGB[i][1]*xyz[1] + xyzD50ToSRGB[i][2]*xyz[2]
+				}
+			}
+			dst.SetNRGBA64(x, y, color.NRGBA64{srgbEncode(out[0]), srgbEncode(out[1]), srgbEncode(out[2]), c.A})
+		}
+	}
+	return dst
+}
+
+// srgbEncode applies the sRGB transfer function to linear light v
+func srgbEncode(v float64) uint16 {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint16(v*0xffff + 0.5)
+}
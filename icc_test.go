@@ -0,0 +1,128 @@
+package govatar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertProfile(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+
+	// Gray of half linear light is 188 in sRGB
+	img := image.NewNRGBA(image.Rect(0, 0, assetSize, assetSize))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	path := filepath.Join(dir, "male", "face", "face1.png")
+	assert.NoError(t, ioutil.WriteFile(path, withPNGProfile(t, buf.Bytes(), linearProfile()), 0644))
+	converted, err := decodeAsset(path)
+	assert.NoError(t, err)
+	c := color.NRGBAModel.Convert(converted.At(10, 10)).(color.NRGBA)
+	assert.InDelta(t, 188, c.R, 1)
+	assert.InDelta(t, 188, c.G, 1)
+	assert.InDelta(t, 188, c.B, 1)
+	assert.Equal(t, uint8(128), c.A)
+
+	// JPEG has no alpha
+	gray := image.NewGray(img.Bounds())
+	for i := range gray.Pix {
+		gray.Pix[i] = 128
+	}
+	buf.Reset()
+	assert.NoError(t, jpeg.Encode(&buf, gray, &jpeg.Options{Quality: 100}))
+	path = filepath.Join(dir, "male", "face", "face2.jpg")
+	assert.NoError(t, ioutil.WriteFile(path, withJPEGProfile(buf.Bytes(), linearProfile()), 0644))
+	converted, err = decodeAsset(path)
+	assert.NoError(t, err)
+	c = color.NRGBAModel.Convert(converted.At(10, 10)).(color.NRGBA)
+	assert.InDelta(t, 188, c.R, 2)
+	assert.NoError(t, os.Remove(path))
+
+	// Profiles that can't be converted are problems
+	buf.Reset()
+	assert.NoError(t, png.Encode(&buf, img))
+	path = filepath.Join(dir, "male", "face", "face3.png")
+	assert.NoError(t, ioutil.WriteFile(path, withPNGProfile(t, buf.Bytes(), []byte("not a profile")), 0644))
+	_, err = BuildPack(dir)
+	assert.Contains(t, err.Error(), "face3.png: unsupported color profile")
+}
+
+// linearProfile returns an ICC profile of sRGB primaries and linear curves
+func linearProfile() []byte {
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	xyz := func(x, y, z float64) []byte {
+		b := append([]byte("XYZ "), 0, 0, 0, 0)
+		for _, v := range []float64{x, y, z} {
+			b = binary.BigEndian.AppendUint32(b, uint32(int32(v*65536)))
+		}
+		return b
+	}
+	linear := append([]byte("curv"), 0, 0, 0, 0, 0, 0, 0, 0)
+	tags := []tag{
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", linear}, {"gTRC", linear}, {"bTRC", linear},
+	}
+	header := make([]byte, 128)
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	offset := 128 + 4 + 12*len(tags)
+	for _, t := range tags {
+		table = append(table, t.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(offset+len(data)))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(t.data)))
+		data = append(data, t.data...)
+	}
+	profile := append(append(header, table...), data...)
+	binary.BigEndian.PutUint32(profile, uint32(len(profile)))
+	return profile
+}
+
+// withPNGProfile returns png data with an iCCP chunk of profile after IHDR
+func withPNGProfile(t *testing.T, data, profile []byte) []byte {
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	_, err := w.Write(profile)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	chunk := append([]byte("iCCP"), "test\x00\x00"...)
+	chunk = append(chunk, z.Bytes()...)
+	var iccp []byte
+	iccp = binary.BigEndian.AppendUint32(iccp, uint32(len(chunk)-4))
+	iccp = append(iccp, chunk...)
+	iccp = binary.BigEndian.AppendUint32(iccp, crc32.ChecksumIEEE(chunk))
+	// Signature and IHDR of 13 bytes
+	end := 8 + 12 + 13
+	return append(append(append([]byte(nil), data[:end]...), iccp...), data[end:]...)
+}
+
+// withJPEGProfile returns jpeg data with an APP2 segment of profile
+func withJPEGProfile(data, profile []byte) []byte {
+	segment := append([]byte("ICC_PROFILE\x00"), 1, 1)
+	segment = append(segment, profile...)
+	app2 := append([]byte{0xff, 0xe2}, byte((len(segment)+2)>>8), byte(len(segment)+2))
+	app2 = append(app2, segment...)
+	return append(append(append([]byte(nil), data[:2]...), app2...), data[2:]...)
+}
@@ -0,0 +1,126 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// faviconSizes are the sizes packed into favicon.ico
+var faviconSizes = []int{16, 32, 48}
+
+// maskableSafeZone is the part of a maskable icon guaranteed to stay visible
+const maskableSafeZone = 0.8
+
+// IconSet maps file names of a favicon and app icon bundle to their content:
+// favicon.ico, apple-touch-icon.png (180x180), icon-192.png and icon-512.png
+// (maskable) and manifest.json, a web app manifest snippet listing the icons.
+type IconSet map[string][]byte
+
+type manifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// NewIconSet generates the favicon and app icon bundle from one avatar.
+// Maskable icons shrink the avatar into the safe zone and fill the rest with
+// the color of its top left corner.
+func NewIconSet(img image.Image) (IconSet, error) {
+	set := make(IconSet)
+
+	var favicons []image.Image
+	for _, size := range faviconSizes {
+		favicons = append(favicons, resize(img, size))
+	}
+	ico, err := encodeICO(favicons)
+	if err != nil {
+		return nil, err
+	}
+	set["favicon.ico"] = ico
+
+	if set["apple-touch-icon.png"], err = encodePNG(resize(img, 180)); err != nil {
+		return nil, err
+	}
+
+	var icons []manifestIcon
+	for _, size := range []int{192, 512} {
+		name := "icon-" + strconv.Itoa(size) + ".png"
+		if set[name], err = encodePNG(maskable(img, size)); err != nil {
+			return nil, err
+		}
+		icons = append(icons, manifestIcon{Src: name, Sizes: strconv.Itoa(size) + "x" + strconv.Itoa(size), Type: "image/png", Purpose: "maskable"})
+	}
+	if set["manifest.json"], err = json.MarshalIndent(map[string][]manifestIcon{"icons": icons}, "", "  "); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// WriteDir writes all files of the set to dir
+func (s IconSet) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, data := range s {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resize scales img to a size by size image
+func resize(img image.Image, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// maskable returns size by size icon with img inside the maskable safe zone
+func maskable(img image.Image, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	bg := img.At(img.Bounds().Min.X, img.Bounds().Min.Y)
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	inset := int(float64(size) * (1 - maskableSafeZone) / 2)
+	xdraw.CatmullRom.Scale(dst, dst.Bounds().Inset(inset), img, img.Bounds(), draw.Over, nil)
+	return dst
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// encodeICO packs images (at most 256x256) into an ICO file of png entries
+func encodeICO(images []image.Image) ([]byte, error) {
+	var header, body bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+	offset := 6 + 16*len(images)
+	for _, img := range images {
+		data, err := encodePNG(img)
+		if err != nil {
+			return nil, err
+		}
+		// 0 stands for 256 in the one byte width and height
+		w, h := uint8(img.Bounds().Dx()), uint8(img.Bounds().Dy())
+		binary.Write(&header, binary.LittleEndian, struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size, Offset                    uint32
+		}{w, h, 0, 0, 1, 32, uint32(len(data)), uint32(offset + body.Len())})
+		body.Write(data)
+	}
+	return append(header.Bytes(), body.Bytes()...), nil
+}
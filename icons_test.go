@@ -0,0 +1,56 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIconSet(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+
+	set, err := NewIconSet(avatar)
+	assert.NoError(t, err)
+	assert.Len(t, set, 5)
+
+	for name, size := range map[string]int{"apple-touch-icon.png": 180, "icon-192.png": 192, "icon-512.png": 512} {
+		img, err := png.Decode(bytes.NewReader(set[name]))
+		assert.NoError(t, err, name)
+		assert.Equal(t, size, img.Bounds().Dx(), name)
+	}
+
+	// ICO header and directory entries
+	ico := set["favicon.ico"]
+	assert.Equal(t, []byte{0, 0, 1, 0, 3, 0}, ico[:6])
+	for i, size := range faviconSizes {
+		entry := ico[6+16*i:]
+		assert.Equal(t, uint8(size), entry[0])
+		length := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		img, err := png.Decode(bytes.NewReader(ico[offset : offset+length]))
+		assert.NoError(t, err)
+		assert.Equal(t, size, img.Bounds().Dx())
+	}
+
+	var manifest struct {
+		Icons []manifestIcon `json:"icons"`
+	}
+	assert.NoError(t, json.Unmarshal(set["manifest.json"], &manifest))
+	assert.Equal(t, manifestIcon{"icon-512.png", "512x512", "image/png", "maskable"}, manifest.Icons[1])
+
+	dir, err := ioutil.TempDir("", "govatar-icons")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, set.WriteDir(dir))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "favicon.ico"))
+	assert.NoError(t, err)
+	assert.Equal(t, ico, data)
+}
@@ -0,0 +1,121 @@
+package govatar
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// IdenticonRenderer is the name of the renderer drawing identicons
+const IdenticonRenderer = "identicon"
+
+// identiconGrid is the number of cells across an identicon
+const identiconGrid = 5
+
+// identiconBackground is the light gray behind identicon blocks
+var identiconBackground = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+// identicon is the procedural layout shared by raster and SVG output
+type identicon struct {
+	// cells are set for blocks in row major order, mirrored left to right
+	cells [identiconGrid * identiconGrid]bool
+	color color.RGBA
+}
+
+func init() {
+	RegisterRenderer(IdenticonRenderer, identiconRenderer{})
+}
+
+// identiconFromSeed derives the left three columns of blocks and the hue of
+// an identicon from seed and mirrors them to the right
+func identiconFromSeed(seed int64) identicon {
+	rnd := rand.New(rand.NewSource(seed))
+	bits := rnd.Intn(1 << 15)
+	return identiconFromBits(bits, rnd.Float64()*360, 0.45+rnd.Float64()*0.2, 0.45+rnd.Float64()*0.15)
+}
+
+func identiconFromBits(bits int, hue, saturation, lightness float64) identicon {
+	// An empty identicon says nothing, fill the middle column
+	if bits == 0 {
+		bits = 0x1f << 10
+	}
+	var id identicon
+	for i := 0; i < 15; i++ {
+		if bits&(1<<uint(i)) == 0 {
+			continue
+		}
+		col, row := i/identiconGrid, i%identiconGrid
+		id.cells[row*identiconGrid+col] = true
+		id.cells[row*identiconGrid+identiconGrid-1-col] = true
+	}
+	id.color = hslToRGB(hue, saturation, lightness)
+	return id
+}
+
+// rect returns the pixel rectangle of cell at col, row in an identicon of
+// size with a margin of half a cell
+func (identicon) rect(col, row, size int) image.Rectangle {
+	// 6 half cells of margin and 5 cells make 12 units across
+	cell := func(i int) int { return (1 + 2*i) * size / (2*identiconGrid + 2) }
+	return image.Rect(cell(col), cell(row), cell(col+1), cell(row+1))
+}
+
+func (id identicon) draw(size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(identiconBackground), image.Point{}, draw.Src)
+	fg := image.NewUniform(id.color)
+	for i, on := range id.cells {
+		if on {
+			draw.Draw(dst, id.rect(i%identiconGrid, i/identiconGrid, size), fg, image.Point{}, draw.Src)
+		}
+	}
+	return dst
+}
+
+// Identicon renders a GitHub style identicon of symmetric blocks colored
+// from the username hash. It needs no assets, so it works as a fallback
+// when no asset pack is loaded and as a distinct look for machine accounts.
+func Identicon(username string, size int) image.Image {
+	return identiconFromSeed(usernameSeed(username)).draw(size)
+}
+
+// IdenticonSVG returns the identicon drawn by Identicon as SVG document
+func IdenticonSVG(username string, size int) string {
+	id := identiconFromSeed(usernameSeed(username))
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, size, size, hexColor(identiconBackground))
+	for i, on := range id.cells {
+		if !on {
+			continue
+		}
+		r := id.rect(i%identiconGrid, i/identiconGrid, size)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, r.Min.X, r.Min.Y, r.Dx(), r.Dy(), hexColor(id.color))
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// identiconRenderer makes identicons available to Render. Spec.Face holds
+// the block bits and Spec.Hair the hue, other parts and gender are ignored.
+type identiconRenderer struct{}
+
+func (identiconRenderer) SelectParts(gender Gender, seed int64) (Spec, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	return Spec{Gender: gender, Face: rnd.Intn(1 << 15), Hair: int(rnd.Float64() * 360)}, nil
+}
+
+func (identiconRenderer) Compose(spec Spec) (image.Image, error) {
+	if spec.Face < 0 || spec.Face >= 1<<15 {
+		return nil, errInvalidSpec
+	}
+	return identiconFromBits(spec.Face, float64(spec.Hair), 0.55, 0.52).draw(assetSize), nil
+}
+
+func (identiconRenderer) Encode(w io.Writer, img image.Image, format string) error {
+	return encode(w, img, format, DefaultConfig().JPEGQuality, false)
+}
@@ -0,0 +1,68 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdenticon(t *testing.T) {
+	img := Identicon("username@site.com", 120)
+	assert.Equal(t, image.Rect(0, 0, 120, 120), img.Bounds())
+	assert.True(t, areImagesEquals(img, Identicon("username@site.com", 120)))
+	assert.False(t, areImagesEquals(img, Identicon("username2@site.com", 120)))
+
+	// Blocks are mirrored and drawn in two colors
+	colors := map[color.Color]bool{}
+	for y := 0; y < 120; y++ {
+		for x := 0; x < 120; x++ {
+			assert.Equal(t, img.At(x, y), img.At(119-x, y))
+			colors[img.At(x, y)] = true
+		}
+	}
+	assert.Len(t, colors, 2)
+	// Margin of half a cell
+	assert.Equal(t, color.Color(identiconBackground), img.At(4, 60))
+
+	id := identiconFromBits(0, 0, 0.5, 0.5)
+	for row := 0; row < identiconGrid; row++ {
+		assert.True(t, id.cells[row*identiconGrid+2])
+	}
+}
+
+func TestIdenticonSVG(t *testing.T) {
+	svg := IdenticonSVG("username@site.com", 120)
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	id := identiconFromSeed(usernameSeed("username@site.com"))
+	blocks := 0
+	for _, on := range id.cells {
+		if on {
+			blocks++
+		}
+	}
+	assert.Equal(t, blocks+1, strings.Count(svg, "<rect"))
+	assert.Contains(t, svg, hexColor(id.color))
+	assert.NoError(t, xml.Unmarshal([]byte(svg), new(interface{})))
+}
+
+func TestIdenticonRenderer(t *testing.T) {
+	_, ok := LookupRenderer(IdenticonRenderer)
+	assert.True(t, ok)
+	var buf bytes.Buffer
+	assert.NoError(t, Render(&buf, IdenticonRenderer, MALE, "deploy-bot", "png"))
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, assetSize, img.Bounds().Dx())
+	var again bytes.Buffer
+	assert.NoError(t, Render(&again, IdenticonRenderer, FEMALE, "deploy-bot", "png"))
+	assert.Equal(t, buf.Bytes(), again.Bytes())
+
+	_, err = identiconRenderer{}.Compose(Spec{Face: 1 << 15})
+	assert.Equal(t, errInvalidSpec, err)
+}
@@ -0,0 +1,109 @@
+// Package imgdiff compares images pixel by pixel with a color tolerance.
+// The tolerance is the largest difference allowed in any RGBA channel, not
+// a perceptual metric, so it suits checking that images stayed the same
+// rather than judging how different they look.
+//
+// It backs the golden tests of govatar and lets users check that an upgrade
+// did not change the avatars of their users.
+package imgdiff
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+var errSizeMismatch = errors.New("Images differ in size")
+
+// Result describes how two images differ
+type Result struct {
+	// Changed is the number of pixels differing by more than the tolerance
+	Changed int
+	// Total is the number of compared pixels
+	Total int
+	// MaxDelta is the largest channel difference found
+	MaxDelta uint8
+}
+
+// Ratio returns the share of changed pixels
+func (r Result) Ratio() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Changed) / float64(r.Total)
+}
+
+// Equal reports whether no pixel changed
+func (r Result) Equal() bool {
+	return r.Changed == 0
+}
+
+// Compare counts pixels of a and b whose colors differ by more than delta
+// in any channel. Colors are compared premultiplied, so fully transparent
+// pixels are equal whatever their color.
+func Compare(a, b image.Image, delta uint8) (Result, error) {
+	var r Result
+	err := walk(a, b, func(x, y int, d uint8) {
+		r.Total++
+		if d > r.MaxDelta {
+			r.MaxDelta = d
+		}
+		if d > delta {
+			r.Changed++
+		}
+	})
+	return r, err
+}
+
+// Visualize returns an image of a faded to gray with pixels differing from b
+// by more than delta marked red.
+func Visualize(a, b image.Image, delta uint8) (*image.NRGBA, error) {
+	out := image.NewNRGBA(image.Rect(0, 0, a.Bounds().Dx(), a.Bounds().Dy()))
+	ab := a.Bounds()
+	err := walk(a, b, func(x, y int, d uint8) {
+		if d > delta {
+			out.SetNRGBA(x, y, color.NRGBA{0xff, 0, 0, 0xff})
+			return
+		}
+		g := color.GrayModel.Convert(a.At(ab.Min.X+x, ab.Min.Y+y)).(color.Gray)
+		// Fade towards white so marks stand out
+		out.SetNRGBA(x, y, color.NRGBA{0xc0 + g.Y/4, 0xc0 + g.Y/4, 0xc0 + g.Y/4, 0xff})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// walk calls fn with the largest channel difference of every pixel pair
+func walk(a, b image.Image, fn func(x, y int, d uint8)) error {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Size() != bb.Size() {
+		return errSizeMismatch
+	}
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			ca := color.RGBAModel.Convert(a.At(ab.Min.X+x, ab.Min.Y+y)).(color.RGBA)
+			cb := color.RGBAModel.Convert(b.At(bb.Min.X+x, bb.Min.Y+y)).(color.RGBA)
+			fn(x, y, maxDiff(diff(ca.R, cb.R), diff(ca.G, cb.G), diff(ca.B, cb.B), diff(ca.A, cb.A)))
+		}
+	}
+	return nil
+}
+
+func maxDiff(d ...uint8) uint8 {
+	var m uint8
+	for _, v := range d {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func diff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,60 @@
+package imgdiff
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func square(c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	a := square(color.White)
+	b := square(color.NRGBA{250, 255, 255, 255})
+	b.Set(3, 4, color.Black)
+
+	r, err := Compare(a, b, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Changed: 1, Total: 100, MaxDelta: 255}, r)
+	assert.Equal(t, 0.01, r.Ratio())
+	assert.False(t, r.Equal())
+
+	r, err = Compare(a, a, 0)
+	assert.NoError(t, err)
+	assert.True(t, r.Equal())
+
+	// Transparent pixels are equal whatever their color
+	r, err = Compare(square(color.NRGBA{255, 0, 0, 0}), square(color.NRGBA{0, 0, 255, 0}), 0)
+	assert.NoError(t, err)
+	assert.True(t, r.Equal())
+
+	// Bounds offset does not matter, only size
+	big := image.NewNRGBA(image.Rect(0, 0, 12, 12))
+	draw.Draw(big, big.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	r, err = Compare(a, big.SubImage(image.Rect(2, 2, 12, 12)), 0)
+	assert.NoError(t, err)
+	assert.True(t, r.Equal())
+	_, err = Compare(a, image.NewNRGBA(image.Rect(0, 0, 5, 5)), 0)
+	assert.Equal(t, errSizeMismatch, err)
+}
+
+func TestVisualize(t *testing.T) {
+	a := square(color.White)
+	b := square(color.White)
+	b.Set(3, 4, color.Black)
+
+	img, err := Visualize(a, b, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{0xff, 0, 0, 0xff}, img.NRGBAAt(3, 4))
+	assert.NotEqual(t, color.NRGBA{0xff, 0, 0, 0xff}, img.NRGBAAt(0, 0))
+
+	_, err = Visualize(a, image.NewNRGBA(image.Rect(0, 0, 5, 5)), 8)
+	assert.Equal(t, errSizeMismatch, err)
+}
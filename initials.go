@@ -0,0 +1,121 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+var (
+	errNoInitials  = errors.New("Name has no letters")
+	errInvalidFont = errors.New("Invalid font")
+)
+
+// WithFont draws text avatars in the TrueType or OpenType font ttf instead
+// of the bundled Go Bold, scaled to the avatar size
+func WithFont(ttf []byte) Option {
+	return func(o *options) {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			o.err = errInvalidFont
+			return
+		}
+		o.font, o.fontFace = f, nil
+	}
+}
+
+// WithFontFace draws text avatars with face as it is. The caller picks its
+// size and keeps ownership: face is not closed.
+func WithFontFace(face font.Face) Option {
+	return func(o *options) {
+		o.font, o.fontFace = nil, face
+		if face == nil {
+			o.err = errInvalidFont
+		}
+	}
+}
+
+// face returns the face text avatars are drawn with and closes it when done
+// if it was made for this call
+func (o options) face(size float64) (face font.Face, done func()) {
+	if o.fontFace != nil {
+		return o.fontFace, func() {}
+	}
+	f := o.font
+	if f == nil {
+		f = boldFont
+	}
+	face = fontFace(f, size)
+	return face, func() { face.Close() }
+}
+
+// GenerateInitials draws the initials of name on a background colored from
+// its hash, like the default avatars of mail and chat apps. Names with more
+// than one word get the first letters of the first and the last word, e-mail
+// addresses are split at dots, dashes and underscores before the @.
+func GenerateInitials(name string, opts ...Option) (image.Image, error) {
+	return std().GenerateInitials(name, opts...)
+}
+
+// GenerateInitials draws the initials of name on a background colored from
+// its hash. It needs no assets.
+func (g *Generator) GenerateInitials(name string, opts ...Option) (image.Image, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	text := initials(name)
+	if text == "" {
+		return nil, errNoInitials
+	}
+	bg := color.Color(NewScheme(usernameSeed(name)).Primary)
+	ink := textColor(bg)
+	switch {
+	case o.transparent:
+		ink, bg = bg, color.Transparent
+	case !o.background:
+		bg = color.White
+		ink = textColor(bg)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, o.size, o.size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	face, done := o.face(float64(o.size) * 0.42)
+	defer done()
+	drawCentered(img, face, ink, text)
+	return o.finish(o.pixelate(img)), nil
+}
+
+// drawCentered draws text with the bounds of its glyphs centered in dst
+func drawCentered(dst draw.Image, face font.Face, c color.Color, text string) {
+	b, _ := font.BoundString(face, text)
+	size := dst.Bounds().Size()
+	x := (size.X - (b.Max.X - b.Min.X).Round()) / 2
+	y := (size.Y - (b.Max.Y - b.Min.Y).Round()) / 2
+	drawText(dst, face, c, dst.Bounds().Min.X+x-b.Min.X.Round(), dst.Bounds().Min.Y+y-b.Min.Y.Round(), text)
+}
+
+// initials returns the upper case first letters of the first and the last
+// word of name
+func initials(name string) string {
+	if at := strings.LastIndex(name, "@"); at > 0 {
+		name = name[:at]
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	first := []rune(words[0])[0]
+	if len(words) == 1 {
+		return string(unicode.ToUpper(first))
+	}
+	last := []rune(words[len(words)-1])[0]
+	return string([]rune{unicode.ToUpper(first), unicode.ToUpper(last)})
+}
@@ -0,0 +1,88 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestInitials(t *testing.T) {
+	for name, want := range map[string]string{
+		"Jane Doe":              "JD",
+		"jane":                  "J",
+		"  jean-luc de picard ": "JP",
+		"john.smith@site.com":   "JS",
+		"admin@site.com":        "A",
+		"élodie":                "É",
+		"!!!":                   "",
+		"":                      "",
+	} {
+		assert.Equal(t, want, initials(name), name)
+	}
+}
+
+func TestGenerateInitials(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	img, err := g.GenerateInitials("Jane Doe", WithSize(120))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 120, 120), img.Bounds())
+	bg := color.RGBAModel.Convert(img.At(0, 0))
+	assert.Equal(t, color.Color(NewScheme(usernameSeed("Jane Doe")).Primary), bg)
+	// Letters are drawn across the middle
+	ink := 0
+	for x := 0; x < 120; x++ {
+		if color.RGBAModel.Convert(img.At(x, 60)) != bg {
+			ink++
+		}
+	}
+	assert.NotZero(t, ink)
+	assert.Equal(t, bg, color.RGBAModel.Convert(img.At(10, 60)))
+
+	again, err := g.GenerateInitials("Jane Doe", WithSize(120))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(img, again))
+	other, err := g.GenerateInitials("John Doe", WithSize(120))
+	assert.NoError(t, err)
+	assert.False(t, areImagesEquals(img, other))
+
+	img, err = g.GenerateInitials("Jane Doe", WithTransparent())
+	assert.NoError(t, err)
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	img, err = g.GenerateInitials("Jane Doe", WithoutBackground())
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), img.At(0, 0))
+
+	_, err = g.GenerateInitials("!!!")
+	assert.Equal(t, errNoInitials, err)
+	_, err = g.GenerateInitials("Jane Doe", WithSize(-1))
+	assert.Equal(t, errInvalidSize, err)
+}
+
+func TestInitialsFont(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	bold, err := g.GenerateInitials("Jane Doe", WithSize(120))
+	assert.NoError(t, err)
+	regular, err := g.GenerateInitials("Jane Doe", WithSize(120), WithFont(goregular.TTF))
+	assert.NoError(t, err)
+	assert.False(t, areImagesEquals(bold, regular))
+
+	// A face is used at its own size
+	face := fontFace(regularFont, 20)
+	defer face.Close()
+	small, err := g.GenerateInitials("Jane Doe", WithSize(120), WithFontFace(face))
+	assert.NoError(t, err)
+	assert.False(t, areImagesEquals(regular, small))
+	bg := color.RGBAModel.Convert(small.At(0, 0))
+	assert.Equal(t, bg, color.RGBAModel.Convert(small.At(60, 40)))
+
+	_, err = g.GenerateInitials("Jane Doe", WithFont([]byte("not a font")))
+	assert.Equal(t, errInvalidFont, err)
+	_, err = g.GenerateInitials("Jane Doe", WithFontFace(nil))
+	assert.Equal(t, errInvalidFont, err)
+}
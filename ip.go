@@ -0,0 +1,45 @@
+package govatar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"net"
+)
+
+var errInvalidIP = errors.New("Invalid IP address")
+
+// Network prefix lengths addresses are truncated to before hashing
+const (
+	ipv4PrefixLen = 24
+	ipv6PrefixLen = 48
+)
+
+// IPKey returns a salted, non-reversible key for ip usable as username.
+// IPv4 addresses are truncated to their /24 and IPv6 addresses to their /48
+// network, so visitors of the same network share a key.
+func IPKey(ip net.IP, salt []byte) (string, error) {
+	var network net.IP
+	if v4 := ip.To4(); v4 != nil {
+		network = v4.Mask(net.CIDRMask(ipv4PrefixLen, 8*net.IPv4len))
+	} else if len(ip) == net.IPv6len {
+		network = ip.Mask(net.CIDRMask(ipv6PrefixLen, 8*net.IPv6len))
+	} else {
+		return "", errInvalidIP
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(network)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// GenerateFromIP generates avatar for an anonymous visitor from the key
+// returned by IPKey
+func GenerateFromIP(gender Gender, ip net.IP, salt []byte) (image.Image, error) {
+	key, err := IPKey(ip, salt)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateFromUsername(gender, key)
+}
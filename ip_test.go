@@ -0,0 +1,44 @@
+package govatar
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPKey(t *testing.T) {
+	salt := []byte("salt")
+	key := func(ip string) string {
+		k, err := IPKey(net.ParseIP(ip), salt)
+		assert.NoError(t, err)
+		return k
+	}
+
+	assert.Equal(t, key("192.168.1.10"), key("192.168.1.200"))
+	assert.Equal(t, key("192.168.1.10"), key("::ffff:192.168.1.10"))
+	assert.NotEqual(t, key("192.168.1.10"), key("192.168.2.10"))
+
+	assert.Equal(t, key("2001:db8:1::1"), key("2001:db8:1:ffff::2"))
+	assert.NotEqual(t, key("2001:db8:1::1"), key("2001:db8:2::1"))
+
+	other, err := IPKey(net.ParseIP("192.168.1.10"), []byte("other salt"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, key("192.168.1.10"), other)
+	assert.NotContains(t, key("192.168.1.10"), "192")
+
+	_, err = IPKey(nil, salt)
+	assert.Equal(t, errInvalidIP, err)
+}
+
+func TestGenerateFromIP(t *testing.T) {
+	salt := []byte("salt")
+	avatar1, err := GenerateFromIP(MALE, net.ParseIP("10.0.0.1"), salt)
+	assert.NoError(t, err)
+	avatar2, err := GenerateFromIP(MALE, net.ParseIP("10.0.0.2"), salt)
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(avatar1, avatar2))
+
+	_, err = GenerateFromIP(MALE, net.IP{1, 2}, salt)
+	assert.Equal(t, errInvalidIP, err)
+}
@@ -0,0 +1,106 @@
+package govatar
+
+import "errors"
+
+var errInvalidLayer = errors.New("Invalid layer")
+
+// WithLayerOrder draws the layers in the given order, e.g. "hair", "face",
+// "clothes" to draw hair behind the face. Layers left out of order follow in
+// the order of the asset pack, the background is always drawn first.
+// It overrides Config.LayerOrder.
+func WithLayerOrder(layers ...string) Option {
+	return func(o *options) {
+		if err := checkLayerOrder(layers); err != nil {
+			o.err = err
+			return
+		}
+		o.order = layers
+	}
+}
+
+// WithoutLayers leaves the given layers out, e.g. "clothes" for a headshot.
+// Leaving out "background" is the same as WithoutBackground.
+func WithoutLayers(layers ...string) Option {
+	return func(o *options) {
+		for _, layer := range layers {
+			if layer == "background" {
+				WithoutBackground()(o)
+				continue
+			}
+			if !validLayer(layer) {
+				o.err = errInvalidLayer
+				return
+			}
+			if o.excluded == nil {
+				o.excluded = map[string]bool{}
+			}
+			o.excluded[layer] = true
+		}
+	}
+}
+
+// validLayer reports whether layer names a person layer or may name an
+// extra layer
+func validLayer(layer string) bool {
+	return isPersonLayer(layer) || validLayerName(layer)
+}
+
+// checkLayerOrder checks that layers name each layer once and leave out
+// the background
+func checkLayerOrder(layers []string) error {
+	seen := map[string]bool{}
+	for _, layer := range layers {
+		if !validLayer(layer) || seen[layer] {
+			return errInvalidLayer
+		}
+		seen[layer] = true
+	}
+	return nil
+}
+
+// arrange returns layers and their assets in the drawing order of o without
+// the layers o leaves out. The background stays first.
+func (o options) arrange(layers, assets []string) ([]string, []string) {
+	if len(o.order) == 0 && len(o.excluded) == 0 {
+		return layers, assets
+	}
+	byName := make(map[string]string, len(layers))
+	for i, layer := range layers {
+		byName[layer] = assets[i]
+	}
+	var names, paths []string
+	add := func(layer string) {
+		path, ok := byName[layer]
+		if !ok || o.excluded[layer] {
+			return
+		}
+		delete(byName, layer)
+		names = append(names, layer)
+		paths = append(paths, path)
+	}
+	add(layers[0])
+	for _, layer := range o.order {
+		add(layer)
+	}
+	for _, layer := range layers {
+		add(layer)
+	}
+	return names, paths
+}
+
+// specAssets returns asset paths of spec in the drawing order of o
+func (g *Generator) specAssets(spec Spec, o options) ([]string, error) {
+	_, assets, err := g.specLayers(spec, o)
+	return assets, err
+}
+
+// specLayers returns the names and asset paths of the layers of spec in
+// the drawing order of o
+func (g *Generator) specLayers(spec Spec, o options) (layers, assets []string, err error) {
+	layers, assets, err = g.store.drawnLayout(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	layers, assets = o.arrange(layers, assets)
+	return layers, assets, nil
+}
@@ -0,0 +1,111 @@
+package govatar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLayerOrder(t *testing.T) {
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	o, err := std().options([]Option{WithLayerOrder("hair", "face")})
+	assert.NoError(t, err)
+	assets, err := std().specAssets(spec, o)
+	assert.NoError(t, err)
+	all, err := std().store.specAssets(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]string{all[0], all[4], all[1], all[2], all[3]}, all[5:]...), assets)
+
+	img, err := GenerateFromSpec(spec, WithLayerOrder("hair", "face"))
+	assert.NoError(t, err)
+	expected, err := std().store.render(context.Background(), assets, nil, assetSize, CatmullRom)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	// Layers the gender doesn't have are skipped
+	_, err = GenerateFromSpec(spec, WithLayerOrder("tattoo", "eye"))
+	assert.NoError(t, err)
+
+	for _, order := range [][]string{{"background"}, {"hair", "hair"}, {"Hair"}, {""}} {
+		_, err = GenerateFromSpec(spec, WithLayerOrder(order...))
+		assert.Equal(t, errInvalidLayer, err, order)
+	}
+
+	withOrder := std().cacheKey(MALE, "username", "png", options{order: []string{"hair"}})
+	assert.NotEqual(t, std().cacheKey(MALE, "username", "png", options{}), withOrder)
+}
+
+func TestWithoutLayers(t *testing.T) {
+	spec, err := SpecFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	all, err := std().store.specAssets(spec)
+	assert.NoError(t, err)
+
+	img, err := GenerateFromSpec(spec, WithoutLayers("clothes", "tattoo"), WithSize(64))
+	assert.NoError(t, err)
+	expected, err := std().store.render(context.Background(), append([]string{all[0], all[1]}, all[3:]...), nil, 64, CatmullRom)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	img, err = GenerateFromSpec(spec, WithoutLayers("background"), WithSize(64))
+	assert.NoError(t, err)
+	expected, err = GenerateFromSpec(spec, WithoutBackground(), WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	_, err = GenerateFromSpec(spec, WithoutLayers("Clothes"))
+	assert.Equal(t, errInvalidLayer, err)
+
+	svg, err := GenerateSVGFromSpec(spec, WithoutLayers("hair"))
+	assert.NoError(t, err)
+	full, err := GenerateSVGFromSpec(spec)
+	assert.NoError(t, err)
+	assert.Less(t, len(svg), len(full))
+
+	key := std().cacheKey(FEMALE, "username", "png", options{excluded: map[string]bool{"clothes": true}})
+	assert.NotEqual(t, std().cacheKey(FEMALE, "username", "png", options{}), key)
+}
+
+func TestEditorWithoutLayers(t *testing.T) {
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	e, err := NewEditor(d, WithoutLayers("clothes"), WithSize(64))
+	assert.NoError(t, err)
+	expected, err := GenerateFromSpec(spec, WithoutLayers("clothes"), WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, e.Image())
+
+	// Parts of left out layers change the descriptor only
+	img, err := e.Set(CLOTHES, (spec.Clothes+1)%len(std().store.Male.assets("clothes")))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+	assert.NotEqual(t, d, e.Descriptor())
+	_, err = e.Set(CLOTHES, 10000)
+	assert.Equal(t, errInvalidSpec, err)
+
+	img, err = e.Set(HAIR, (spec.Hair+1)%len(std().store.Male.assets("hair")))
+	assert.NoError(t, err)
+	spec.Hair = (spec.Hair + 1) % len(std().store.Male.assets("hair"))
+	expected, err = GenerateFromSpec(spec, WithoutLayers("clothes"), WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+}
+
+func TestConfigLayerOrder(t *testing.T) {
+	c := DefaultConfig()
+	c.LayerOrder = []string{"hair", "face"}
+	g, err := New(c)
+	assert.NoError(t, err)
+	img, err := g.GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	expected, err := GenerateFromUsername(MALE, "username@site.com", WithLayerOrder("hair", "face"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	c.LayerOrder = []string{"face", "face"}
+	assert.Equal(t, errInvalidLayer, c.Validate())
+}
@@ -0,0 +1,349 @@
+package govatar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// person holds the assets of a gender by layer name
+type person struct {
+	layers map[string][]string
+	// order lists layers in drawing order, background is drawn first
+	order []string
+	// extras lists layers beyond personLayers in selection order
+	extras []string
+	// none maps extra layers to the probability that avatars go without them
+	none map[string]float64
+	// shared marks extra layers read from the shared accessories directory,
+	// which random avatars wear only if the store says so
+	shared map[string]bool
+	// rules keep parts apart
+	rules []rule
+	// weights maps layers to the weights of their parts in random avatars,
+	// nil for layers whose parts are equally likely
+	weights map[string][]float64
+	// dependents maps dependent layers, which order lists but layers does
+	// not, to their parts
+	dependents map[string]dependentLayer
+}
+
+// assets returns the assets of layer in selection order
+func (p person) assets(layer string) []string {
+	return p.layers[layer]
+}
+
+// isPersonLayer reports whether layer is one of personLayers, which every
+// gender has
+func isPersonLayer(layer string) bool {
+	for _, l := range personLayers {
+		if l == layer {
+			return true
+		}
+	}
+	return false
+}
+
+// validLayerName reports whether a directory can hold an extra layer:
+// names are two or more lowercase letters, - and _, so they can key parts
+// of spec strings
+func validLayerName(name string) bool {
+	if len(name) < 2 || name == "background" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPerson reads the layers of the gender directory name in the pack root
+// of src, or of root itself if name is empty. Every subdirectory with a
+// valid name is a layer: the person layers come first, then the accessory
+// layers and other layers by name. manifest, if not nil, orders layers for
+// drawing, sets how often extra layers are left out, how likely their parts
+// are, which parts are kept apart and which layers depend on others.
+// Gender directories also get the layers of the shared accessories
+// directory of root they don't have themselves.
+func loadPerson(src assetSource, root, name string, manifest *Manifest) person {
+	dir := filepath.Join(root, name)
+	p := person{layers: map[string][]string{}, none: map[string]float64{}, shared: map[string]bool{}, weights: map[string][]float64{}}
+	for _, layer := range personLayers {
+		p.layers[layer] = src.list(filepath.Join(dir, layer))
+	}
+	p.extras = extraLayers(src, dir)
+	dirs := map[string]string{}
+	for _, layer := range p.extras {
+		dirs[layer] = filepath.Join(dir, layer)
+	}
+	if name != "" {
+		for _, layer := range extraLayers(src, filepath.Join(root, accessoriesDir)) {
+			if _, ok := dirs[layer]; !ok && wearsAccessory(name, layer) {
+				dirs[layer] = filepath.Join(root, accessoriesDir, layer)
+				p.shared[layer] = true
+				p.extras = append(p.extras, layer)
+			}
+		}
+		p.extras = sortExtras(p.extras)
+	}
+	for _, layer := range p.extras {
+		assets := src.list(dirs[layer])
+		if len(assets) == 0 {
+			continue
+		}
+		p.layers[layer] = assets
+		p.none[layer] = accessoryNone[layer]
+		if manifest != nil {
+			if none, ok := manifest.Optional[layer]; ok {
+				p.none[layer] = none
+			}
+		}
+	}
+	extras := p.extras[:0]
+	for _, layer := range p.extras {
+		if p.layers[layer] != nil {
+			extras = append(extras, layer)
+		}
+	}
+	p.extras = extras
+	p.loadDependents(src, dir, manifest)
+	if manifest != nil {
+		for layer, assets := range p.layers {
+			if p.shared[layer] {
+				continue
+			}
+			if weights := loadWeights(assets, manifest.Weights[path.Join(name, layer)]); weights != nil {
+				p.weights[layer] = weights
+			}
+		}
+	}
+
+	var order []string
+	if manifest != nil {
+		order = append(order, manifest.Layers...)
+		// BuildPack reports invalid rules
+		p.rules, _ = parseRules(manifest.Rules)
+	}
+	p.order = placeDependents(orderLayers(append(append(order, personLayers...), p.extras...), p.drawable()), p.parents())
+	return p
+}
+
+// extraLayers returns names of the subdirectories of dir beyond personLayers
+// that can hold layers: the accessory layers first, then others by name
+func extraLayers(src assetSource, dir string) []string {
+	var layers []string
+	for _, name := range src.dirs(dir) {
+		if validLayerName(name) && !isPersonLayer(name) {
+			layers = append(layers, name)
+		}
+	}
+	return sortExtras(layers)
+}
+
+// sortExtras returns extra layers in selection order: the accessory layers
+// first, then others by name
+func sortExtras(layers []string) []string {
+	found := map[string]bool{}
+	for _, layer := range layers {
+		found[layer] = true
+	}
+	var extras []string
+	for _, layer := range accessoryLayers {
+		if found[layer] {
+			extras = append(extras, layer)
+			delete(found, layer)
+		}
+	}
+	var others []string
+	for layer := range found {
+		others = append(others, layer)
+	}
+	sort.Strings(others)
+	return append(extras, others...)
+}
+
+// orderLayers returns layers without duplicates and layers missing from assets
+func orderLayers(layers []string, assets map[string][]string) []string {
+	var order []string
+	seen := map[string]bool{}
+	for _, layer := range layers {
+		if _, ok := assets[layer]; ok && !seen[layer] {
+			seen[layer] = true
+			order = append(order, layer)
+		}
+	}
+	return order
+}
+
+// readManifest returns the manifest in dir of src, nil if there is none
+func readManifest(src assetSource, dir string) *Manifest {
+	f, err := src.open(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	var m Manifest
+	if json.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	return &m
+}
+
+// mixPeople returns a person with the parts of a followed by those of b
+func mixPeople(a, b person) person {
+	p := person{layers: map[string][]string{}, none: map[string]float64{}, shared: map[string]bool{}, weights: map[string][]float64{}}
+	for _, layers := range []map[string][]string{a.layers, b.layers} {
+		for layer, assets := range layers {
+			// Shared accessories are the same for both
+			if a.shared[layer] && b.shared[layer] && p.layers[layer] != nil {
+				continue
+			}
+			p.layers[layer] = append(p.layers[layer], assets...)
+		}
+	}
+	for layer := range p.layers {
+		if weights := mixWeights(a.weights[layer], len(a.layers[layer]), b.weights[layer], len(b.layers[layer])); weights != nil {
+			p.weights[layer] = weights
+		}
+	}
+	for _, none := range []map[string]float64{b.none, a.none} {
+		for layer, n := range none {
+			p.none[layer] = n
+		}
+	}
+	for layer := range p.layers {
+		if (a.shared[layer] || a.layers[layer] == nil) && (b.shared[layer] || b.layers[layer] == nil) {
+			p.shared[layer] = true
+		}
+	}
+	p.dependents = mixDependents(a, b)
+	p.order = orderLayers(append(append([]string(nil), a.order...), b.order...), p.drawable())
+	p.extras = orderLayers(append(append([]string(nil), a.extras...), b.extras...), p.layers)
+	start, countA, countB := map[string]int{}, map[string]int{}, map[string]int{}
+	for layer, assets := range a.layers {
+		start[layer], countA[layer] = len(assets), len(assets)
+	}
+	for layer, assets := range b.layers {
+		countB[layer] = len(assets)
+	}
+	p.rules = append(shiftRules(a.rules, nil, countA), shiftRules(b.rules, start, countB)...)
+	return p
+}
+
+// withPrefix returns p with prefix added to the path of every asset
+func (p person) withPrefix(prefix string) person {
+	layers := make(map[string][]string, len(p.layers))
+	for layer, assets := range p.layers {
+		prefixed := make([]string, len(assets))
+		for i, asset := range assets {
+			prefixed[i] = prefix + asset
+		}
+		layers[layer] = prefixed
+	}
+	p.layers = layers
+	if len(p.dependents) > 0 {
+		dependents := make(map[string]dependentLayer, len(p.dependents))
+		for layer, d := range p.dependents {
+			prefixed := dependentLayer{parent: d.parent, assets: make([]string, len(d.assets))}
+			for i, asset := range d.assets {
+				if asset != "" {
+					prefixed.assets[i] = prefix + asset
+				}
+			}
+			dependents[layer] = prefixed
+		}
+		p.dependents = dependents
+	}
+	return p
+}
+
+// specLayout returns the names and asset paths of the layers of spec in
+// drawing order
+func (s *store) specLayout(spec Spec) (layers, assets []string, err error) {
+	p, err := s.person(spec.Gender)
+	if err != nil {
+		return nil, nil, err
+	}
+	for layer := range spec.Accessories {
+		if isPersonLayer(layer) || p.layers[layer] == nil {
+			return nil, nil, errInvalidSpec
+		}
+	}
+	add := func(layer string, choices []string, i int) error {
+		if i < 0 || i >= len(choices) {
+			return errInvalidSpec
+		}
+		layers = append(layers, layer)
+		assets = append(assets, choices[i])
+		return nil
+	}
+	if err = add("background", s.Background, spec.Background); err != nil {
+		return nil, nil, err
+	}
+	for _, layer := range p.order {
+		i, ok := spec.Accessories[layer]
+		if index := spec.layer(layer); index != nil {
+			i, ok = *index, true
+		}
+		if !ok {
+			continue
+		}
+		if err = add(layer, p.layers[layer], i); err != nil {
+			return nil, nil, err
+		}
+	}
+	return layers, assets, nil
+}
+
+// drawnLayout returns the layers of spec like specLayout without the parts
+// rules leave out and with the parts of dependent layers their parent parts
+// pull in
+func (s *store) drawnLayout(spec Spec) (layers, assets []string, err error) {
+	all, paths, err := s.specLayout(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, _ := s.person(spec.Gender)
+	drawn := func(layer string) (int, bool) {
+		index, ok := spec.part(layer)
+		return index, ok && !p.excluded(spec, layer, index)
+	}
+	layers, assets = []string{all[0]}, []string{paths[0]}
+	next := 1
+	for _, layer := range p.order {
+		if d, ok := p.dependents[layer]; ok {
+			if index, ok := drawn(d.parent); ok && d.assets[index] != "" {
+				layers = append(layers, layer)
+				assets = append(assets, d.assets[index])
+			}
+			continue
+		}
+		if next == len(all) || all[next] != layer {
+			continue
+		}
+		if _, ok := drawn(layer); ok {
+			layers = append(layers, layer)
+			assets = append(assets, paths[next])
+		}
+		next++
+	}
+	return layers, assets, nil
+}
+
+// layerIndex returns the index of layer in layers, -1 if it is not there
+func layerIndex(layers []string, layer string) int {
+	for i, l := range layers {
+		if l == layer {
+			return i
+		}
+	}
+	return -1
+}
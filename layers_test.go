@@ -0,0 +1,84 @@
+package govatar
+
+import (
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraLayers(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys["tattoo/tattoo1.png"] = fsys["glasses/glasses1.png"]
+	fsys["Tattoo/tattoo1.png"] = fsys["glasses/glasses1.png"]
+	fsys["x/x1.png"] = fsys["glasses/glasses1.png"]
+	assert.NoError(t, Register("tattooed", fsys))
+	gender, _ := LookupStyle("tattooed")
+
+	st, _ := style(gender)
+	assert.Equal(t, []string{"glasses", "hat", "tattoo"}, st.person.extras)
+	assert.Equal(t, []string{"face", "clothes", "mouth", "hair", "eye", "glasses", "hat", "tattoo"}, st.person.order)
+
+	// Layers other than accessories are drawn on every avatar
+	for i := 0; i < 50; i++ {
+		spec, err := SpecFromUsername(gender, "user"+strconv.Itoa(i))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, spec.Accessories["tattoo"])
+		assert.Contains(t, spec.Accessories, "tattoo")
+	}
+	spec := NewBuilder(gender).Accessory("tattoo", 0).Spec()
+	assert.Equal(t, "v1:tattooed:f0.c0.h0.e0.m0.b0.tattoo0", FormatSpec(spec))
+	parsed, err := ParseSpec(FormatSpec(spec))
+	assert.NoError(t, err)
+	assert.Equal(t, spec, parsed)
+
+	c := GetCatalog()
+	layers := c.Genders[len(c.Genders)-1].Layers
+	assert.Equal(t, CatalogLayer{"tattoo", 1}, layers[len(layers)-1])
+}
+
+func TestManifestLayerOrder(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys["tattoo/tattoo1.png"] = fsys["glasses/glasses1.png"]
+	fsys["hair/hair2.png"] = fsys["hat/hat1.png"]
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"layers": ["tattoo", "face", "hair", "clothes", "unknown"], "optional": {"tattoo": 0.5, "hat": 0}}`)}
+	assert.NoError(t, Register("ordered", fsys))
+	gender, _ := LookupStyle("ordered")
+
+	st, _ := style(gender)
+	assert.Equal(t, []string{"tattoo", "face", "hair", "clothes", "mouth", "eye", "glasses", "hat"}, st.person.order)
+	// Drawing order leaves the selection of parts as it is
+	assert.Equal(t, []string{"glasses", "hat", "tattoo"}, st.person.extras)
+
+	tattoos := 0
+	for i := 0; i < 100; i++ {
+		spec, err := SpecFromUsername(gender, "user"+strconv.Itoa(i))
+		assert.NoError(t, err)
+		assert.Contains(t, spec.Accessories, "hat")
+		if _, ok := spec.Accessories["tattoo"]; ok {
+			tattoos++
+		}
+	}
+	assert.InDelta(t, 50, tattoos, 20)
+
+	spec := NewBuilder(gender).Accessory("tattoo", 0).Spec()
+	layers, assets, err := std().store.specLayout(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"background", "tattoo", "face", "hair", "clothes", "mouth", "eye"}, layers)
+	assert.Equal(t, "style:ordered/tattoo/tattoo1.png", assets[1])
+
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "tattoo", d.Layers[1].Name)
+	e, err := NewEditor(d)
+	assert.NoError(t, err)
+	img, err := e.Set(HAIR, 1)
+	assert.NoError(t, err)
+	spec.Hair = 1
+	expected, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+}
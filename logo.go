@@ -0,0 +1,89 @@
+package govatar
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+var errInvalidLogo = errors.New("Invalid logo")
+
+// logoScale is the largest part of the avatar width or height covered by
+// logos, logoMargin the gap to the edges
+const (
+	logoScale  = 0.25
+	logoMargin = 0.04
+)
+
+// logo is an image stamped over the finished avatar
+type logo struct {
+	img     image.Image
+	corner  Corner
+	opacity float64
+}
+
+// WithLogo stamps img on corner of the avatar with opacity from 0 to 1, as
+// the last layer over shapes, frames and badges. The logo keeps its aspect
+// ratio and covers at most a quarter of the avatar width.
+func WithLogo(img image.Image, corner Corner, opacity float64) Option {
+	return func(o *options) {
+		o.logo = &logo{img: img, corner: corner, opacity: opacity}
+		if img == nil || img.Bounds().Empty() || corner < TopLeft || corner > BottomRight || opacity < 0 || opacity > 1 {
+			o.err = errInvalidLogo
+		}
+	}
+}
+
+// rect returns where l is drawn on an avatar of bounds
+func (l *logo) rect(bounds image.Rectangle) image.Rectangle {
+	src := l.img.Bounds()
+	w, h := float64(bounds.Dx())*logoScale, float64(bounds.Dy())*logoScale
+	if k := float64(src.Dx()) / float64(src.Dy()); k > w/h {
+		h = w / k
+	} else {
+		w = h * k
+	}
+	dx, dy := int(w+0.5), int(h+0.5)
+	if dx < 1 {
+		dx = 1
+	}
+	if dy < 1 {
+		dy = 1
+	}
+	margin := int(float64(bounds.Dx())*logoMargin + 0.5)
+	x, y := bounds.Min.X+margin, bounds.Min.Y+margin
+	if l.corner == TopRight || l.corner == BottomRight {
+		x = bounds.Max.X - margin - dx
+	}
+	if l.corner == BottomLeft || l.corner == BottomRight {
+		y = bounds.Max.Y - margin - dy
+	}
+	return image.Rect(x, y, x+dx, y+dy)
+}
+
+// draw stamps l over img
+func (l *logo) draw(img *image.RGBA) {
+	r := l.rect(img.Bounds())
+	scaled := image.NewRGBA(r)
+	xdraw.CatmullRom.Scale(scaled, r, l.img, l.img.Bounds(), draw.Src, nil)
+	mask := image.NewUniform(color.Alpha16{uint16(l.opacity*0xffff + 0.5)})
+	draw.DrawMask(img, r, scaled, r.Min, mask, image.Point{}, draw.Over)
+}
+
+// svg writes l stamped on an avatar of size as an SVG image in svgGrid units
+func (l *logo) svg(w *strings.Builder, size int) error {
+	uri, err := pngDataURI(l.img)
+	if err != nil {
+		return err
+	}
+	k := float64(svgGrid) / float64(size)
+	r := l.rect(image.Rect(0, 0, size, size))
+	fmt.Fprintf(w, `<image x="%g" y="%g" width="%g" height="%g" opacity="%g" preserveAspectRatio="none" href="%s"/>`,
+		float64(r.Min.X)*k, float64(r.Min.Y)*k, float64(r.Dx())*k, float64(r.Dy())*k, l.opacity, uri)
+	return nil
+}
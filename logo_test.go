@@ -0,0 +1,65 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogo returns a white logo of w by h pixels
+func testLogo(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+func TestLogoRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 200)
+	l := &logo{img: testLogo(40, 20), corner: BottomRight}
+	assert.Equal(t, image.Rect(142, 167, 192, 192), l.rect(bounds))
+	l.corner = TopLeft
+	assert.Equal(t, image.Rect(8, 8, 58, 33), l.rect(bounds))
+	l = &logo{img: testLogo(10, 40), corner: TopRight}
+	assert.Equal(t, image.Rect(179, 8, 192, 58), l.rect(bounds))
+}
+
+func TestWithLogo(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	plain, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(200))
+	assert.NoError(t, err)
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(200), WithLogo(testLogo(40, 20), BottomRight, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), img.At(170, 180))
+	assert.Equal(t, plain.At(195, 195), img.At(195, 195))
+
+	// Translucent logos blend with the avatar
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(200), WithLogo(testLogo(40, 20), BottomRight, 0.5))
+	assert.NoError(t, err)
+	under := color.RGBAModel.Convert(plain.At(170, 180)).(color.RGBA)
+	blend := color.RGBAModel.Convert(img.At(170, 180)).(color.RGBA)
+	assert.InDelta(t, (int(under.R)+0xff)/2, int(blend.R), 1)
+
+	// Logos are drawn over badges
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(200), WithBadge(BottomRight, badgeGreen), WithLogo(testLogo(40, 20), BottomRight, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), img.At(170, 180))
+
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithSize(200), WithLogo(testLogo(40, 20), TopLeft, 0.5))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `<image x="1.6" y="1.6" width="10" height="5" opacity="0.5"`)
+
+	for _, opt := range []Option{
+		WithLogo(nil, TopLeft, 1),
+		WithLogo(testLogo(0, 0), TopLeft, 1),
+		WithLogo(testLogo(4, 4), Corner(-1), 1),
+		WithLogo(testLogo(4, 4), TopLeft, 1.5),
+	} {
+		_, err = g.GenerateFromUsername(MALE, "username@site.com", opt)
+		assert.Equal(t, errInvalidLogo, err)
+	}
+}
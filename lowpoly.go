@@ -0,0 +1,188 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// lowPolyPoints is the number of points sampled inside the image by LowPoly
+const lowPolyPoints = 300
+
+type point struct {
+	X, Y float64
+}
+
+type triangle struct {
+	A, B, C point
+}
+
+// LowPoly renders img as a mesh of flat colored triangles. Mesh points are
+// sampled with seed, favouring edges, and triangulated with Delaunay so
+// the same avatar and seed always give the same mesh. Empty images are
+// returned as empty images.
+func LowPoly(img image.Image, seed int64) image.Image {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return image.NewNRGBA(bounds)
+	}
+	triangles := delaunay(samplePoints(img, rand.New(rand.NewSource(seed)), lowPolyPoints))
+
+	dst := image.NewNRGBA(bounds)
+	for _, t := range triangles {
+		fillTriangle(dst, img, t)
+	}
+	return dst
+}
+
+// samplePoints returns image corners, border points and n inner points
+// picked with probability growing with local contrast
+func samplePoints(img image.Image, rnd *rand.Rand, n int) []point {
+	b := img.Bounds()
+	minX, minY := float64(b.Min.X), float64(b.Min.Y)
+	maxX, maxY := float64(b.Max.X), float64(b.Max.Y)
+	points := []point{{minX, minY}, {maxX, minY}, {minX, maxY}, {maxX, maxY}}
+	for i := 1; i < 8; i++ {
+		fx := minX + (maxX-minX)*float64(i)/8
+		fy := minY + (maxY-minY)*float64(i)/8
+		points = append(points, point{fx, minY}, point{fx, maxY}, point{minX, fy}, point{maxX, fy})
+	}
+
+	for attempts := 0; n > 0 && attempts < n*50; attempts++ {
+		x := b.Min.X + rnd.Intn(b.Dx())
+		y := b.Min.Y + rnd.Intn(b.Dy())
+		if rnd.Float64() < 0.05+edgeStrength(img, x, y) {
+			points = append(points, point{float64(x) + 0.5, float64(y) + 0.5})
+			n--
+		}
+	}
+	return points
+}
+
+// edgeStrength returns contrast between the pixel at x, y and its right and
+// bottom neighbours from 0 to 1
+func edgeStrength(img image.Image, x, y int) float64 {
+	b := img.Bounds()
+	c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	var diff float64
+	if x+1 < b.Max.X {
+		diff += math.Abs(luma(c) - luma(color.NRGBAModel.Convert(img.At(x+1, y)).(color.NRGBA)))
+	}
+	if y+1 < b.Max.Y {
+		diff += math.Abs(luma(c) - luma(color.NRGBAModel.Convert(img.At(x, y+1)).(color.NRGBA)))
+	}
+	return math.Min(diff/255, 1)
+}
+
+// delaunay triangulates points with the Bowyer-Watson algorithm
+func delaunay(points []point) []triangle {
+	if len(points) < 3 {
+		return nil
+	}
+	minX, minY, maxX, maxY := points[0].X, points[0].Y, points[0].X, points[0].Y
+	for _, p := range points {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	d := math.Max(maxX-minX, maxY-minY) * 10
+	midX, midY := (minX+maxX)/2, (minY+maxY)/2
+	super := triangle{point{midX - d, midY - d}, point{midX + d, midY - d}, point{midX, midY + d}}
+
+	triangles := []triangle{super}
+	for _, p := range points {
+		type edge struct{ A, B point }
+		var edges []edge
+		kept := triangles[:0]
+		for _, t := range triangles {
+			if t.circumcircleContains(p) {
+				edges = append(edges, edge{t.A, t.B}, edge{t.B, t.C}, edge{t.C, t.A})
+			} else {
+				kept = append(kept, t)
+			}
+		}
+		triangles = kept
+		for i, e := range edges {
+			shared := false
+			for j, o := range edges {
+				if i != j && (e.A == o.A && e.B == o.B || e.A == o.B && e.B == o.A) {
+					shared = true
+					break
+				}
+			}
+			if !shared {
+				triangles = append(triangles, triangle{e.A, e.B, p})
+			}
+		}
+	}
+
+	result := triangles[:0]
+	for _, t := range triangles {
+		if !t.hasVertex(super.A) && !t.hasVertex(super.B) && !t.hasVertex(super.C) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func (t triangle) hasVertex(p point) bool {
+	return t.A == p || t.B == p || t.C == p
+}
+
+func (t triangle) circumcircleContains(p point) bool {
+	ax, ay := t.A.X-p.X, t.A.Y-p.Y
+	bx, by := t.B.X-p.X, t.B.Y-p.Y
+	cx, cy := t.C.X-p.X, t.C.Y-p.Y
+	det := (ax*ax+ay*ay)*(bx*cy-cx*by) - (bx*bx+by*by)*(ax*cy-cx*ay) + (cx*cx+cy*cy)*(ax*by-bx*ay)
+	if t.orientation() > 0 {
+		return det > 0
+	}
+	return det < 0
+}
+
+func (t triangle) orientation() float64 {
+	return (t.B.X-t.A.X)*(t.C.Y-t.A.Y) - (t.B.Y-t.A.Y)*(t.C.X-t.A.X)
+}
+
+// contains reports whether the center of pixel x, y lies inside t
+func (t triangle) contains(x, y int) bool {
+	p := point{float64(x) + 0.5, float64(y) + 0.5}
+	d1 := triangle{p, t.A, t.B}.orientation()
+	d2 := triangle{p, t.B, t.C}.orientation()
+	d3 := triangle{p, t.C, t.A}.orientation()
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+// fillTriangle paints the pixels of t in dst with their average color in src
+func fillTriangle(dst *image.NRGBA, src image.Image, t triangle) {
+	r := image.Rect(
+		int(math.Floor(math.Min(t.A.X, math.Min(t.B.X, t.C.X)))),
+		int(math.Floor(math.Min(t.A.Y, math.Min(t.B.Y, t.C.Y)))),
+		int(math.Ceil(math.Max(t.A.X, math.Max(t.B.X, t.C.X)))),
+		int(math.Ceil(math.Max(t.A.Y, math.Max(t.B.Y, t.C.Y)))),
+	).Intersect(dst.Bounds())
+
+	var sr, sg, sb, sa, n uint64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if t.contains(x, y) {
+				c := color.RGBA64Model.Convert(src.At(x, y)).(color.RGBA64)
+				sr, sg, sb, sa = sr+uint64(c.R), sg+uint64(c.G), sb+uint64(c.B), sa+uint64(c.A)
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return
+	}
+	avg := color.RGBA64{uint16(sr / n), uint16(sg / n), uint16(sb / n), uint16(sa / n)}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if t.contains(x, y) {
+				dst.Set(x, y, avg)
+			}
+		}
+	}
+}
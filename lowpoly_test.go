@@ -0,0 +1,43 @@
+package govatar
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLowPoly(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+
+	img := LowPoly(avatar, 42)
+	assert.Equal(t, avatar.Bounds(), img.Bounds())
+	assert.True(t, areImagesEquals(img, LowPoly(avatar, 42)))
+	assert.False(t, areImagesEquals(img, LowPoly(avatar, 43)))
+
+	// Every pixel is covered by a triangle
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a == 0 {
+				_, _, _, srcA := avatar.At(x, y).RGBA()
+				assert.Equal(t, uint32(0), srcA, "pixel %d,%d is not covered", x, y)
+			}
+		}
+	}
+}
+
+func TestLowPolyEmpty(t *testing.T) {
+	for _, r := range []image.Rectangle{{}, image.Rect(0, 0, 0, 10), image.Rect(5, 5, 15, 5)} {
+		img := LowPoly(image.NewNRGBA(r), 42)
+		assert.True(t, img.Bounds().Empty(), "%v", r)
+	}
+}
+
+func TestDelaunay(t *testing.T) {
+	square := []point{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	assert.Len(t, delaunay(square), 2)
+	assert.Len(t, delaunay(append(square, point{0.5, 0.5})), 4)
+	assert.Nil(t, delaunay(square[:2]))
+}
@@ -0,0 +1,201 @@
+package govatar
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// Option adjusts a single generation call
+type Option func(*options)
+
+type options struct {
+	size        int
+	seed        int64
+	seeded      bool
+	background  bool
+	transparent bool
+	filter      Filter
+	quality     int
+	lossless    bool
+	ctx         context.Context
+	cache       Cache
+	descriptor  *Descriptor
+	traits      *Traits
+	pixelArt    int
+	supersample int
+	font        *opentype.Font
+	fontFace    font.Face
+	shape       Shape
+	radius      int
+	matte       color.Color
+	frame       *frame
+	badge       *badge
+	logo        *logo
+	overlays    []overlay
+	palette     []color.Color
+	backgrounds []image.Image
+	// tints recolor layers, nil tints are picked from the parts
+	tints map[string]*Tint
+	// tintPalette holds the colors of picked tints, see WithTheme
+	tintPalette []color.Color
+	// skinTones remap the skin of faces, see WithSkinTones
+	skinTones []color.Color
+	// order and excluded arrange layers, see arrange
+	order    []string
+	excluded map[string]bool
+	// renderer draws the avatar instead of the layered artwork if set, see
+	// WithRenderer
+	renderer     Renderer
+	rendererName string
+	// err is set by options that failed to apply
+	err error
+}
+
+// WithSize sets width and height of the avatar in pixels
+func WithSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithSeed picks parts from seed instead of a random or username derived one
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
+
+// WithoutBackground leaves out the background artwork and draws the
+// character over white, for formats without transparency
+func WithoutBackground() Option {
+	return func(o *options) {
+		o.background = false
+		o.transparent = false
+		o.palette = nil
+		o.backgrounds = nil
+	}
+}
+
+// WithTransparent leaves out the background artwork and keeps the area
+// around the character transparent. JPEG output shows the matte there.
+func WithTransparent() Option {
+	return func(o *options) {
+		o.background = false
+		o.transparent = true
+		o.palette = nil
+		o.backgrounds = nil
+	}
+}
+
+// WithTransparentBackground is WithTransparent: the background artwork is
+// left out and PNG, WebP, AVIF and GIF output keep the alpha channel so the
+// avatar sits on any surface. JPEG output shows the color set by WithMatte
+// around the character.
+func WithTransparentBackground() Option {
+	return WithTransparent()
+}
+
+// WithQuality sets quality of jpeg, lossy webp and avif output from 1 to 100,
+// overriding Config.JPEGQuality
+func WithQuality(quality int) Option {
+	return func(o *options) {
+		o.quality = quality
+	}
+}
+
+// WithLossless encodes webp and avif output losslessly
+func WithLossless() Option {
+	return func(o *options) {
+		o.lossless = true
+	}
+}
+
+// options returns settings of g adjusted by opts
+func (g *Generator) options(opts []Option) (options, error) {
+	o := options{size: g.config.Size, background: true, filter: g.config.Filter, quality: g.config.JPEGQuality, matte: color.White, order: g.config.LayerOrder}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.err != nil {
+		return o, o.err
+	}
+	if o.size <= 0 || o.size > maxSize {
+		return o, errInvalidSize
+	}
+	if o.filter < CatmullRom || o.filter > NEAREST {
+		return o, errInvalidFilter
+	}
+	if o.quality < 1 || o.quality > 100 {
+		return o, errInvalidQuality
+	}
+	return o, nil
+}
+
+// pixelate turns img into pixel art if o asks for it
+func (o options) pixelate(img image.Image) image.Image {
+	if o.pixelArt == 0 || img == nil {
+		return img
+	}
+	return pixelate(img, o.pixelArt, o.size)
+}
+
+// compose draws the avatar of spec as set by o
+func (g *Generator) compose(spec Spec, o options) (img image.Image, err error) {
+	if o.supersample > 1 && o.pixelArt == 0 {
+		img, err = g.compose(spec, o.supersampled())
+		if err != nil {
+			return nil, err
+		}
+		return downsample(img, o.supersample), nil
+	}
+	span := o.startSpan("govatar.Compose", attribute.String("govatar.gender", genderName(spec.Gender)), attribute.Int("govatar.size", o.size))
+	defer func() { endSpan(span, err) }()
+	if err = o.context().Err(); err != nil {
+		return nil, err
+	}
+	if o.renderer != nil {
+		return composeRendered(spec, o)
+	}
+	names, layers, err := g.specLayers(spec, o)
+	if err != nil {
+		return nil, err
+	}
+	if err = g.describe(spec, o); err != nil {
+		return nil, err
+	}
+	tints := o.tintLayers(spec, names)
+	size := o.size
+	if o.pixelArt > 0 {
+		size = assetSize
+	}
+	if o.background {
+		img, err = g.store.render(o.context(), layers, tints, size, o.filter)
+		if err != nil {
+			return nil, err
+		}
+		return o.finish(o.pixelate(img)), nil
+	}
+	// The first layer is background
+	if tints != nil {
+		tints = tints[1:]
+	}
+	img, err = g.store.render(o.context(), layers[1:], tints, size, o.filter)
+	if err != nil {
+		return nil, err
+	}
+	img = o.pixelate(img)
+	if o.transparent {
+		return o.finish(img), nil
+	}
+	dst := image.NewRGBA(img.Bounds())
+	o.backdrop(dst, spec)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return o.finish(dst), nil
+}
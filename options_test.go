@@ -0,0 +1,82 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+func TestWithSize(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com", WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, 64, avatar.Bounds().Dx())
+	assert.Equal(t, 64, avatar.Bounds().Dy())
+
+	_, err = Generate(MALE, WithSize(0))
+	assert.Equal(t, errInvalidSize, err)
+	_, err = Generate(MALE, WithSize(maxSize+1))
+	assert.Equal(t, errInvalidSize, err)
+}
+
+func TestWithSeed(t *testing.T) {
+	a, err := Generate(FEMALE, WithSeed(42))
+	assert.NoError(t, err)
+	b, err := Generate(FEMALE, WithSeed(42))
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	// The seed replaces the username derived one
+	c, err := GenerateFromUsername(FEMALE, "username@site.com", WithSeed(42))
+	assert.NoError(t, err)
+	assert.Equal(t, a, c)
+}
+
+func TestBackgroundOptions(t *testing.T) {
+	// The corners only show background artwork
+	transparent, err := GenerateFromUsername(FEMALE, "username@site.com", WithTransparent())
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), color.NRGBAModel.Convert(transparent.At(0, 0)).(color.NRGBA).A)
+
+	white, err := GenerateFromUsername(FEMALE, "username@site.com", WithoutBackground())
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, color.RGBAModel.Convert(white.At(0, 0)))
+
+	spec, err := SpecFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	fromSpec, err := GenerateFromSpec(spec, WithTransparent())
+	assert.NoError(t, err)
+	assert.Equal(t, transparent, fromSpec)
+}
+
+func TestWithTransparentBackground(t *testing.T) {
+	transparent, err := GenerateFromUsername(FEMALE, "username@site.com", WithTransparent())
+	assert.NoError(t, err)
+	img, err := GenerateFromUsername(FEMALE, "username@site.com", WithTransparentBackground())
+	assert.NoError(t, err)
+	assert.Equal(t, transparent, img)
+
+	// Alpha survives encoding, JPEG shows the matte
+	for format, decode := range map[string]func(io.Reader) (image.Image, error){
+		"png":  png.Decode,
+		"webp": webp.Decode,
+		"jpeg": jpeg.Decode,
+	} {
+		b, err := GenerateBytesFromUsername(FEMALE, "username@site.com", format, WithTransparentBackground(), WithMatte(color.Black))
+		assert.NoError(t, err)
+		img, err := decode(bytes.NewReader(b))
+		assert.NoError(t, err, format)
+		c := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+		if format == "jpeg" {
+			assert.True(t, c.A == 0xff && c.R < 0x10 && c.G < 0x10 && c.B < 0x10, "%v", c)
+		} else {
+			assert.Equal(t, uint8(0), c.A, format)
+		}
+	}
+}
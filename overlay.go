@@ -0,0 +1,121 @@
+package govatar
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+var (
+	errInvalidOverlay = errors.New("Invalid overlay")
+	errOverlayExists  = errors.New("Overlay already registered")
+	errUnknownOverlay = errors.New("Unknown overlay")
+)
+
+// Overlay is a decoration drawn over finished avatars, e.g. a Santa hat in
+// December or confetti on a birthday
+type Overlay struct {
+	// Image is drawn over the whole avatar, scaled to its size
+	Image image.Image
+	// Active reports whether the overlay is drawn at a time, always if nil
+	Active func(t time.Time) bool
+}
+
+// overlay is a registered overlay picked for a single avatar
+type overlay struct {
+	name string
+	img  image.Image
+}
+
+var (
+	overlaysMu sync.RWMutex
+	overlays   = map[string]Overlay{}
+)
+
+// InMonths returns an Overlay.Active func drawing the overlay during months
+func InMonths(months ...time.Month) func(time.Time) bool {
+	return func(t time.Time) bool {
+		for _, m := range months {
+			if t.Month() == m {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RegisterOverlay makes ov available to WithOverlays by name. Names are
+// lowercase letters, digits, - and _.
+func RegisterOverlay(name string, ov Overlay) error {
+	if !validStyleName(name) || ov.Image == nil || ov.Image.Bounds().Empty() {
+		return errInvalidOverlay
+	}
+	overlaysMu.Lock()
+	defer overlaysMu.Unlock()
+	if _, ok := overlays[name]; ok {
+		return errOverlayExists
+	}
+	overlays[name] = ov
+	return nil
+}
+
+// Overlays returns sorted names of registered overlays
+func Overlays() []string {
+	overlaysMu.RLock()
+	defer overlaysMu.RUnlock()
+	names := make([]string, 0, len(overlays))
+	for name := range overlays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithOverlays draws the registered overlays names that are active at t
+// over the avatar in the given order, all registered overlays in name order
+// if names is empty. Pass the names that match a user, e.g. "confetti" on
+// their birthday. Overlays are drawn over the character and under frames,
+// badges and logos, and are cut to the shape. The avatar under them stays
+// the same.
+func WithOverlays(t time.Time, names ...string) Option {
+	return func(o *options) {
+		if len(names) == 0 {
+			names = Overlays()
+		}
+		overlaysMu.RLock()
+		defer overlaysMu.RUnlock()
+		o.overlays = nil
+		for _, name := range names {
+			ov, ok := overlays[name]
+			if !ok {
+				o.err = errUnknownOverlay
+				return
+			}
+			if ov.Active == nil || ov.Active(t) {
+				o.overlays = append(o.overlays, overlay{name: name, img: ov.Image})
+			}
+		}
+	}
+}
+
+// draw scales ov over img
+func (ov overlay) draw(img *image.RGBA) {
+	xdraw.CatmullRom.Scale(img, img.Bounds(), ov.img, ov.img.Bounds(), draw.Over, nil)
+}
+
+// svg writes ov as an SVG image covering the document
+func (ov overlay) svg(w *strings.Builder) error {
+	uri, err := pngDataURI(ov.img)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, `<image width="%d" height="%d" preserveAspectRatio="none" href="%s"/>`, svgGrid, svgGrid, uri)
+	return nil
+}
@@ -0,0 +1,74 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlays(t *testing.T) {
+	t.Cleanup(func() {
+		overlaysMu.Lock()
+		defer overlaysMu.Unlock()
+		delete(overlays, "santa")
+		delete(overlays, "dot")
+	})
+	// The hat covers the top half, the dot the top left quarter
+	hat := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(hat, image.Rect(0, 0, 10, 5), image.NewUniform(color.RGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+	dot := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(dot, image.Rect(0, 0, 5, 5), image.NewUniform(color.RGBA{0, 0, 0xff, 0xff}), image.Point{}, draw.Src)
+	assert.NoError(t, RegisterOverlay("santa", Overlay{Image: hat, Active: InMonths(time.December)}))
+	assert.NoError(t, RegisterOverlay("dot", Overlay{Image: dot}))
+	assert.Equal(t, errOverlayExists, RegisterOverlay("dot", Overlay{Image: dot}))
+	assert.Equal(t, errInvalidOverlay, RegisterOverlay("Dot", Overlay{Image: dot}))
+	assert.Equal(t, errInvalidOverlay, RegisterOverlay("empty", Overlay{}))
+	assert.Equal(t, []string{"dot", "santa"}, Overlays())
+
+	christmas := time.Date(2024, time.December, 24, 0, 0, 0, 0, time.UTC)
+	summer := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	plain, err := GenerateFromUsername(MALE, "username@site.com", WithSize(100))
+	assert.NoError(t, err)
+
+	img, err := GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithOverlays(christmas, "santa"))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, img.At(50, 10))
+	// The avatar under the overlay stays the same
+	assert.Equal(t, plain.At(50, 90), img.At(50, 90))
+
+	img, err = GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithOverlays(summer, "santa"))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(plain, img))
+
+	// All registered overlays in name order, the hat over the dot
+	img, err = GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithOverlays(christmas))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, img.At(10, 10))
+	img, err = GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithOverlays(summer))
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 0xff, 0xff}, img.At(10, 10))
+
+	// Overlays are cut to the shape
+	img, err = GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithOverlays(christmas, "santa"))
+	assert.NoError(t, err)
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+
+	svg, err := GenerateSVGFromUsername(MALE, "username@site.com", WithOverlays(christmas, "santa"))
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(svg, `preserveAspectRatio="none" href="data:image/png;base64,`))
+
+	o, err := std().options([]Option{WithOverlays(christmas, "santa")})
+	assert.NoError(t, err)
+	summerOptions, err := std().options([]Option{WithOverlays(summer, "santa")})
+	assert.NoError(t, err)
+	assert.NotEqual(t, std().cacheKey(MALE, "username", "png", o), std().cacheKey(MALE, "username", "png", summerOptions))
+
+	_, err = GenerateFromUsername(MALE, "username@site.com", WithOverlays(christmas, "pumpkin"))
+	assert.Equal(t, errUnknownOverlay, err)
+}
@@ -0,0 +1,440 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	// Decoders for source artwork
+	_ "image/gif"
+	_ "image/jpeg"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Names of files written to the pack directory by BuildPack
+const (
+	ManifestFile = "manifest.json"
+	PreviewFile  = "preview.png"
+)
+
+// previewColumns is the number of sample avatars per gender in the preview sheet
+const previewColumns = 6
+
+// Manifest describes the contents of an asset pack
+type Manifest struct {
+	// MappingVersion of the pack layout, see MappingVersion
+	MappingVersion int `json:"mappingVersion"`
+	// Size is width and height of every asset in pixels
+	Size int `json:"size"`
+	// Layers lists person layers in drawing order, background is drawn first.
+	// Generators loading the pack draw layers in this order, layers left out
+	// follow in their default order.
+	Layers []string `json:"layers"`
+	// Optional maps extra layers to the probability that avatars go without
+	// them. Accessory layers are left out of most avatars by default, other
+	// extra layers are always drawn.
+	Optional map[string]float64 `json:"optional,omitempty"`
+	// Rules keep parts apart that look broken together, see Rule
+	Rules []Rule `json:"rules,omitempty"`
+	// Weights maps the directory of a layer and file name to the weight of
+	// a part in random avatars, e.g. a part of weight 0.1 is picked a tenth
+	// as often as one of the default weight 1. Weight 0 leaves a part to
+	// explicit specs. Directories are slash separated, e.g. "male/hair" or
+	// "background"; packs of a style added with Register name layers alone.
+	Weights map[string]map[string]float64 `json:"weights,omitempty"`
+	// Dependent maps extra layers to the layer whose parts pull in their
+	// parts, e.g. "hairback": "hair" draws hairback/hair3.png with
+	// hair/hair3.png. Dependent layers are never picked on their own and
+	// drawn right behind their parent layer unless Layers says otherwise.
+	Dependent map[string]string `json:"dependent,omitempty"`
+	// Background lists background assets in selection order
+	Background []string `json:"background"`
+	// Genders maps gender and layer to assets in selection order
+	Genders map[string]map[string][]string `json:"genders"`
+	// License of the artwork, kept by BuildPack when rebuilding
+	License *License `json:"license,omitempty"`
+}
+
+// License describes terms the pack artwork is distributed under
+type License struct {
+	// Name is the license name or SPDX identifier, e.g. CC-BY-4.0
+	Name string `json:"name"`
+	// URL points to the license text
+	URL string `json:"url,omitempty"`
+	// Attribution is the credit line required by the license
+	Attribution string `json:"attribution,omitempty"`
+}
+
+// Pack is an asset pack built by BuildPack
+type Pack struct {
+	dir      string
+	manifest Manifest
+}
+
+// OpenPack reads the manifest of the pack in dir
+func OpenPack(dir string) (*Pack, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	p := &Pack{dir: dir}
+	if err = json.Unmarshal(data, &p.manifest); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Dir returns the pack directory
+func (p *Pack) Dir() string {
+	return p.dir
+}
+
+// Manifest returns the pack manifest
+func (p *Pack) Manifest() Manifest {
+	return p.manifest
+}
+
+// License returns the artwork license. ok is false if the pack declares none.
+func (p *Pack) License() (license License, ok bool) {
+	if p.manifest.License == nil {
+		return License{}, false
+	}
+	return *p.manifest.License, true
+}
+
+// AssetsLicense returns the license of the configured assets if they are a
+// pack declaring one
+func AssetsLicense() (license License, ok bool) {
+	return std().License()
+}
+
+// License returns the license of the assets of g if they are a pack
+// declaring one
+func (g *Generator) License() (license License, ok bool) {
+	if g.store.license == nil {
+		return License{}, false
+	}
+	return *g.store.license, true
+}
+
+// PackProblems lists everything wrong with an asset pack
+type PackProblems []string
+
+func (p PackProblems) Error() string {
+	return strings.Join(p, "\n")
+}
+
+// BuildPack turns a folder of artwork laid out like the data directory
+// (background and <gender>/<layer> subdirectories) into a ready pack in
+// place. Every asset is checked, scaled to 400x400, converted from its
+// embedded color profile to sRGB and written back as png. Source files in
+// other formats are replaced by the png, nothing else in dir is removed.
+// Assets are ordered naturally by file name and the result is described in
+// manifest.json next to a preview.png sheet of sample avatars. License of an
+// existing manifest is kept.
+//
+// Subdirectories of genders beyond face, clothes, mouth, hair and eye are
+// extra layers. Layer order and optional layers of an existing manifest are
+// kept as well.
+// If anything is wrong, nothing is written and PackProblems lists all issues.
+// Files are written to temporary files first and renamed once all of them
+// are written.
+func BuildPack(dir string) (*Manifest, error) {
+	return BuildPackTo(dir, dir)
+}
+
+// BuildPackTo builds the pack of the artwork in dir like BuildPack, but
+// writes it to out and leaves dir as it is. out is created if missing.
+func BuildPackTo(dir, out string) (*Manifest, error) {
+	var problems PackProblems
+	m := &Manifest{
+		MappingVersion: MappingVersion,
+		Size:           assetSize,
+		Layers:         personLayers,
+		Genders:        make(map[string]map[string][]string),
+	}
+
+	images := make(map[string]image.Image)
+	// sources holds the files assets were read from by asset path
+	sources := make(map[string]string)
+	load := func(layerDir string) []string {
+		names, files, imgs, errs := loadPackLayer(filepath.Join(dir, layerDir))
+		for _, err := range errs {
+			problems = append(problems, err.Error())
+		}
+		for i, name := range names {
+			images[filepath.Join(layerDir, name)] = imgs[i]
+			sources[filepath.Join(layerDir, name)] = filepath.Join(layerDir, files[i])
+		}
+		return names
+	}
+
+	previous, err := OpenPack(dir)
+	if err == nil {
+		m.Layers = previous.manifest.Layers
+		m.Optional = previous.manifest.Optional
+		m.Rules = previous.manifest.Rules
+		m.Dependent = previous.manifest.Dependent
+		m.Weights = previous.manifest.Weights
+		m.License = previous.manifest.License
+	}
+	m.Background = load("background")
+	all := make(map[string][]string)
+	var extras []string
+	for _, g := range []Gender{MALE, FEMALE, MONSTER} {
+		genderExtras := extraLayers(dirSource{}, filepath.Join(dir, genderName(g)))
+		layers := make(map[string][]string)
+		for _, layer := range append(append([]string(nil), personLayers...), genderExtras...) {
+			layers[layer] = load(filepath.Join(genderName(g), layer))
+			all[layer] = layers[layer]
+		}
+		extras = append(extras, genderExtras...)
+		m.Genders[genderName(g)] = layers
+	}
+	problems = append(problems, checkRules(m)...)
+	problems = append(problems, checkDependents(m)...)
+	problems = append(problems, checkWeights(m)...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	var independent []string
+	dependent := map[string]string{}
+	for _, layer := range extras {
+		if parent := m.Dependent[layer]; parent != "" {
+			dependent[layer] = parent
+		} else {
+			independent = append(independent, layer)
+		}
+	}
+	m.Layers = placeDependents(orderLayers(append(append(append([]string(nil), m.Layers...), personLayers...), independent...), all), dependent)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var w packWriter
+	for path, img := range images {
+		err = w.stage(filepath.Join(out, path), func(f io.Writer) error { return png.Encode(f, img) })
+		if err != nil {
+			w.abort()
+			return nil, err
+		}
+	}
+	err = w.stage(filepath.Join(out, ManifestFile), func(f io.Writer) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err == nil {
+		err = w.stage(filepath.Join(out, PreviewFile), func(f io.Writer) error { return png.Encode(f, previewSheet(m, images)) })
+	}
+	if err != nil {
+		w.abort()
+		return nil, err
+	}
+	if err = w.commit(); err != nil {
+		return nil, err
+	}
+	if filepath.Clean(dir) != filepath.Clean(out) {
+		return m, nil
+	}
+	// Remove sources replaced by a png of another name
+	for path, source := range sources {
+		if source != path {
+			if err := os.Remove(filepath.Join(dir, source)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// packWriter writes the files of a pack to temporary files first, so a
+// failed build leaves the pack as it was
+type packWriter struct {
+	// staged maps temporary files to the files they become
+	staged map[string]string
+}
+
+// stage writes the file at path with write to a temporary file next to it
+func (w *packWriter) stage(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".govatar-build-")
+	if err != nil {
+		return err
+	}
+	if w.staged == nil {
+		w.staged = map[string]string{}
+	}
+	w.staged[f.Name()] = path
+	if err = write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(f.Name(), 0644)
+}
+
+// commit renames the temporary files to the files they become
+func (w *packWriter) commit() error {
+	for tmp, path := range w.staged {
+		if err := os.Rename(tmp, path); err != nil {
+			w.abort()
+			return err
+		}
+		delete(w.staged, tmp)
+	}
+	return nil
+}
+
+// abort removes the temporary files
+func (w *packWriter) abort() {
+	for tmp := range w.staged {
+		os.Remove(tmp)
+	}
+	w.staged = nil
+}
+
+// checkRules lists problems of the rules of m: invalid selectors and parts
+// no gender has
+func checkRules(m *Manifest) (problems []string) {
+	for i, r := range m.Rules {
+		parsed, err := parseRules([]Rule{r})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: rule %d: %v", ManifestFile, i+1, err))
+			continue
+		}
+		for _, s := range append([]selector{parsed[0].part}, parsed[0].excludes...) {
+			found := false
+			for _, layers := range m.Genders {
+				found = found || len(layers[s.layer]) > 0 && s.to < len(layers[s.layer])
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("%s: rule %d: no gender has the parts of %s", ManifestFile, i+1, s.layer))
+			}
+		}
+	}
+	return problems
+}
+
+// loadPackLayer decodes and normalizes all images of a layer directory.
+// Returned names are png file names in natural order, files the names of
+// the source files they were read from.
+func loadPackLayer(dir string) (names, files []string, imgs []image.Image, problems []error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, []error{fmt.Errorf("%s: missing layer directory", dir)}
+	}
+	byName := make(map[string]image.Image)
+	fileOf := make(map[string]string)
+	for _, file := range entries {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		img, err := decodeAsset(path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", path, err))
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())) + ".png"
+		if _, ok := byName[name]; ok {
+			problems = append(problems, fmt.Errorf("%s: duplicates %s", path, name))
+			continue
+		}
+		byName[name] = img
+		fileOf[name] = file.Name()
+		names = append(names, name)
+	}
+	if len(names) == 0 && len(problems) == 0 {
+		problems = append(problems, fmt.Errorf("%s: no assets", dir))
+	}
+	sort.Sort(naturalSort(names))
+	for _, name := range names {
+		files = append(files, fileOf[name])
+		imgs = append(imgs, byName[name])
+	}
+	return names, files, imgs, problems
+}
+
+// decodeAsset reads a square image, converts it from its embedded color
+// profile to sRGB and scales it to asset size
+func decodeAsset(path string) (image.Image, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	if b.Dx() != b.Dy() {
+		return nil, fmt.Errorf("image is %dx%d, must be square", b.Dx(), b.Dy())
+	}
+	profile, err := embeddedProfile(data, format)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		icc, err := parseICC(profile)
+		if err != nil {
+			return nil, err
+		}
+		if icc != nil {
+			src = icc.convert(src)
+		}
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, assetSize, assetSize))
+	if b.Dx() == assetSize {
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	}
+	return dst, nil
+}
+
+// previewSheet draws a row of sample avatars per gender
+func previewSheet(m *Manifest, images map[string]image.Image) image.Image {
+	const cell = assetSize / 4
+	genders := []Gender{MALE, FEMALE, MONSTER}
+	sheet := image.NewNRGBA(image.Rect(0, 0, previewColumns*cell, len(genders)*cell))
+	for row, g := range genders {
+		name := genderName(g)
+		for col := 0; col < previewColumns; col++ {
+			avatar := image.NewNRGBA(image.Rect(0, 0, assetSize, assetSize))
+			bg := m.Background[col%len(m.Background)]
+			draw.Draw(avatar, avatar.Bounds(), images[filepath.Join("background", bg)], image.Point{}, draw.Over)
+			for _, layer := range m.Layers {
+				assets := m.Genders[name][layer]
+				if parent := m.Dependent[layer]; parent != "" {
+					// Drawn with the part of the parent layer
+					assets = m.Genders[name][parent]
+				}
+				if len(assets) == 0 {
+					continue
+				}
+				path := filepath.Join(name, layer, assets[col%len(assets)])
+				if img, ok := images[path]; ok {
+					draw.Draw(avatar, avatar.Bounds(), img, image.Point{}, draw.Over)
+				}
+			}
+			r := image.Rect(col*cell, row*cell, (col+1)*cell, (row+1)*cell)
+			xdraw.CatmullRom.Scale(sheet, r, avatar, avatar.Bounds(), draw.Src, nil)
+		}
+	}
+	return sheet
+}
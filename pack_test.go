@@ -0,0 +1,253 @@
+package govatar
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPack(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+
+	// Source artwork in other sizes and formats is normalized
+	writeTestImage(t, filepath.Join(dir, "male", "hair", "hair10.png"), 200)
+	f, err := os.Create(filepath.Join(dir, "male", "hair", "hair2.jpg"))
+	assert.NoError(t, err)
+	assert.NoError(t, jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, 100, 100)), nil))
+	f.Close()
+	// Extra layers are picked up from their directories
+	writeTestImage(t, filepath.Join(dir, "male", "tattoo", "tattoo1.png"), 400)
+
+	m, err := BuildPack(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hair1.png", "hair2.png", "hair10.png"}, m.Genders["male"]["hair"])
+	assert.Equal(t, append(personLayers, "tattoo"), m.Layers)
+	assert.Equal(t, []string{"tattoo1.png"}, m.Genders["male"]["tattoo"])
+	assert.Equal(t, []string{"background1.png"}, m.Background)
+
+	_, err = os.Stat(filepath.Join(dir, "male", "hair", "hair2.jpg"))
+	assert.True(t, os.IsNotExist(err))
+	for _, name := range m.Genders["male"]["hair"] {
+		f, err := os.Open(filepath.Join(dir, "male", "hair", name))
+		assert.NoError(t, err)
+		cfg, _, err := image.DecodeConfig(f)
+		f.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, 400, cfg.Width)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	assert.NoError(t, err)
+	var decoded Manifest
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *m, decoded)
+
+	_, err = os.Stat(filepath.Join(dir, PreviewFile))
+	assert.NoError(t, err)
+
+	// The built pack is usable as assets path
+	c := DefaultConfig()
+	c.AssetsPath = dir
+	assert.NoError(t, c.Validate())
+}
+
+func TestBuildPackTo(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+	writeTestImage(t, filepath.Join(dir, "male", "hair", "hair10.png"), 200)
+	f, err := os.Create(filepath.Join(dir, "male", "hair", "hair2.jpg"))
+	assert.NoError(t, err)
+	assert.NoError(t, jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, 100, 100)), nil))
+	f.Close()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "male", "hair", ".notes"), []byte("todo"), 0644))
+
+	out := filepath.Join(dir+"-out", "pack")
+	defer os.RemoveAll(dir + "-out")
+	m, err := BuildPackTo(dir, out)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hair1.png", "hair2.png", "hair10.png"}, m.Genders["male"]["hair"])
+	c := DefaultConfig()
+	c.AssetsPath = out
+	assert.NoError(t, c.Validate())
+
+	// The artwork is left as it is
+	_, err = os.Stat(filepath.Join(dir, "male", "hair", "hair2.jpg"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, ManifestFile))
+	assert.True(t, os.IsNotExist(err))
+	f, err = os.Open(filepath.Join(dir, "male", "hair", "hair10.png"))
+	assert.NoError(t, err)
+	cfg, _, err := image.DecodeConfig(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 200, cfg.Width)
+
+	// Builds in place replace only the converted sources
+	_, err = BuildPack(dir)
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "male", "hair", "hair2.jpg"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "male", "hair", ".notes"))
+	assert.NoError(t, err)
+}
+
+func TestBuildPackFailedWrite(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+	out, err := ioutil.TempDir("", "govatar-pack-out")
+	assert.NoError(t, err)
+	defer os.RemoveAll(out)
+	// A file in the way of a layer directory fails the build half way
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(out, "male"), nil, 0644))
+
+	_, err = BuildPackTo(dir, out)
+	assert.Error(t, err)
+	var written []string
+	assert.NoError(t, filepath.Walk(out, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			written = append(written, path)
+		}
+		return err
+	}))
+	assert.Equal(t, []string{filepath.Join(out, "male")}, written)
+}
+
+func TestPackLicense(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+
+	_, err := OpenPack(dir)
+	assert.Error(t, err)
+
+	_, err = BuildPack(dir)
+	assert.NoError(t, err)
+	p, err := OpenPack(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, dir, p.Dir())
+	_, ok := p.License()
+	assert.False(t, ok)
+
+	// License added by the author survives rebuilding
+	m := p.Manifest()
+	m.License = &License{Name: "CC-BY-4.0", Attribution: "Artwork by Jane"}
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	_, err = BuildPack(dir)
+	assert.NoError(t, err)
+
+	p, err = OpenPack(dir)
+	assert.NoError(t, err)
+	license, ok := p.License()
+	assert.True(t, ok)
+	assert.Equal(t, License{Name: "CC-BY-4.0", Attribution: "Artwork by Jane"}, license)
+
+	defer Configure(DefaultConfig())
+	c := DefaultConfig()
+	c.AssetsPath = dir
+	assert.NoError(t, Configure(c))
+	license, ok = AssetsLicense()
+	assert.True(t, ok)
+	assert.Equal(t, "CC-BY-4.0", license.Name)
+
+	// Generators of file systems read the manifest of the file system
+	g, err := NewFromFS(os.DirFS(dir))
+	assert.NoError(t, err)
+	license, ok = g.License()
+	assert.True(t, ok)
+	assert.Equal(t, "CC-BY-4.0", license.Name)
+}
+
+func TestPackRules(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+	m, err := BuildPack(dir)
+	assert.NoError(t, err)
+
+	// Rules survive rebuilding, rules on missing parts are problems
+	m.Rules = []Rule{{Part: "hair:0", Excludes: []string{"mouth"}}}
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	m, err = BuildPack(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []Rule{{Part: "hair:0", Excludes: []string{"mouth"}}}, m.Rules)
+
+	m.Rules = append(m.Rules, Rule{Part: "hat", Excludes: []string{"hair:1"}}, Rule{Part: "hair:", Excludes: []string{"eye"}})
+	data, err = json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	_, err = BuildPack(dir)
+	problems, ok := err.(PackProblems)
+	assert.True(t, ok)
+	assert.Len(t, problems, 3)
+	assert.Contains(t, err.Error(), "rule 2: no gender has the parts of hat")
+	assert.Contains(t, err.Error(), "rule 2: no gender has the parts of hair")
+	assert.Contains(t, err.Error(), "rule 3: Invalid rule")
+}
+
+func TestBuildPackProblems(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.RemoveAll(filepath.Join(dir, "female", "eye")))
+	writeTestImage(t, filepath.Join(dir, "male", "face", "face2.png"), 0)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "male", "face", "notes.txt"), []byte("todo"), 0644))
+
+	_, err := BuildPack(dir)
+	problems, ok := err.(PackProblems)
+	assert.True(t, ok)
+	assert.Len(t, problems, 3)
+	assert.Contains(t, err.Error(), filepath.Join("female", "eye")+": missing layer directory")
+	assert.Contains(t, err.Error(), "must be square")
+	assert.Contains(t, err.Error(), "notes.txt")
+
+	_, err = os.Stat(filepath.Join(dir, ManifestFile))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// newTestPack creates a pack with one 400x400 asset per layer
+func newTestPack(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "govatar-pack")
+	assert.NoError(t, err)
+	writeTestImage(t, filepath.Join(dir, "background", "background1.png"), 400)
+	for _, g := range []Gender{MALE, FEMALE, MONSTER} {
+		for _, layer := range personLayers {
+			writeTestImage(t, filepath.Join(dir, genderName(g), layer, layer+"1.png"), 400)
+		}
+	}
+	return dir
+}
+
+// writeTestImage writes a size by size png, or a non square one if size is 0
+func writeTestImage(t *testing.T, path string, size int) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	r := image.Rect(0, 0, size, size)
+	if size == 0 {
+		r = image.Rect(0, 0, 40, 20)
+	}
+	img := image.NewNRGBA(r)
+	img.SetNRGBA(0, 0, color.NRGBA{0xff, 0, 0, 0xff})
+	assert.NoError(t, writePNG(path, img))
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
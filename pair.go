@@ -0,0 +1,43 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Pair holds two variants of one avatar, for light and for dark UI themes
+type Pair struct {
+	Light image.Image
+	Dark  image.Image
+}
+
+// GeneratePairFromUsername generates light and dark variants of the avatar
+// from string. Both share every part: the background is a light or a dark
+// tone of the same hue and the character gets a thin outline contrasting
+// with it, so dark hair stays visible on dark backgrounds and vice versa.
+func GeneratePairFromUsername(gender Gender, username string) (Pair, error) {
+	img, err := GenerateFromUsername(gender, username, WithTransparent())
+	if err != nil {
+		return Pair{}, err
+	}
+	character := image.NewNRGBA(img.Bounds())
+	draw.Draw(character, character.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	hue := NewScheme(usernameSeed(username)).Hue
+	return Pair{
+		Light: themed(character, hslToRGB(hue, 0.35, 0.88), hslToRGB(hue, 0.3, 0.3)),
+		Dark:  themed(character, hslToRGB(hue, 0.3, 0.16), hslToRGB(hue, 0.25, 0.8)),
+	}, nil
+}
+
+// themed draws character with an outline over a solid background
+func themed(character *image.NRGBA, background, outline color.Color) *image.NRGBA {
+	bounds := character.Bounds()
+	dst := image.NewNRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(background), image.Point{}, draw.Src)
+	contour := dilateAlpha(character, bounds.Dx()/100+1)
+	draw.DrawMask(dst, bounds, image.NewUniform(outline), image.Point{}, &image.Alpha{Pix: contour, Stride: bounds.Dx(), Rect: bounds}, bounds.Min, draw.Over)
+	draw.Draw(dst, bounds, character, bounds.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,28 @@
+package govatar
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePairFromUsername(t *testing.T) {
+	pair, err := GeneratePairFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, pair.Light.Bounds().Dx())
+	assert.Equal(t, 400, pair.Dark.Bounds().Dx())
+
+	// Corners show the background
+	light := color.NRGBAModel.Convert(pair.Light.At(0, 0)).(color.NRGBA)
+	dark := color.NRGBAModel.Convert(pair.Dark.At(0, 0)).(color.NRGBA)
+	assert.True(t, luma(light) > 180)
+	assert.True(t, luma(dark) < 60)
+
+	again, err := GeneratePairFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, pair, again)
+
+	_, err = GeneratePairFromUsername(Gender(100), "username@site.com")
+	assert.Equal(t, errUnknownGender, err)
+}
@@ -0,0 +1,57 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+var errInvalidPixelArt = errors.New("Invalid pixel art resolution")
+
+// WithPixelArt draws the avatar as pixel art of resolution by resolution
+// flat pixels, scaled to the avatar size with hard edges whatever the
+// filter. Every pixel is the average color of the area it covers and is
+// either opaque or transparent. 20 matches the grid of the bundled artwork,
+// smaller resolutions look more retro.
+func WithPixelArt(resolution int) Option {
+	return func(o *options) {
+		o.pixelArt = resolution
+		if resolution < 1 || resolution > assetSize {
+			o.err = errInvalidPixelArt
+		}
+	}
+}
+
+// pixelate averages img over a resolution by resolution grid and scales the
+// grid up to size with nearest neighbor
+func pixelate(img image.Image, resolution, size int) *image.RGBA {
+	b := img.Bounds()
+	cells := make([]color.RGBA, resolution*resolution)
+	for cy := 0; cy < resolution; cy++ {
+		y0, y1 := b.Min.Y+cy*b.Dy()/resolution, b.Min.Y+(cy+1)*b.Dy()/resolution
+		for cx := 0; cx < resolution; cx++ {
+			x0, x1 := b.Min.X+cx*b.Dx()/resolution, b.Min.X+(cx+1)*b.Dx()/resolution
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := img.At(x, y).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			if n == 0 || a < n*0x8000 {
+				continue
+			}
+			// Unpremultiply to an opaque pixel
+			cells[cy*resolution+cx] = color.RGBA{uint8(r * 0xff / a), uint8(g * 0xff / a), uint8(bl * 0xff / a), 0xff}
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		row := cells[y*resolution/size*resolution:]
+		for x := 0; x < size; x++ {
+			dst.SetRGBA(x, y, row[x*resolution/size])
+		}
+	}
+	return dst
+}
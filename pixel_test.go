@@ -0,0 +1,65 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPixelArt(t *testing.T) {
+	img, err := GenerateFromUsername(MALE, "username@site.com", WithPixelArt(10), WithSize(100))
+	assert.NoError(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+	// Every 10x10 block is a single color
+	for y := 0; y < 100; y += 10 {
+		for x := 0; x < 100; x += 10 {
+			c := img.At(x, y)
+			assert.Equal(t, c, img.At(x+9, y+9))
+			assert.Equal(t, c, img.At(x+5, y+3))
+		}
+	}
+	// The filter does not soften the edges
+	lanczos, err := GenerateFromUsername(MALE, "username@site.com", WithPixelArt(10), WithSize(100), WithFilter(LANCZOS))
+	assert.NoError(t, err)
+	assert.Equal(t, img, lanczos)
+
+	transparent, err := GenerateFromUsername(FEMALE, "username@site.com", WithPixelArt(20), WithSize(40), WithTransparent())
+	assert.NoError(t, err)
+	pix := transparent.(*image.RGBA).Pix
+	for i := 3; i < len(pix); i += 4 {
+		assert.True(t, pix[i] == 0 || pix[i] == 0xff)
+	}
+
+	d, err := Describe(Spec{Gender: FEMALE, Hair: 1})
+	assert.NoError(t, err)
+	e, err := NewEditor(d, WithPixelArt(16), WithSize(64))
+	assert.NoError(t, err)
+	expected, err := RenderDescriptor(d, WithPixelArt(16), WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, e.Image())
+
+	key := std().cacheKey(MALE, "username", "png", options{pixelArt: 20})
+	assert.NotEqual(t, std().cacheKey(MALE, "username", "png", options{}), key)
+
+	for _, resolution := range []int{0, -1, assetSize + 1} {
+		_, err = GenerateFromUsername(MALE, "username", WithPixelArt(resolution))
+		assert.Equal(t, errInvalidPixelArt, err)
+	}
+}
+
+func TestPixelate(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	// Top left cell half red half blue, top right cell mostly transparent
+	src.SetRGBA(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	src.SetRGBA(1, 0, color.RGBA{0xff, 0, 0, 0xff})
+	src.SetRGBA(0, 1, color.RGBA{0, 0, 0xff, 0xff})
+	src.SetRGBA(1, 1, color.RGBA{0, 0, 0xff, 0xff})
+	src.SetRGBA(2, 0, color.RGBA{0, 0xff, 0, 0xff})
+	dst := pixelate(src, 2, 6)
+	assert.Equal(t, color.RGBA{0x7f, 0, 0x7f, 0xff}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{0x7f, 0, 0x7f, 0xff}, dst.RGBAAt(2, 2))
+	assert.Equal(t, color.RGBA{}, dst.RGBAAt(3, 0))
+	assert.Equal(t, color.RGBA{}, dst.RGBAAt(5, 5))
+}
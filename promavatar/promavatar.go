@@ -0,0 +1,85 @@
+// Package promavatar exports metrics of an httpavatar.Handler to Prometheus.
+//
+//	metrics := promavatar.New()
+//	prometheus.MustRegister(metrics)
+//	handler := httpavatar.New(httpavatar.Options{Metrics: metrics})
+package promavatar
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector counts avatar requests, renders and cache lookups. It implements
+// httpavatar.Metrics and prometheus.Collector.
+type Collector struct {
+	requests *prometheus.CounterVec
+	renders  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	cache    *prometheus.CounterVec
+}
+
+// New returns a collector of metrics named govatar_*
+func New() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "govatar_requests_total",
+			Help: "Avatar requests by format and status code.",
+		}, []string{"format", "code"}),
+		renders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "govatar_renders_total",
+			Help: "Rendered avatars by format and result.",
+		}, []string{"format", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "govatar_render_duration_seconds",
+			Help:    "Time to draw and encode an avatar.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+		}, []string{"format"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "govatar_cache_lookups_total",
+			Help: "Avatar cache lookups by result.",
+		}, []string{"result"}),
+	}
+}
+
+// Served implements httpavatar.Metrics
+func (c *Collector) Served(format string, status int) {
+	c.requests.WithLabelValues(format, strconv.Itoa(status)).Inc()
+}
+
+// Rendered implements httpavatar.Metrics
+func (c *Collector) Rendered(format string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	c.renders.WithLabelValues(format, result).Inc()
+	c.duration.WithLabelValues(format).Observe(duration.Seconds())
+}
+
+// CacheLookup implements httpavatar.Metrics
+func (c *Collector) CacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	c.cache.WithLabelValues(result).Inc()
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.renders.Describe(ch)
+	c.duration.Describe(ch)
+	c.cache.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.renders.Collect(ch)
+	c.duration.Collect(ch)
+	c.cache.Collect(ch)
+}
@@ -0,0 +1,49 @@
+package promavatar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/recoilme/govatar"
+	"github.com/recoilme/govatar/httpavatar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	c := govatar.DefaultConfig()
+	c.Size = 64
+	c.AssetsPath = "../data"
+	g, err := govatar.New(c)
+	assert.NoError(t, err)
+
+	metrics := New()
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(metrics))
+	h := httpavatar.New(httpavatar.Options{Generator: g, Metrics: metrics})
+	for _, target := range []string{"/avatar/a.png", "/avatar/a.png", "/avatar/b.webp", "/avatar/a.png?s=0"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	expected := `
+# HELP govatar_cache_lookups_total Avatar cache lookups by result.
+# TYPE govatar_cache_lookups_total counter
+govatar_cache_lookups_total{result="hit"} 1
+govatar_cache_lookups_total{result="miss"} 2
+# HELP govatar_renders_total Rendered avatars by format and result.
+# TYPE govatar_renders_total counter
+govatar_renders_total{format="png",result="ok"} 1
+govatar_renders_total{format="webp",result="ok"} 1
+# HELP govatar_requests_total Avatar requests by format and status code.
+# TYPE govatar_requests_total counter
+govatar_requests_total{code="200",format="png"} 2
+govatar_requests_total{code="200",format="webp"} 1
+govatar_requests_total{code="400",format="png"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"govatar_cache_lookups_total", "govatar_renders_total", "govatar_requests_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics, "govatar_render_duration_seconds"))
+}
@@ -0,0 +1,50 @@
+package govatar
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+)
+
+var errGenderNotEmbedded = errors.New("Gender not embedded")
+
+var (
+	embeddedOnce  sync.Once
+	embeddedStore *store
+)
+
+// GenerateFromSeed generates avatar as a pure function of gender and seed.
+// It draws assets embedded in the binary at their original size and ignores
+// Configure, so it reads neither the filesystem nor the clock and the same
+// arguments always yield the same pixels. Genders left out by build tags
+// return an error.
+func GenerateFromSeed(gender Gender, seed int64) (image.Image, error) {
+	if genderName(gender) == "" {
+		return nil, errUnknownGender
+	}
+	embedded := embeddedGenders[gender]
+	if gender == NEUTRAL {
+		embedded = embeddedGenders[MALE] && embeddedGenders[FEMALE]
+	}
+	if !embedded {
+		return nil, errGenderNotEmbedded
+	}
+	embeddedOnce.Do(func() {
+		embeddedStore = loadStore(fsSource{embeddedAssets}, "data")
+	})
+	spec, err := embeddedStore.randomSpec(gender, seed)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := embeddedStore.specAssets(spec)
+	if err != nil {
+		return nil, err
+	}
+	return embeddedStore.render(context.Background(), layers, nil, assetSize, CatmullRom)
+}
+
+// SeedFromUsername returns the seed GenerateFromUsername derives from username
+func SeedFromUsername(username string) int64 {
+	return usernameSeed(username)
+}
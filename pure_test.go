@@ -0,0 +1,33 @@
+package govatar
+
+import (
+	"testing"
+
+	"github.com/recoilme/govatar/imgdiff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFromSeed(t *testing.T) {
+	for _, g := range []Gender{MALE, FEMALE, MONSTER, NEUTRAL} {
+		if !embeddedGenders[g] && g != NEUTRAL {
+			_, err := GenerateFromSeed(g, 1)
+			assert.Equal(t, errGenderNotEmbedded, err)
+			continue
+		}
+		img, err := GenerateFromSeed(g, SeedFromUsername("username@site.com"))
+		assert.NoError(t, err)
+		again, err := GenerateFromSeed(g, SeedFromUsername("username@site.com"))
+		assert.NoError(t, err)
+		assert.Equal(t, img, again)
+
+		// Embedded assets match the data directory
+		expected, err := GenerateFromUsername(g, "username@site.com")
+		assert.NoError(t, err)
+		result, err := imgdiff.Compare(expected, img, 0)
+		assert.NoError(t, err)
+		assert.True(t, result.Equal())
+	}
+
+	_, err := GenerateFromSeed(Gender(100), 1)
+	assert.Equal(t, errUnknownGender, err)
+}
@@ -0,0 +1,51 @@
+package govatar
+
+import (
+	"image"
+	"image/draw"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Corner represents a corner of the avatar
+type Corner int
+
+// Avatar corners
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// EmbedQRCode returns a copy of img with a QR code encoding content drawn
+// in the given corner. The code takes about a quarter of the image width.
+func EmbedQRCode(img image.Image, content string, corner Corner) (image.Image, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	code := q.Image(bounds.Dx() / 4)
+
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	draw.Draw(dst, cornerRect(bounds, code.Bounds().Size(), corner), code, code.Bounds().Min, draw.Src)
+	return dst, nil
+}
+
+// cornerRect returns a rectangle of the given size placed in the corner of bounds
+func cornerRect(bounds image.Rectangle, size image.Point, corner Corner) image.Rectangle {
+	var min image.Point
+	switch corner {
+	case TopRight:
+		min = image.Pt(bounds.Max.X-size.X, bounds.Min.Y)
+	case BottomLeft:
+		min = image.Pt(bounds.Min.X, bounds.Max.Y-size.Y)
+	case BottomRight:
+		min = bounds.Max.Sub(size)
+	default:
+		min = bounds.Min
+	}
+	return image.Rectangle{Min: min, Max: min.Add(size)}
+}
@@ -0,0 +1,41 @@
+package govatar
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbedQRCode(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+
+	for _, corner := range []Corner{TopLeft, TopRight, BottomLeft, BottomRight} {
+		img, err := EmbedQRCode(avatar, "https://site.com/u/username", corner)
+		assert.NoError(t, err)
+		assert.Equal(t, avatar.Bounds(), img.Bounds())
+
+		r := cornerRect(img.Bounds(), image.Pt(100, 100), corner)
+		assert.False(t, areImagesEquals(subImage(avatar, r), subImage(img, r)))
+	}
+
+	_, err = EmbedQRCode(avatar, strings.Repeat("x", 5000), TopLeft)
+	assert.Error(t, err)
+}
+
+func TestCornerRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 400, 400)
+	size := image.Pt(100, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 100), cornerRect(bounds, size, TopLeft))
+	assert.Equal(t, image.Rect(300, 0, 400, 100), cornerRect(bounds, size, TopRight))
+	assert.Equal(t, image.Rect(0, 300, 100, 400), cornerRect(bounds, size, BottomLeft))
+	assert.Equal(t, image.Rect(300, 300, 400, 400), cornerRect(bounds, size, BottomRight))
+}
+
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	return img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}).SubImage(r)
+}
@@ -0,0 +1,168 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+	"sync"
+)
+
+var (
+	errUnknownRenderer = errors.New("Unknown renderer")
+	errRendererSVG     = errors.New("Renderer does not draw SVG")
+)
+
+// DefaultRenderer is the name of the built-in layered asset renderer
+const DefaultRenderer = "default"
+
+// Renderer produces avatars in three steps. Third party packages implement it
+// to add new avatar styles and make them available with RegisterRenderer.
+type Renderer interface {
+	// SelectParts deterministically picks parts of the avatar for seed.
+	// Renderers give the indices of Spec the meaning they need.
+	SelectParts(gender Gender, seed int64) (Spec, error)
+	// Compose draws the avatar described by spec
+	Compose(spec Spec) (image.Image, error)
+	// Encode writes img to w in format given as file extension (.png, .jpg, ...)
+	Encode(w io.Writer, img image.Image, format string) error
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Renderer)
+)
+
+func init() {
+	RegisterRenderer(DefaultRenderer, layerRenderer{})
+}
+
+// RegisterRenderer makes a renderer available by name, usually from the init
+// function of the package implementing it. It panics if r is nil or name is
+// already registered.
+func RegisterRenderer(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if r == nil {
+		panic("govatar: RegisterRenderer renderer is nil")
+	}
+	if _, dup := renderers[name]; dup {
+		panic("govatar: RegisterRenderer called twice for renderer " + name)
+	}
+	renderers[name] = r
+}
+
+// LookupRenderer returns the renderer registered by name
+func LookupRenderer(name string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// Renderers returns sorted names of registered renderers
+func Renderers() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithRenderer draws the avatar with the registered renderer name instead
+// of the layered artwork of the generator. Size, seed, pixel art, shape,
+// overlays, frames, badges and logos apply to every renderer, options
+// changing the artwork to the default one only. Avatars of other renderers
+// are encoded by the renderer and have no SVG, descriptor or traits.
+func WithRenderer(name string) Option {
+	return func(o *options) {
+		r, ok := LookupRenderer(name)
+		if !ok {
+			o.err = errUnknownRenderer
+			return
+		}
+		o.renderer, o.rendererName = nil, ""
+		if name != DefaultRenderer {
+			o.renderer, o.rendererName = r, name
+		}
+	}
+}
+
+// Render generates avatar for username with the named renderer and writes it
+// to w in format given as file extension. Empty username gives random avatar.
+func Render(w io.Writer, renderer string, gender Gender, username string, format string, opts ...Option) error {
+	return std().Render(w, renderer, gender, username, format, opts...)
+}
+
+// Render generates avatar for username with the named renderer, the
+// artwork of g for DefaultRenderer, and writes it to w in format given as
+// file extension. Empty username gives random avatar.
+func (g *Generator) Render(w io.Writer, renderer string, gender Gender, username string, format string, opts ...Option) error {
+	opts = append(opts[:len(opts):len(opts)], WithRenderer(renderer))
+	if username == "" {
+		return g.GenerateTo(w, gender, format, opts...)
+	}
+	return g.GenerateToFromUsername(w, gender, username, format, opts...)
+}
+
+// Renderer returns the layered asset renderer drawing with the artwork and
+// settings of g
+func (g *Generator) Renderer() Renderer {
+	return layerRenderer{g}
+}
+
+// selectParts picks the parts of the avatar of gender for seed with the
+// renderer of o
+func (g *Generator) selectParts(gender Gender, seed int64, o options) (Spec, error) {
+	if o.renderer != nil {
+		return o.renderer.SelectParts(gender, seed)
+	}
+	return g.store.randomSpec(gender, seed)
+}
+
+// composeRendered draws the avatar of spec with the renderer of o at the
+// size of o and decorates it like layered avatars
+func composeRendered(spec Spec, o options) (image.Image, error) {
+	img, err := o.renderer.Compose(spec)
+	if err != nil {
+		return nil, err
+	}
+	if o.pixelArt > 0 {
+		return o.finish(o.pixelate(img)), nil
+	}
+	if b := img.Bounds(); b.Dx() != o.size || b.Dy() != o.size {
+		dst := image.NewRGBA(image.Rect(0, 0, o.size, o.size))
+		o.filter.scaler().Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+		img = dst
+	}
+	return o.finish(img), nil
+}
+
+// layerRenderer is the built-in renderer stacking asset layers of gen, the
+// default generator if nil
+type layerRenderer struct {
+	gen *Generator
+}
+
+func (r layerRenderer) generator() *Generator {
+	if r.gen == nil {
+		return std()
+	}
+	return r.gen
+}
+
+func (r layerRenderer) SelectParts(gender Gender, seed int64) (Spec, error) {
+	return r.generator().store.randomSpec(gender, seed)
+}
+
+func (r layerRenderer) Compose(spec Spec) (image.Image, error) {
+	return r.generator().GenerateFromSpec(spec)
+}
+
+func (r layerRenderer) Encode(w io.Writer, img image.Image, format string) error {
+	return encode(w, img, format, r.generator().config.JPEGQuality, false)
+}
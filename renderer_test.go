@@ -0,0 +1,114 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type solidRenderer struct{}
+
+func (solidRenderer) SelectParts(gender Gender, seed int64) (Spec, error) {
+	return Spec{Gender: gender, Background: int(seed % 256)}, nil
+}
+
+func (solidRenderer) Compose(spec Spec) (image.Image, error) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(spec.Background)
+	}
+	return img, nil
+}
+
+func (solidRenderer) Encode(w io.Writer, img image.Image, format string) error {
+	return png.Encode(w, img)
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer("solid", solidRenderer{})
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "solid")
+		renderersMu.Unlock()
+	}()
+
+	assert.Equal(t, []string{DefaultRenderer, IdenticonRenderer, "solid"}, Renderers())
+	assert.Panics(t, func() { RegisterRenderer("solid", solidRenderer{}) })
+	assert.Panics(t, func() { RegisterRenderer("nil", nil) })
+
+	var buf bytes.Buffer
+	assert.NoError(t, Render(&buf, "solid", MALE, "username@site.com", ".png"))
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	// Renderers draw at the size of the generator
+	assert.Equal(t, 400, img.Bounds().Dx())
+	assert.Equal(t, color.Gray{uint8(usernameSeed("username@site.com") % 256)}, color.GrayModel.Convert(img.At(0, 0)))
+}
+
+func TestWithRenderer(t *testing.T) {
+	RegisterRenderer("solid", solidRenderer{})
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "solid")
+		renderersMu.Unlock()
+	}()
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithRenderer("solid"), WithSize(16), WithShape(Circle))
+	assert.NoError(t, err)
+	assert.Equal(t, 16, img.Bounds().Dx())
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	gray := uint8(usernameSeed("username@site.com") % 256)
+	assert.Equal(t, color.RGBA{gray, gray, gray, 0xff}, img.At(8, 8))
+
+	// Cached avatars of renderers don't mix with layered ones
+	cache := NewMemoryCache(4)
+	solid, err := g.GenerateBytesFromUsername(MALE, "username@site.com", "png", WithRenderer("solid"), WithCache(cache))
+	assert.NoError(t, err)
+	layered, err := g.GenerateBytesFromUsername(MALE, "username@site.com", "png", WithCache(cache))
+	assert.NoError(t, err)
+	assert.NotEqual(t, solid, layered)
+	assert.Equal(t, 2, cache.Len())
+	var buf bytes.Buffer
+	assert.NoError(t, g.Render(&buf, "solid", MALE, "username@site.com", "png", WithCache(cache)))
+	assert.Equal(t, solid, buf.Bytes())
+
+	// The default renderer draws with the artwork of the generator
+	buf.Reset()
+	assert.NoError(t, g.Render(&buf, DefaultRenderer, MALE, "username@site.com", "png", WithSize(64)))
+	expected, err := g.GenerateBytesFromUsername(MALE, "username@site.com", "png", WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, buf.Bytes())
+	spec, err := g.Renderer().SelectParts(MALE, usernameSeed("username@site.com"))
+	assert.NoError(t, err)
+	composed, err := g.Renderer().Compose(spec)
+	assert.NoError(t, err)
+	avatar, err := g.GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, avatar, composed)
+
+	_, err = g.GenerateSVGFromUsername(MALE, "username@site.com", WithRenderer("solid"))
+	assert.Equal(t, errRendererSVG, err)
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithRenderer("missing"))
+	assert.Equal(t, errUnknownRenderer, err)
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Render(&buf, DefaultRenderer, FEMALE, "username@site.com", ".png"))
+	avatar, err := GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	var expected bytes.Buffer
+	assert.NoError(t, png.Encode(&expected, avatar))
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+
+	assert.Equal(t, errUnknownRenderer, Render(&buf, "missing", FEMALE, "username@site.com", ".png"))
+	assert.Equal(t, errUnknownGender, Render(&buf, DefaultRenderer, Gender(-1), "username@site.com", ".png"))
+}
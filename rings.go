@@ -0,0 +1,137 @@
+package govatar
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// ringSegment is an annular sector between two radii and two angles.
+// Radii are fractions of the image size, angles are in radians.
+type ringSegment struct {
+	Inner, Outer float64
+	Start, End   float64
+	Color        color.RGBA
+}
+
+// ringIdenticon is the procedural layout shared by raster and SVG output
+type ringIdenticon struct {
+	Background color.RGBA
+	Center     color.RGBA
+	Segments   []ringSegment
+}
+
+// ringCenterRadius is the radius of the central dot as a fraction of size
+const ringCenterRadius = 0.09
+
+func newRingIdenticon(username string) ringIdenticon {
+	seed := usernameSeed(username)
+	scheme := NewScheme(seed)
+	rnd := rand.New(rand.NewSource(seed))
+	id := ringIdenticon{Background: scheme.Background, Center: scheme.Accent}
+
+	const rings, inner, outer, gap = 3, 0.13, 0.48, 0.015
+	width := (outer - inner) / rings
+	for r := 0; r < rings; r++ {
+		count := randInt(rnd, 3, 9)
+		offset := rnd.Float64() * 2 * math.Pi
+		step := 2 * math.Pi / float64(count)
+		drawn := 0
+		for i := 0; i < count; i++ {
+			if rnd.Float64() < 0.3 && (drawn > 0 || i < count-1) {
+				continue
+			}
+			c := scheme.Primary
+			if rnd.Intn(2) == 0 {
+				c = scheme.Accent
+			}
+			id.Segments = append(id.Segments, ringSegment{
+				Inner: inner + float64(r)*width + gap/2,
+				Outer: inner + float64(r+1)*width - gap/2,
+				Start: offset + float64(i)*step + gap,
+				End:   offset + float64(i+1)*step - gap,
+				Color: c,
+			})
+			drawn++
+		}
+	}
+	return id
+}
+
+// at returns the color at point x, y given as fractions of the image size
+func (id ringIdenticon) at(x, y float64) color.RGBA {
+	dx, dy := x-0.5, y-0.5
+	r := math.Hypot(dx, dy)
+	if r <= ringCenterRadius {
+		return id.Center
+	}
+	angle := math.Atan2(dy, dx)
+	for _, s := range id.Segments {
+		if r < s.Inner || r > s.Outer {
+			continue
+		}
+		a := math.Mod(angle-s.Start, 2*math.Pi)
+		if a < 0 {
+			a += 2 * math.Pi
+		}
+		if a <= s.End-s.Start {
+			return s.Color
+		}
+	}
+	return id.Background
+}
+
+// RingIdenticon renders an asset free identicon of concentric ring segments
+// colored from the username hash
+func RingIdenticon(username string, size int) image.Image {
+	id := newRingIdenticon(username)
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	// 2x2 samples per pixel smooth the arc edges
+	offsets := [...]float64{0.25, 0.75}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var r, g, b int
+			for _, oy := range offsets {
+				for _, ox := range offsets {
+					c := id.at((float64(x)+ox)/float64(size), (float64(y)+oy)/float64(size))
+					r, g, b = r+int(c.R), g+int(c.G), b+int(c.B)
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / 4), uint8(g / 4), uint8(b / 4), 0xff})
+		}
+	}
+	return dst
+}
+
+// RingIdenticonSVG returns the identicon drawn by RingIdenticon as SVG document
+func RingIdenticonSVG(username string, size int) string {
+	id := newRingIdenticon(username)
+	s := float64(size)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, size, size, hexColor(id.Background))
+	for _, seg := range id.Segments {
+		large := 0
+		if seg.End-seg.Start > math.Pi {
+			large = 1
+		}
+		pt := func(r, a float64) string {
+			return fmt.Sprintf("%.2f %.2f", s*(0.5+r*math.Cos(a)), s*(0.5+r*math.Sin(a)))
+		}
+		fmt.Fprintf(&b, `<path d="M%s A%.2f %.2f 0 %d 1 %s L%s A%.2f %.2f 0 %d 0 %s Z" fill="%s"/>`,
+			pt(seg.Outer, seg.Start), s*seg.Outer, s*seg.Outer, large, pt(seg.Outer, seg.End),
+			pt(seg.Inner, seg.End), s*seg.Inner, s*seg.Inner, large, pt(seg.Inner, seg.Start),
+			hexColor(seg.Color))
+	}
+	fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="%s"/>`, s/2, s/2, s*ringCenterRadius, hexColor(id.Center))
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// hexColor returns c in #rrggbb notation
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,33 @@
+package govatar
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingIdenticon(t *testing.T) {
+	img := RingIdenticon("username@site.com", 128)
+	assert.Equal(t, 128, img.Bounds().Dx())
+	assert.Equal(t, 128, img.Bounds().Dy())
+	assert.True(t, areImagesEquals(img, RingIdenticon("username@site.com", 128)))
+	assert.False(t, areImagesEquals(img, RingIdenticon("username2@site.com", 128)))
+
+	id := newRingIdenticon("username@site.com")
+	assert.NotEmpty(t, id.Segments)
+	r, g, b, _ := img.At(64, 64).RGBA()
+	assert.Equal(t, [3]uint32{uint32(id.Center.R) * 0x101, uint32(id.Center.G) * 0x101, uint32(id.Center.B) * 0x101}, [3]uint32{r, g, b})
+}
+
+func TestRingIdenticonSVG(t *testing.T) {
+	svg := RingIdenticonSVG("username@site.com", 128)
+	assert.Equal(t, svg, RingIdenticonSVG("username@site.com", 128))
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+
+	id := newRingIdenticon("username@site.com")
+	assert.Equal(t, len(id.Segments), strings.Count(svg, "<path"))
+	assert.Contains(t, svg, hexColor(id.Background))
+	assert.NoError(t, xml.Unmarshal([]byte(svg), new(interface{})))
+}
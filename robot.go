@@ -0,0 +1,15 @@
+//go:build !govatar_no_robot
+
+package govatar
+
+import "embed"
+
+//go:embed data/robot
+var robotAssets embed.FS
+
+// The robot style is drawn from artwork compiled into the binary, so it is
+// available whatever AssetsPath is. Leave it out with the govatar_no_robot
+// build tag.
+func init() {
+	mustRegisterEmbedded("robot", robotAssets, "data/robot")
+}
@@ -0,0 +1,38 @@
+//go:build !govatar_no_robot
+
+package govatar
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobot(t *testing.T) {
+	robot, ok := LookupStyle("robot")
+	assert.True(t, ok)
+	assert.Contains(t, Styles(), "robot")
+	assert.Equal(t, errStyleExists, Register("robot", os.DirFS("data/robot")))
+
+	p, err := std().store.person(robot)
+	assert.NoError(t, err)
+	for _, parts := range [][]string{p.assets("face"), p.assets("clothes"), p.assets("mouth"), p.assets("hair"), p.assets("eye")} {
+		assert.NotEmpty(t, parts)
+	}
+	img, err := GenerateStyle("robot", "deploy-bot")
+	assert.NoError(t, err)
+	assert.Equal(t, assetSize, img.Bounds().Dx())
+	again, err := GenerateFromUsername(robot, "deploy-bot")
+	assert.NoError(t, err)
+	assert.Equal(t, img, again)
+
+	// Robots draw over custom asset packs too
+	sub, err := fs.Sub(embeddedAssets, "data")
+	assert.NoError(t, err)
+	if g, err := NewFromFS(sub); err == nil {
+		_, err = g.GenerateStyle("robot", "deploy-bot", WithSize(64))
+		assert.NoError(t, err)
+	}
+}
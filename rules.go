@@ -0,0 +1,178 @@
+package govatar
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+var errInvalidRule = errors.New("Invalid rule")
+
+// Rule keeps parts apart that look broken together, e.g. a hat that hides
+// the hair or a mask covering the mouth. Parts are selected by layer name,
+// optionally followed by a zero based index of the part as in specs or a
+// range of indices: "mouth", "hat:3", "hair:7-12".
+//
+// Random avatars with the part of a rule get other parts of the excluded
+// person layers, or go without excluded extra layers. Excluded parts are
+// never drawn, also when a spec asks for them. Rules are applied in the
+// order of the person layers and extra layers.
+type Rule struct {
+	// Part selects the parts the rule applies to
+	Part string `json:"part"`
+	// Excludes selects the parts left out of avatars with Part
+	Excludes []string `json:"excludes"`
+}
+
+// selector selects parts from to to of layer, all parts if to is negative
+type selector struct {
+	layer    string
+	from, to int
+}
+
+func (s selector) matches(layer string, i int) bool {
+	return s.layer == layer && (s.to < 0 || i >= s.from && i <= s.to)
+}
+
+func parseSelector(s string) (selector, error) {
+	layer, parts, ranged := strings.Cut(s, ":")
+	if !validLayer(layer) {
+		return selector{}, errInvalidRule
+	}
+	if !ranged {
+		return selector{layer: layer, to: -1}, nil
+	}
+	first, last, ok := strings.Cut(parts, "-")
+	if !ok {
+		last = first
+	}
+	from, err := strconv.ParseUint(first, 10, 16)
+	if err != nil {
+		return selector{}, errInvalidRule
+	}
+	to, err := strconv.ParseUint(last, 10, 16)
+	if err != nil || to < from {
+		return selector{}, errInvalidRule
+	}
+	return selector{layer: layer, from: int(from), to: int(to)}, nil
+}
+
+// rule is a parsed Rule
+type rule struct {
+	part     selector
+	excludes []selector
+}
+
+func parseRules(rules []Rule) ([]rule, error) {
+	parsed := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		part, err := parseSelector(r.Part)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.Excludes) == 0 {
+			return nil, errInvalidRule
+		}
+		pr := rule{part: part}
+		for _, e := range r.Excludes {
+			exclude, err := parseSelector(e)
+			if err != nil {
+				return nil, err
+			}
+			pr.excludes = append(pr.excludes, exclude)
+		}
+		parsed = append(parsed, pr)
+	}
+	return parsed, nil
+}
+
+// part returns the index of the part of layer, false if spec leaves it out
+func (s Spec) part(layer string) (int, bool) {
+	if i := s.layer(layer); i != nil {
+		return *i, true
+	}
+	i, ok := s.Accessories[layer]
+	return i, ok
+}
+
+// excluded reports whether a rule of p keeps part i of layer out of spec
+func (p person) excluded(spec Spec, layer string, i int) bool {
+	for _, r := range p.rules {
+		if j, ok := spec.part(r.part.layer); !ok || !r.part.matches(r.part.layer, j) {
+			continue
+		}
+		for _, e := range r.excludes {
+			if e.matches(layer, i) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyRules replaces the excluded parts of a random spec with other parts
+// drawn from rnd, or leaves out excluded extra layers
+func applyRules(rnd *rand.Rand, p person, spec *Spec) {
+	if len(p.rules) == 0 {
+		return
+	}
+	for _, layer := range append(append([]string(nil), personLayers...), p.extras...) {
+		i, ok := spec.part(layer)
+		if !ok || !p.excluded(*spec, layer, i) {
+			continue
+		}
+		if !isPersonLayer(layer) {
+			spec.setAccessory(layer, -1)
+			continue
+		}
+		var allowed []int
+		var weights []float64
+		for j := range p.layers[layer] {
+			if !p.excluded(*spec, layer, j) {
+				allowed = append(allowed, j)
+				if p.weights[layer] != nil {
+					weights = append(weights, p.weights[layer][j])
+				}
+			}
+		}
+		if len(allowed) > 0 {
+			*spec.layer(layer) = allowed[weightedIndex(rnd, len(allowed), weights)]
+		}
+	}
+}
+
+// addRules adds rules to the rules of the asset pack of every gender of s
+func (s *store) addRules(rules []rule) {
+	if len(rules) == 0 {
+		return
+	}
+	for _, p := range []*person{&s.Male, &s.Female, &s.Monster} {
+		p.rules = append(append([]rule(nil), p.rules...), rules...)
+	}
+	s.Neutral = mixPeople(s.Male, s.Female)
+}
+
+// shiftRules returns rules of the person whose assets follow the n[layer]
+// assets of another person in every layer, see mixPeople
+func shiftRules(rules []rule, n map[string]int, count map[string]int) []rule {
+	shift := func(s selector, whole bool) selector {
+		if s.to < 0 {
+			if !whole {
+				return s
+			}
+			s.from, s.to = 0, count[s.layer]-1
+		}
+		s.from += n[s.layer]
+		s.to += n[s.layer]
+		return s
+	}
+	shifted := make([]rule, len(rules))
+	for i, r := range rules {
+		shifted[i] = rule{part: shift(r.part, true)}
+		for _, e := range r.excludes {
+			shifted[i].excludes = append(shifted[i].excludes, shift(e, false))
+		}
+	}
+	return shifted
+}
@@ -0,0 +1,101 @@
+package govatar
+
+import (
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestRules(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"optional": {"hat": 0, "glasses": 0}, "rules": [{"part": "hat:1", "excludes": ["glasses", "mouth"]}]}`)}
+	assert.NoError(t, Register("ruled", fsys))
+	gender, _ := LookupStyle("ruled")
+
+	hats := 0
+	for i := 0; i < 50; i++ {
+		username := "user" + strconv.Itoa(i)
+		spec, err := SpecFromUsername(gender, username)
+		assert.NoError(t, err)
+		if spec.Accessories["hat"] == 1 {
+			hats++
+			assert.NotContains(t, spec.Accessories, "glasses")
+		} else {
+			assert.Contains(t, spec.Accessories, "glasses")
+		}
+		again, err := SpecFromUsername(gender, username)
+		assert.NoError(t, err)
+		assert.Equal(t, spec, again)
+	}
+	assert.InDelta(t, 25, hats, 15)
+
+	// Excluded parts are not drawn even when a spec asks for them
+	spec := NewBuilder(gender).Accessory("glasses", 0).Accessory("hat", 1).Spec()
+	layers, _, err := std().store.drawnLayout(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"background", "face", "clothes", "hair", "eye", "hat"}, layers)
+	img, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+	expected, err := GenerateFromSpec(spec, WithoutLayers("glasses", "mouth"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	e, err := NewEditor(d)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, e.Image())
+}
+
+func TestConfigRules(t *testing.T) {
+	c := DefaultConfig()
+	c.Rules = []Rule{{Part: "hair:0-5", Excludes: []string{"clothes:0-9"}}}
+	g, err := New(c)
+	assert.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		username := "user" + strconv.Itoa(i)
+		spec, err := g.SpecFromUsername(MALE, username)
+		assert.NoError(t, err)
+		if spec.Hair <= 5 {
+			assert.True(t, spec.Clothes >= 10, username)
+		}
+		// Rules keep the parts they allow
+		free, err := SpecFromUsername(MALE, username)
+		assert.NoError(t, err)
+		if free.Hair > 5 || free.Clothes >= 10 {
+			assert.Equal(t, free, spec)
+		}
+	}
+
+	// The editor brings parts back when a rule stops applying
+	spec := Spec{Gender: MALE}
+	d, err := g.Describe(spec)
+	assert.NoError(t, err)
+	e, err := g.NewEditor(d)
+	assert.NoError(t, err)
+	img, err := e.Set(HAIR, 10)
+	assert.NoError(t, err)
+	spec.Hair = 10
+	expected, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+	img, err = e.Set(HAIR, 0)
+	assert.NoError(t, err)
+	spec.Hair = 0
+	expected, err = GenerateFromSpec(spec, WithoutLayers("clothes"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+
+	for _, rules := range [][]Rule{
+		{{Part: "hair:5-2", Excludes: []string{"clothes"}}},
+		{{Part: "hair:x", Excludes: []string{"clothes"}}},
+		{{Part: "Hair", Excludes: []string{"clothes"}}},
+		{{Part: "hair"}},
+	} {
+		c.Rules = rules
+		assert.Equal(t, errInvalidRule, c.Validate(), rules[0].Part)
+	}
+}
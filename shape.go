@@ -0,0 +1,161 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+var (
+	errInvalidShape        = errors.New("Invalid shape")
+	errInvalidCornerRadius = errors.New("Invalid corner radius")
+	errInvalidMatte        = errors.New("Invalid matte color")
+)
+
+// Shape is the outline avatars are cut to
+type Shape int
+
+// Square keeps the whole avatar, Circle cuts it to the inscribed circle
+const (
+	Square Shape = iota
+	Circle
+)
+
+// WithShape cuts the avatar to shape. Outside of it the avatar is
+// transparent, with anti-aliased edges.
+func WithShape(shape Shape) Option {
+	return func(o *options) {
+		o.shape = shape
+		if shape < Square || shape > Circle {
+			o.err = errInvalidShape
+		}
+	}
+}
+
+// WithCornerRadius rounds the corners of the avatar with radius in pixels
+// of the output size. Radiuses beyond half the size give a circle.
+func WithCornerRadius(radius int) Option {
+	return func(o *options) {
+		o.radius = radius
+		if radius < 0 {
+			o.err = errInvalidCornerRadius
+		}
+	}
+}
+
+// WithMatte sets the color JPEG output shows where the avatar is
+// transparent, white by default
+func WithMatte(c color.Color) Option {
+	return func(o *options) {
+		o.matte = c
+		if c == nil {
+			o.err = errInvalidMatte
+		}
+	}
+}
+
+// cornerRadius returns the radius corners of an avatar of size are rounded by
+func (o options) cornerRadius(size int) float64 {
+	if o.shape == Circle {
+		return float64(size) / 2
+	}
+	return float64(o.radius)
+}
+
+// decorated reports whether o sets a shape or overlays applied to the
+// composed avatar
+func (o options) decorated() bool {
+	return o.shape != Square || o.radius > 0 || o.frame != nil || o.badge != nil || o.logo != nil || len(o.overlays) > 0
+}
+
+// finish applies the shape and overlays set by o to the composed img
+func (o options) finish(img image.Image) image.Image {
+	if img == nil {
+		return img
+	}
+	b := img.Bounds()
+	radius := o.cornerRadius(b.Dx())
+	if !o.decorated() {
+		return img
+	}
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	for _, ov := range o.overlays {
+		ov.draw(dst)
+	}
+	if o.frame != nil {
+		o.frame.draw(dst, radius)
+	}
+	if radius > 0 {
+		roundCorners(dst, radius)
+	}
+	if o.badge != nil {
+		o.badge.draw(dst)
+	}
+	if o.logo != nil {
+		o.logo.draw(dst)
+	}
+	return dst
+}
+
+// flatten draws img over the matte of o for formats without transparency
+func (o options) flatten(img image.Image, format string) image.Image {
+	switch normalizeFormat(format) {
+	case "jpeg", "jpg":
+	default:
+		return img
+	}
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(o.matte), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// roundCorners makes img transparent outside of the rectangle of its bounds
+// with corners rounded by radius. Edge pixels keep the part of them covered
+// by the rounded rectangle.
+func roundCorners(img *image.RGBA, radius float64) {
+	b := img.Bounds()
+	radius = fitRadius(b, radius)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			coverage := 0.5 - edgeDistance(b, radius, x, y)
+			if coverage >= 1 {
+				continue
+			}
+			i := img.PixOffset(x, y)
+			if coverage <= 0 {
+				copy(img.Pix[i:i+4], []uint8{0, 0, 0, 0})
+				continue
+			}
+			for c := 0; c < 4; c++ {
+				img.Pix[i+c] = uint8(float64(img.Pix[i+c])*coverage + 0.5)
+			}
+		}
+	}
+}
+
+// fitRadius limits radius to half the shorter side of b
+func fitRadius(b image.Rectangle, radius float64) float64 {
+	if half := float64(b.Dx()) / 2; radius > half {
+		radius = half
+	}
+	if half := float64(b.Dy()) / 2; radius > half {
+		radius = half
+	}
+	return radius
+}
+
+// edgeDistance returns the distance of the center of pixel x, y to the
+// outline of b with corners rounded by radius, negative inside
+func edgeDistance(b image.Rectangle, radius float64, x, y int) float64 {
+	// Distances to the rectangle inset by radius
+	qx := math.Abs(float64(x)+0.5-float64(b.Min.X+b.Max.X)/2) - float64(b.Dx())/2 + radius
+	qy := math.Abs(float64(y)+0.5-float64(b.Min.Y+b.Max.Y)/2) - float64(b.Dy())/2 + radius
+	return math.Hypot(math.Max(qx, 0), math.Max(qy, 0)) + math.Min(math.Max(qx, qy), 0) - radius
+}
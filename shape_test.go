@@ -0,0 +1,129 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundCorners(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	roundCorners(img, 50)
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(99, 99))
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, img.RGBAAt(50, 50))
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, img.RGBAAt(1, 50))
+	// Edges are anti-aliased
+	edge := img.RGBAAt(14, 14).A
+	assert.True(t, edge > 0 && edge < 0xff, edge)
+	r, g, b, a := img.At(14, 14).RGBA()
+	assert.True(t, r <= a && g <= a && b <= a)
+}
+
+func TestWithShape(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	square, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100))
+	assert.NoError(t, err)
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle))
+	assert.NoError(t, err)
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	assert.Equal(t, square.At(50, 50), img.At(50, 50))
+	same, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Square))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(square, same))
+
+	// Shapes apply over the white background
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithoutBackground(), WithShape(Circle))
+	assert.NoError(t, err)
+	_, _, _, a = img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	initials, err := g.GenerateInitials("Jane Doe", WithShape(Circle))
+	assert.NoError(t, err)
+	_, _, _, a = initials.At(0, 0).RGBA()
+	assert.Zero(t, a)
+
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithShape(Circle))
+	assert.NoError(t, err)
+	assert.Regexp(t, `<clipPath id="gv[0-9a-f]{8}-shape">`, svg)
+	assert.True(t, strings.HasSuffix(svg, "</g></svg>"))
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithShape(Shape(7)))
+	assert.Equal(t, errInvalidShape, err)
+}
+
+func TestEditorShape(t *testing.T) {
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	want, err := GenerateFromSpec(spec, WithSize(100), WithShape(Circle))
+	assert.NoError(t, err)
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	e, err := NewEditor(d, WithSize(100), WithShape(Circle))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(want, e.Image()))
+}
+
+func TestWithCornerRadius(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 60))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	roundCorners(img, 10)
+	assert.Zero(t, img.RGBAAt(0, 0).A)
+	assert.Zero(t, img.RGBAAt(99, 59).A)
+	assert.Equal(t, uint8(0xff), img.RGBAAt(50, 0).A)
+	assert.Equal(t, uint8(0xff), img.RGBAAt(0, 30).A)
+	assert.Equal(t, uint8(0xff), img.RGBAAt(10, 10).A)
+
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	square, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100))
+	assert.NoError(t, err)
+	rounded, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithCornerRadius(20))
+	assert.NoError(t, err)
+	_, _, _, a := rounded.At(1, 1).RGBA()
+	assert.Zero(t, a)
+	assert.Equal(t, square.At(50, 1), rounded.At(50, 1))
+	// Large radiuses give a circle
+	huge, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithCornerRadius(500))
+	assert.NoError(t, err)
+	circle, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle))
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(huge, circle))
+
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithSize(100), WithCornerRadius(20))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `rx="8"`)
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithCornerRadius(-1))
+	assert.Equal(t, errInvalidCornerRadius, err)
+}
+
+func TestWithMatte(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	decode := func(opts ...Option) image.Image {
+		b, err := g.GenerateBytesFromUsername(MALE, "username@site.com", "jpeg", append(opts, WithSize(100), WithShape(Circle))...)
+		assert.NoError(t, err)
+		img, err := jpeg.Decode(bytes.NewReader(b))
+		assert.NoError(t, err)
+		return img
+	}
+	r, g8, b, _ := decode().At(0, 0).RGBA()
+	assert.True(t, r > 0xf000 && g8 > 0xf000 && b > 0xf000, "%x %x %x", r, g8, b)
+	r, g8, b, _ = decode(WithMatte(color.RGBA{0xff, 0, 0, 0xff})).At(0, 0).RGBA()
+	assert.True(t, r > 0xf000 && g8 < 0x1000 && b < 0x1000, "%x %x %x", r, g8, b)
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithMatte(nil))
+	assert.Equal(t, errInvalidMatte, err)
+}
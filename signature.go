@@ -0,0 +1,53 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Email signature strip size
+const (
+	SignatureWidth  = 480
+	SignatureHeight = 112
+)
+
+// GenerateSignature generates a horizontal email signature strip with the
+// avatar of username followed by name and job title on white background.
+// Encode it as png to keep the text crisp.
+func GenerateSignature(gender Gender, username, name, title string) (image.Image, error) {
+	avatar, err := GenerateFromUsername(gender, username)
+	if err != nil {
+		return nil, err
+	}
+	strip := image.NewRGBA(image.Rect(0, 0, SignatureWidth, SignatureHeight))
+	draw.Draw(strip, strip.Bounds(), image.White, image.Point{}, draw.Src)
+
+	const padding = 8
+	avatarSize := SignatureHeight - 2*padding
+	xdraw.CatmullRom.Scale(strip, image.Rect(padding, padding, padding+avatarSize, padding+avatarSize), avatar, avatar.Bounds(), draw.Over, nil)
+
+	// Separator between avatar and text in the accent color of the user
+	scheme := NewScheme(usernameSeed(username))
+	lineX := 2*padding + avatarSize + 8
+	draw.Draw(strip, image.Rect(lineX, padding+8, lineX+3, SignatureHeight-padding-8), image.NewUniform(scheme.Primary), image.Point{}, draw.Src)
+
+	textX := lineX + 3 + 16
+	textWidth := SignatureWidth - textX - padding
+	nameFace, name := fitText(boldFont, name, 26, 14, textWidth)
+	defer nameFace.Close()
+	titleFace, title := fitText(regularFont, title, 18, 11, textWidth)
+	defer titleFace.Close()
+
+	nameY := SignatureHeight / 2
+	if title == "" {
+		nameY += nameFace.Metrics().Ascent.Ceil() / 2
+	}
+	drawText(strip, nameFace, color.NRGBA{0x22, 0x22, 0x22, 0xff}, textX, nameY, name)
+	if title != "" {
+		drawText(strip, titleFace, color.NRGBA{0x66, 0x66, 0x66, 0xff}, textX, nameY+titleFace.Metrics().Height.Ceil()+4, title)
+	}
+	return strip, nil
+}
@@ -0,0 +1,23 @@
+package govatar
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSignature(t *testing.T) {
+	strip, err := GenerateSignature(FEMALE, "username@site.com", "Jane Doe", "Head of People")
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureWidth, strip.Bounds().Dx())
+	assert.Equal(t, SignatureHeight, strip.Bounds().Dy())
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, strip.At(SignatureWidth-1, 0))
+
+	noTitle, err := GenerateSignature(FEMALE, "username@site.com", "Jane Doe", "")
+	assert.NoError(t, err)
+	assert.False(t, areImagesEquals(strip, noTitle))
+
+	_, err = GenerateSignature(Gender(-1), "username@site.com", "Jane Doe", "")
+	assert.Equal(t, errUnknownGender, err)
+}
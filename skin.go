@@ -0,0 +1,85 @@
+package govatar
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+)
+
+var errInvalidSkinTone = errors.New("Invalid skin tone")
+
+// DefaultSkinTones holds the skin tones WithSkinTones picks from when called
+// without tones, from light to deep
+var DefaultSkinTones = []color.Color{
+	color.RGBA{0xf9, 0xd9, 0xbc, 0xff},
+	color.RGBA{0xe7, 0xbc, 0x91, 0xff},
+	color.RGBA{0xc6, 0x8e, 0x5b, 0xff},
+	color.RGBA{0xa0, 0x68, 0x3f, 0xff},
+	color.RGBA{0x7a, 0x4a, 0x2a, 0xff},
+	color.RGBA{0x4e, 0x2f, 0x1f, 0xff},
+}
+
+// WithSkinTones remaps the skin of the face layer, ears included, to a tone
+// of tones, DefaultSkinTones if empty. The tone is picked from a hash of the
+// parts, so an avatar always gets the same one. Shading is kept and faces
+// that aren't skin colored, e.g. of monsters, are left as drawn. SVG output
+// is not remapped.
+func WithSkinTones(tones ...color.Color) Option {
+	return func(o *options) {
+		if len(tones) == 0 {
+			tones = DefaultSkinTones
+		}
+		for _, c := range tones {
+			if c == nil {
+				o.err = errInvalidSkinTone
+				return
+			}
+		}
+		o.skinTones = tones
+	}
+}
+
+// WithSkinTone remaps the skin of the face layer to tone
+func WithSkinTone(tone color.Color) Option {
+	return WithSkinTones(tone)
+}
+
+// skinTone returns the skin tone of the avatar of spec, nil if o leaves
+// faces as drawn
+func (o options) skinTone(spec Spec) color.Color {
+	if len(o.skinTones) == 0 {
+		return nil
+	}
+	h := fnv.New32a()
+	fmt.Fprint(h, spec.Gender, spec.Face, spec.Clothes, spec.Mouth, spec.Hair, spec.Eye, " skin")
+	return o.skinTones[h.Sum32()%uint32(len(o.skinTones))]
+}
+
+// remapSkin returns a copy of img with the skin colored pixels moved from
+// the most common color of img to tone. img is returned as is if its most
+// common color isn't skin colored.
+func remapSkin(img image.Image, tone color.NRGBA) image.Image {
+	return remap(img, tone, true)
+}
+
+// dominantColor returns the most common opaque color of img
+func dominantColor(img image.Image) (color.NRGBA, bool) {
+	counts := map[color.NRGBA]int{}
+	var dominant color.NRGBA
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				continue
+			}
+			counts[c]++
+			if counts[c] > counts[dominant] {
+				dominant = c
+			}
+		}
+	}
+	return dominant, counts[dominant] > 0
+}
@@ -0,0 +1,80 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemapSkin(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{0xdc, 0xce, 0x9c, 0xff})
+	src.SetNRGBA(1, 0, color.NRGBA{0xdc, 0xce, 0x9c, 0xff})
+	src.SetNRGBA(2, 0, color.NRGBA{0x20, 0x20, 0x20, 0xff})
+	src.SetNRGBA(3, 0, color.NRGBA{0x40, 0x80, 0xff, 0xff})
+
+	tone := color.NRGBA{0x7a, 0x4a, 0x2a, 0xff}
+	img := remapSkin(src, tone)
+	assert.Equal(t, tone, img.At(0, 0))
+	// Outlines and other colors are kept
+	assert.Equal(t, src.At(2, 0), img.At(2, 0))
+	assert.Equal(t, src.At(3, 0), img.At(3, 0))
+
+	// Faces that aren't skin colored are left as drawn
+	src.SetNRGBA(1, 0, color.NRGBA{0x40, 0x80, 0xff, 0xff})
+	src.SetNRGBA(2, 0, color.NRGBA{0x40, 0x80, 0xff, 0xff})
+	assert.Equal(t, src, remapSkin(src, tone))
+}
+
+func TestWithSkinTones(t *testing.T) {
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	plain, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+	toned, err := GenerateFromSpec(spec, WithSkinTones())
+	assert.NoError(t, err)
+	again, err := GenerateFromSpec(spec, WithSkinTones())
+	assert.NoError(t, err)
+	assert.Equal(t, toned, again)
+
+	o, err := std().options([]Option{WithSkinTones()})
+	assert.NoError(t, err)
+	assert.Contains(t, DefaultSkinTones, o.skinTone(spec))
+	assert.NotNil(t, o.tint(spec, "face"))
+	assert.Nil(t, o.tint(spec, "hair"))
+
+	// Usernames spread over the tones
+	seen := map[color.Color]bool{}
+	for _, username := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		spec, err := SpecFromUsername(FEMALE, username)
+		assert.NoError(t, err)
+		seen[o.skinTone(spec)] = true
+	}
+	assert.True(t, len(seen) > 3, len(seen))
+
+	// Every face takes the tone
+	deep := color.RGBA{0x4e, 0x2f, 0x1f, 0xff}
+	for face := range std().store.Male.assets("face") {
+		spec.Face = face
+		img, err := GenerateFromSpec(spec, WithSkinTone(deep), WithoutLayers("background", "clothes", "mouth", "hair", "eye"), WithTransparent())
+		assert.NoError(t, err)
+		base, ok := dominantColor(img)
+		assert.True(t, ok)
+		assert.InDelta(t, deep.R, base.R, 2)
+		assert.InDelta(t, deep.G, base.G, 2)
+		assert.InDelta(t, deep.B, base.B, 2)
+	}
+
+	// Only the face changes
+	bare, err := GenerateFromSpec(spec, WithoutLayers("face"))
+	assert.NoError(t, err)
+	bareToned, err := GenerateFromSpec(spec, WithoutLayers("face"), WithSkinTone(deep))
+	assert.NoError(t, err)
+	assert.Equal(t, bare, bareToned)
+	assert.NotEqual(t, plain, toned)
+
+	_, err = GenerateFromSpec(spec, WithSkinTone(nil))
+	assert.Equal(t, errInvalidSkinTone, err)
+}
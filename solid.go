@@ -0,0 +1,67 @@
+package govatar
+
+import (
+	"errors"
+	"image/color"
+)
+
+var errInvalidPalette = errors.New("Invalid palette")
+
+// DefaultPalette holds the background colors WithSolidBackground picks from
+// when called without a palette
+var DefaultPalette = []color.Color{
+	color.RGBA{0xe5, 0x73, 0x73, 0xff},
+	color.RGBA{0xf0, 0x62, 0x92, 0xff},
+	color.RGBA{0xba, 0x68, 0xc8, 0xff},
+	color.RGBA{0x95, 0x75, 0xcd, 0xff},
+	color.RGBA{0x79, 0x86, 0xcb, 0xff},
+	color.RGBA{0x64, 0xb5, 0xf6, 0xff},
+	color.RGBA{0x4f, 0xc3, 0xf7, 0xff},
+	color.RGBA{0x4d, 0xd0, 0xe1, 0xff},
+	color.RGBA{0x4d, 0xb6, 0xac, 0xff},
+	color.RGBA{0x81, 0xc7, 0x84, 0xff},
+	color.RGBA{0xae, 0xd5, 0x81, 0xff},
+	color.RGBA{0xdc, 0xe7, 0x75, 0xff},
+	color.RGBA{0xff, 0xf1, 0x76, 0xff},
+	color.RGBA{0xff, 0xd5, 0x4f, 0xff},
+	color.RGBA{0xff, 0xb7, 0x4d, 0xff},
+	color.RGBA{0xff, 0x8a, 0x65, 0xff},
+	color.RGBA{0xa1, 0x88, 0x7f, 0xff},
+	color.RGBA{0x90, 0xa4, 0xae, 0xff},
+}
+
+// WithSolidBackground leaves out the background artwork and fills the area
+// around the character with a color of palette, DefaultPalette if empty.
+// The color is the one of palette closest to the Background of the scheme
+// of the parts, so an avatar always gets the same one, it goes with picked
+// tints and it tells users apart better than the few background images.
+func WithSolidBackground(palette ...color.Color) Option {
+	return func(o *options) {
+		if len(palette) == 0 {
+			palette = DefaultPalette
+		}
+		o.background = false
+		o.transparent = false
+		o.palette = palette
+		o.backgrounds = nil
+		for _, c := range palette {
+			if c == nil {
+				o.err = errInvalidPalette
+			}
+		}
+	}
+}
+
+// fill returns the color drawn under the character of spec when the
+// background artwork is left out
+func (o options) fill(spec Spec) color.Color {
+	if len(o.palette) == 0 {
+		return color.White
+	}
+	return o.palette[nearestColor(o.palette, specScheme(spec).Background)]
+}
+
+// pick returns an index below n picked by a hash of the parts of spec
+func pick(spec Spec, n int) int {
+	return int(specSeed(spec) % int64(n))
+}
@@ -0,0 +1,51 @@
+package govatar
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSolidBackground(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	spec, err := g.SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	o, err := g.options([]Option{WithSolidBackground()})
+	assert.NoError(t, err)
+	want := o.fill(spec)
+	assert.Contains(t, DefaultPalette, want)
+
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithSolidBackground())
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(want), img.At(0, 0))
+	again, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithSolidBackground())
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(img, again))
+
+	// Usernames spread over the palette
+	seen := map[color.Color]bool{}
+	for _, username := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		spec, err := g.SpecFromUsername(FEMALE, username)
+		assert.NoError(t, err)
+		seen[o.fill(spec)] = true
+	}
+	assert.True(t, len(seen) > 4, len(seen))
+
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	img, err = g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithSolidBackground(red))
+	assert.NoError(t, err)
+	assert.Equal(t, color.Color(red), img.At(0, 0))
+	svg, err := g.GenerateSVGFromUsername(MALE, "username@site.com", WithSolidBackground(red))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `<rect width="40" height="40" fill="#ff0000"/>`)
+
+	// Without palette the background is white
+	o, err = g.options([]Option{WithoutBackground()})
+	assert.NoError(t, err)
+	assert.Equal(t, color.White, o.fill(spec))
+
+	_, err = g.GenerateFromUsername(MALE, "username@site.com", WithSolidBackground(red, nil))
+	assert.Equal(t, errInvalidPalette, err)
+}
@@ -0,0 +1,122 @@
+package govatar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// specFormatVersion prefixes spec strings produced by FormatSpec
+const specFormatVersion = "v1"
+
+var specGenderCodes = map[Gender]string{MALE: "m", FEMALE: "f", MONSTER: "x", NEUTRAL: "n"}
+
+// FormatSpec encodes spec as a compact versioned string such as
+// "v1:m:f3.c12.h7.e2.m5.b0". After the version and the gender (m - male,
+// f - female, x - monster, n - neutral, or the name of a registered style) come zero
+// based part indices keyed by layer: f - face, c - clothes, h - hair,
+// e - eye, m - mouth, b - background. Parts of extra layers such as
+// accessories follow keyed by their layer name, e.g. ".hat2".
+func FormatSpec(spec Spec) string {
+	s := fmt.Sprintf("%s:%s:f%d.c%d.h%d.e%d.m%d.b%d", specFormatVersion, specGenderCode(spec.Gender),
+		spec.Face, spec.Clothes, spec.Hair, spec.Eye, spec.Mouth, spec.Background)
+	layers := make([]string, 0, len(spec.Accessories))
+	for layer := range spec.Accessories {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	for _, layer := range layers {
+		s += fmt.Sprintf(".%s%d", layer, spec.Accessories[layer])
+	}
+	return s
+}
+
+// specGenderCode returns the code of gender in specs
+func specGenderCode(gender Gender) string {
+	if code, ok := specGenderCodes[gender]; ok {
+		return code
+	}
+	if st, ok := style(gender); ok {
+		return st.name
+	}
+	return ""
+}
+
+// ParseSpec decodes a string produced by FormatSpec. Parts may come in any order.
+func ParseSpec(s string) (Spec, error) {
+	var spec Spec
+	fields := strings.Split(s, ":")
+	if len(fields) != 3 || fields[0] != specFormatVersion {
+		return spec, errInvalidSpec
+	}
+
+	spec.Gender = Gender(-1)
+	for g, code := range specGenderCodes {
+		if code == fields[1] {
+			spec.Gender = g
+		}
+	}
+	if g, ok := LookupStyle(fields[1]); ok && g > NEUTRAL && spec.Gender < 0 {
+		spec.Gender = g
+	}
+	if spec.Gender < 0 {
+		return spec, errUnknownGender
+	}
+
+	parts := map[byte]*int{
+		'f': &spec.Face,
+		'c': &spec.Clothes,
+		'h': &spec.Hair,
+		'e': &spec.Eye,
+		'm': &spec.Mouth,
+		'b': &spec.Background,
+	}
+	for _, part := range strings.Split(fields[2], ".") {
+		key := strings.TrimRight(part, "0123456789")
+		n, err := strconv.ParseUint(part[len(key):], 10, 16)
+		if key == "" || err != nil {
+			return spec, errInvalidSpec
+		}
+		if len(key) > 1 {
+			if _, ok := spec.Accessories[key]; ok || !validLayerName(key) || isPersonLayer(key) {
+				return spec, errInvalidSpec
+			}
+			spec.setAccessory(key, int(n))
+			continue
+		}
+		index, ok := parts[key[0]]
+		if !ok {
+			return spec, errInvalidSpec
+		}
+		*index = int(n)
+		delete(parts, key[0])
+	}
+	if len(parts) != 0 {
+		return spec, errInvalidSpec
+	}
+	return spec, nil
+}
+
+// String returns spec encoded by FormatSpec
+func (s Spec) String() string {
+	return FormatSpec(s)
+}
+
+// MarshalText implements encoding.TextMarshaler using FormatSpec
+func (s Spec) MarshalText() ([]byte, error) {
+	if specGenderCode(s.Gender) == "" {
+		return nil, errUnknownGender
+	}
+	return []byte(FormatSpec(s)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseSpec
+func (s *Spec) UnmarshalText(text []byte) error {
+	spec, err := ParseSpec(string(text))
+	if err != nil {
+		return err
+	}
+	*s = spec
+	return nil
+}
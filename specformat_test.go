@@ -0,0 +1,61 @@
+package govatar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSpec(t *testing.T) {
+	spec := Spec{Gender: MALE, Face: 3, Clothes: 12, Hair: 7, Eye: 2, Mouth: 5, Background: 9}
+	assert.Equal(t, "v1:m:f3.c12.h7.e2.m5.b9", FormatSpec(spec))
+	assert.Equal(t, "v1:x:f0.c0.h0.e0.m0.b0", Spec{Gender: MONSTER}.String())
+
+	for _, username := range []string{"a", "username@site.com"} {
+		for _, g := range []Gender{MALE, FEMALE, MONSTER} {
+			spec, err := SpecFromUsername(g, username)
+			assert.NoError(t, err)
+			parsed, err := ParseSpec(FormatSpec(spec))
+			assert.NoError(t, err)
+			assert.Equal(t, spec, parsed)
+		}
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec("v1:f:b1.m2.e3.h4.c5.f6")
+	assert.NoError(t, err)
+	assert.Equal(t, Spec{Gender: FEMALE, Background: 1, Mouth: 2, Eye: 3, Hair: 4, Clothes: 5, Face: 6}, spec)
+
+	invalid := []string{
+		"",
+		"v1:m",
+		"v2:m:f3.c12.h7.e2.m5.b9",
+		"v1:m:f3.c12.h7.e2.m5",
+		"v1:m:f3.c12.h7.e2.m5.b9.z1",
+		"v1:m:f3.c12.h7.e2.m5.bx",
+		"v1:m:f3.c12.h7.e2.m5.b-1",
+		"v1:m:f3..c12.h7.e2.m5.b9",
+	}
+	for _, s := range invalid {
+		_, err := ParseSpec(s)
+		assert.Equal(t, errInvalidSpec, err, s)
+	}
+	_, err = ParseSpec("v1:q:f3.c12.h7.e2.m5.b9")
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestSpecJSON(t *testing.T) {
+	spec := Spec{Gender: FEMALE, Face: 3, Clothes: 12, Hair: 7, Eye: 2, Mouth: 5}
+	data, err := json.Marshal(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, `"v1:f:f3.c12.h7.e2.m5.b0"`, string(data))
+
+	var decoded Spec
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, spec, decoded)
+
+	_, err = json.Marshal(Spec{Gender: Gender(-1)})
+	assert.Error(t, err)
+}
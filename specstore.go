@@ -0,0 +1,30 @@
+package govatar
+
+import "errors"
+
+// ErrSpecNotFound is returned by SpecStore when no spec is stored for a user
+var ErrSpecNotFound = errors.New("Spec not found")
+
+// SpecStore persists avatar specs by user ID so an avatar stays the same
+// even if the user later changes the username it was generated from.
+// Implementations must be safe for concurrent use.
+type SpecStore interface {
+	// Get returns the spec stored for userID or ErrSpecNotFound
+	Get(userID string) (Spec, error)
+	// Put stores spec for userID replacing any previous one
+	Put(userID string, spec Spec) error
+}
+
+// PinSpec returns the spec stored for userID. If there is none, the spec is
+// generated from username and stored, e.g. at signup.
+func PinSpec(store SpecStore, userID string, gender Gender, username string) (Spec, error) {
+	spec, err := store.Get(userID)
+	if err != ErrSpecNotFound {
+		return spec, err
+	}
+	spec, err = SpecFromUsername(gender, username)
+	if err != nil {
+		return Spec{}, err
+	}
+	return spec, store.Put(userID, spec)
+}
@@ -0,0 +1,48 @@
+package govatar
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySpecStore struct {
+	sync.Mutex
+	specs map[string]Spec
+}
+
+func (s *memorySpecStore) Get(userID string) (Spec, error) {
+	s.Lock()
+	defer s.Unlock()
+	spec, ok := s.specs[userID]
+	if !ok {
+		return Spec{}, ErrSpecNotFound
+	}
+	return spec, nil
+}
+
+func (s *memorySpecStore) Put(userID string, spec Spec) error {
+	s.Lock()
+	defer s.Unlock()
+	s.specs[userID] = spec
+	return nil
+}
+
+func TestPinSpec(t *testing.T) {
+	store := &memorySpecStore{specs: map[string]Spec{}}
+
+	spec, err := PinSpec(store, "42", MALE, "username@site.com")
+	assert.NoError(t, err)
+	expected, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, spec)
+
+	// Username change keeps the pinned spec
+	spec, err = PinSpec(store, "42", MALE, "username2@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, spec)
+
+	_, err = PinSpec(store, "43", Gender(-1), "username@site.com")
+	assert.Equal(t, errUnknownGender, err)
+}
@@ -0,0 +1,89 @@
+// Package sqlstore implements govatar.SpecStore on top of database/sql
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+
+	"github.com/recoilme/govatar"
+)
+
+var (
+	errInvalidTable   = errors.New("Invalid table name")
+	errInvalidDialect = errors.New("Invalid SQL dialect")
+)
+
+// Dialect selects the SQL understood by the database
+type Dialect string
+
+const (
+	// SQLite also covers databases with INSERT ... ON CONFLICT and ?
+	// placeholders
+	SQLite Dialect = "sqlite"
+	// MySQL also covers MariaDB
+	MySQL Dialect = "mysql"
+)
+
+// tableName matches plain SQL identifiers, which need no quoting
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Store keeps specs in a two column table (user_id, spec).
+// Queries use ? placeholders as understood by SQLite and MySQL drivers.
+type Store struct {
+	db     *sql.DB
+	table  string
+	upsert string
+}
+
+// New returns a store using table of db. The table must exist, see CreateTable.
+// Table names are letters, digits and _ not starting with a digit.
+func New(db *sql.DB, table string, dialect Dialect) (*Store, error) {
+	if !tableName.MatchString(table) {
+		return nil, errInvalidTable
+	}
+	insert := "INSERT INTO " + table + " (user_id, spec) VALUES (?, ?) "
+	var upsert string
+	switch dialect {
+	case SQLite:
+		upsert = insert + "ON CONFLICT (user_id) DO UPDATE SET spec = excluded.spec"
+	case MySQL:
+		upsert = insert + "ON DUPLICATE KEY UPDATE spec = VALUES(spec)"
+	default:
+		return nil, errInvalidDialect
+	}
+	return &Store{db: db, table: table, upsert: upsert}, nil
+}
+
+// CreateTable creates the store table if it doesn't exist
+func (s *Store) CreateTable() error {
+	_, err := s.db.Exec("CREATE TABLE IF NOT EXISTS " + s.table + " (user_id VARCHAR(255) PRIMARY KEY, spec TEXT NOT NULL)")
+	return err
+}
+
+// Get returns the spec stored for userID or govatar.ErrSpecNotFound
+func (s *Store) Get(userID string) (govatar.Spec, error) {
+	var spec govatar.Spec
+	var data string
+	err := s.db.QueryRow("SELECT spec FROM "+s.table+" WHERE user_id = ?", userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return spec, govatar.ErrSpecNotFound
+	}
+	if err != nil {
+		return spec, err
+	}
+	err = json.Unmarshal([]byte(data), &spec)
+	return spec, err
+}
+
+// Put stores spec for userID replacing any previous one in a single upsert,
+// so concurrent puts for a new user don't fail on the primary key
+func (s *Store) Put(userID string, spec govatar.Spec) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(s.upsert, userID, string(data))
+	return err
+}
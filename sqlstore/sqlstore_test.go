@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/recoilme/govatar"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+// openMemory returns an in-memory SQLite database. A single connection keeps
+// every query on the same database.
+func openMemory(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestStore(t *testing.T) {
+	db := openMemory(t)
+	s, err := New(db, "specs", SQLite)
+	assert.NoError(t, err)
+	assert.NoError(t, s.CreateTable())
+	assert.NoError(t, s.CreateTable())
+
+	_, err = s.Get("42")
+	assert.Equal(t, govatar.ErrSpecNotFound, err)
+
+	spec := govatar.Spec{Gender: govatar.FEMALE, Background: 1, Face: 2, Clothes: 3, Mouth: 4, Hair: 5, Eye: 6}
+	assert.NoError(t, s.Put("42", spec))
+	stored, err := s.Get("42")
+	assert.NoError(t, err)
+	assert.Equal(t, spec, stored)
+
+	// Putting the same spec again is fine
+	assert.NoError(t, s.Put("42", spec))
+
+	spec.Hair = 7
+	assert.NoError(t, s.Put("42", spec))
+	stored, err = s.Get("42")
+	assert.NoError(t, err)
+	assert.Equal(t, spec, stored)
+	var n int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM specs").Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+func TestConcurrentPut(t *testing.T) {
+	s, err := New(openMemory(t), "specs", SQLite)
+	assert.NoError(t, err)
+	assert.NoError(t, s.CreateTable())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.Put("42", govatar.Spec{Gender: govatar.MALE, Hair: i}))
+		}(i)
+	}
+	wg.Wait()
+	_, err = s.Get("42")
+	assert.NoError(t, err)
+}
+
+func TestNew(t *testing.T) {
+	db := openMemory(t)
+	for _, table := range []string{"", "1specs", "specs; DROP TABLE users", "specs-v2", `"specs"`} {
+		_, err := New(db, table, SQLite)
+		assert.Equal(t, errInvalidTable, err, table)
+	}
+	for i, table := range []string{"specs", "_specs", "user_specs2"} {
+		_, err := New(db, table, []Dialect{SQLite, MySQL, SQLite}[i])
+		assert.NoError(t, err, table)
+	}
+	_, err := New(db, "specs", Dialect("oracle"))
+	assert.Equal(t, errInvalidDialect, err)
+}
@@ -0,0 +1,94 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+var (
+	errNoSpec        = errors.New("No spec embedded in image")
+	errSpecDoesntFit = errors.New("Image too narrow to embed spec")
+)
+
+// specMagic marks the start of an embedded spec
+var specMagic = []byte("gv2")
+
+// EmbedSpec returns a copy of img with spec hidden in the low-order bits of
+// its first pixel row. The spec survives lossless formats (png) only.
+// Every pixel holds 3 bits, so specs need images about 100 pixels wide,
+// more with accessories.
+func EmbedSpec(img image.Image, spec Spec) (image.Image, error) {
+	data := encodeSpec(spec)
+	pixels := specPixels(img.Bounds())
+	if len(data)*8 > 3*len(pixels) {
+		return nil, errSpecDoesntFit
+	}
+	dst := image.NewNRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	writeLowBits(dst, pixels, data)
+	return dst, nil
+}
+
+// RecoverSpec extracts the spec hidden in img by EmbedSpec
+func RecoverSpec(img image.Image) (Spec, error) {
+	pixels := specPixels(img.Bounds())
+	header := readLowBits(img, pixels, len(specMagic)+2)
+	n := int(binary.BigEndian.Uint16(header[len(specMagic):]))
+	return decodeSpec(readLowBits(img, pixels, len(header)+n+4))
+}
+
+// encodeSpec returns the magic, the length of the formatted spec, the
+// formatted spec and a checksum of all of them
+func encodeSpec(spec Spec) []byte {
+	formatted := FormatSpec(spec)
+	buf := bytes.NewBuffer(append([]byte(nil), specMagic...))
+	binary.Write(buf, binary.BigEndian, uint16(len(formatted)))
+	buf.WriteString(formatted)
+	binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+func decodeSpec(data []byte) (Spec, error) {
+	n := len(data) - 4
+	if !bytes.HasPrefix(data, specMagic) || binary.BigEndian.Uint32(data[n:]) != crc32.ChecksumIEEE(data[:n]) {
+		return Spec{}, errNoSpec
+	}
+	return ParseSpec(string(data[len(specMagic)+2 : n]))
+}
+
+// specPixels returns the pixels of the first row of bounds
+func specPixels(bounds image.Rectangle) []image.Point {
+	pixels := make([]image.Point, 0, bounds.Dx())
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		pixels = append(pixels, image.Pt(x, bounds.Min.Y))
+	}
+	return pixels
+}
+
+// writeLowBits stores data in the least significant bit of the R, G and B
+// channels of the given pixels. Data that doesn't fit is dropped.
+func writeLowBits(img *image.NRGBA, pixels []image.Point, data []byte) {
+	for bit := 0; bit < len(data)*8 && bit/3 < len(pixels); bit++ {
+		p := pixels[bit/3]
+		i := img.PixOffset(p.X, p.Y) + bit%3
+		v := data[bit/8] >> uint(7-bit%8) & 1
+		img.Pix[i] = img.Pix[i]&^1 | v
+	}
+}
+
+// readLowBits reads n bytes stored by writeLowBits
+func readLowBits(img image.Image, pixels []image.Point, n int) []byte {
+	data := make([]byte, n)
+	for bit := 0; bit < n*8 && bit/3 < len(pixels); bit++ {
+		p := pixels[bit/3]
+		c := color.NRGBAModel.Convert(img.At(p.X, p.Y)).(color.NRGBA)
+		v := [3]uint8{c.R, c.G, c.B}[bit%3] & 1
+		data[bit/8] |= v << uint(7-bit%8)
+	}
+	return data
+}
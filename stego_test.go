@@ -0,0 +1,73 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbedSpec(t *testing.T) {
+	spec, err := SpecFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	avatar, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+
+	img, err := EmbedSpec(avatar, spec)
+	assert.NoError(t, err)
+	assert.Equal(t, avatar.Bounds(), img.Bounds())
+
+	// Spec survives png round trip
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	decoded, _, err := image.Decode(&buf)
+	assert.NoError(t, err)
+
+	recovered, err := RecoverSpec(decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, spec, recovered)
+
+	regenerated, err := GenerateFromSpec(recovered)
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(avatar, regenerated))
+}
+
+func TestEmbedSpecAccessories(t *testing.T) {
+	withStyles(t)
+	assert.NoError(t, Register("hatted", accessorizedFS(t)))
+	gender, _ := LookupStyle("hatted")
+	spec := NewBuilder(gender).Accessory("hat", 1).Accessory("glasses", 0).Spec()
+	avatar, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+
+	img, err := EmbedSpec(avatar, spec)
+	assert.NoError(t, err)
+	recovered, err := RecoverSpec(img)
+	assert.NoError(t, err)
+	assert.Equal(t, spec, recovered)
+	regenerated, err := GenerateFromSpec(recovered)
+	assert.NoError(t, err)
+	assert.True(t, areImagesEquals(avatar, regenerated))
+}
+
+func TestEmbedSpecDoesntFit(t *testing.T) {
+	spec, err := SpecFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	avatar, err := GenerateFromSpec(spec, WithSize(32))
+	assert.NoError(t, err)
+
+	_, err = EmbedSpec(avatar, spec)
+	assert.Equal(t, errSpecDoesntFit, err)
+	_, err = EmbedSpec(image.NewNRGBA(image.Rectangle{}), spec)
+	assert.Equal(t, errSpecDoesntFit, err)
+}
+
+func TestRecoverSpecMissing(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+
+	_, err = RecoverSpec(avatar)
+	assert.Equal(t, errNoSpec, err)
+}
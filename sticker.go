@@ -0,0 +1,107 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// GenerateStickerFromUsername generates avatar from string without background
+// and turns it into a sticker
+func GenerateStickerFromUsername(gender Gender, username string) (image.Image, error) {
+	img, err := GenerateFromUsername(gender, username, WithTransparent())
+	if err != nil {
+		return nil, err
+	}
+	return Sticker(img), nil
+}
+
+// Sticker shrinks the visible part of img to make room, surrounds it with a
+// thick white contour and a soft drop shadow, and keeps the rest transparent.
+// img is expected to have a transparent background.
+func Sticker(img image.Image) image.Image {
+	bounds := img.Bounds()
+	size := bounds.Dx()
+	if bounds.Dy() < size {
+		size = bounds.Dy()
+	}
+	outline := size/40 + 1
+	margin := 2 * outline
+
+	// Fit the character inside the margin
+	character := image.NewNRGBA(bounds)
+	xdraw.CatmullRom.Scale(character, bounds.Inset(margin), img, bounds, draw.Src, nil)
+
+	contour := dilateAlpha(character, outline)
+	shadow := boxBlurAlpha(contour, bounds.Dx(), bounds.Dy(), outline/2+1)
+
+	dst := image.NewNRGBA(bounds)
+	w := bounds.Dx()
+	offset := outline / 2
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sx, sy := x-offset-bounds.Min.X, y-offset-bounds.Min.Y
+			if sx >= 0 && sy >= 0 {
+				if a := shadow[sy*w+sx] / 4; a > 0 {
+					dst.SetNRGBA(x, y, color.NRGBA{0, 0, 0, a})
+				}
+			}
+		}
+	}
+	white := image.NewUniform(color.White)
+	draw.DrawMask(dst, bounds, white, image.Point{}, &image.Alpha{Pix: contour, Stride: w, Rect: bounds}, bounds.Min, draw.Over)
+	draw.Draw(dst, bounds, character, bounds.Min, draw.Over)
+	return dst
+}
+
+// dilateAlpha returns alpha mask of img grown by radius pixels
+func dilateAlpha(img *image.NRGBA, radius int) []uint8 {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	mask := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := img.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y).A
+			if a == 0 {
+				continue
+			}
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					nx, ny := x+dx, y+dy
+					if dx*dx+dy*dy > radius*radius || nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					if i := ny*w + nx; mask[i] < a {
+						mask[i] = a
+					}
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// boxBlurAlpha returns w by h mask blurred with a box of the given radius
+func boxBlurAlpha(mask []uint8, w, h, radius int) []uint8 {
+	blur := func(src []uint8, step, lines, length, lineStep int) []uint8 {
+		dst := make([]uint8, len(src))
+		for l := 0; l < lines; l++ {
+			start := l * lineStep
+			for i := 0; i < length; i++ {
+				var sum, n int
+				for k := i - radius; k <= i+radius; k++ {
+					if k >= 0 && k < length {
+						sum += int(src[start+k*step])
+						n++
+					}
+				}
+				dst[start+i*step] = uint8(sum / n)
+			}
+		}
+		return dst
+	}
+	horizontal := blur(mask, 1, h, w, w)
+	return blur(horizontal, w, w, h, 1)
+}
@@ -0,0 +1,46 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSticker(t *testing.T) {
+	img, err := GenerateStickerFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+
+	// Corners are transparent and the edge of the character is white
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0), a)
+	for x := 0; x < 200; x++ {
+		c := color.NRGBAModel.Convert(img.At(x, 200)).(color.NRGBA)
+		if c.A == 0xff {
+			assert.Equal(t, color.NRGBA{0xff, 0xff, 0xff, 0xff}, c)
+			break
+		}
+	}
+
+	_, err = GenerateStickerFromUsername(Gender(-1), "username@site.com")
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestDilateAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 9, 9))
+	img.SetNRGBA(4, 4, color.NRGBA{A: 0xff})
+	mask := dilateAlpha(img, 2)
+	assert.Equal(t, uint8(0xff), mask[4*9+6])
+	assert.Equal(t, uint8(0xff), mask[2*9+4])
+	assert.Equal(t, uint8(0), mask[2*9+2])
+	assert.Equal(t, uint8(0), mask[4*9+7])
+}
+
+func TestBoxBlurAlpha(t *testing.T) {
+	mask := []uint8{0, 0, 0, 0, 90, 0, 0, 0, 0}
+	blurred := boxBlurAlpha(mask, 3, 3, 1)
+	assert.Equal(t, uint8(10), blurred[4])
+	assert.Equal(t, uint8(22), blurred[0])
+}
@@ -0,0 +1,129 @@
+package govatar
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ErrNotStored is returned by Storage when nothing is stored at a key
+var ErrNotStored = errors.New("Avatar not stored")
+
+// Storage persists encoded avatars, e.g. in S3, GCS or MinIO buckets.
+// Keys are slash separated paths ending in the format extension.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Get returns the avatar stored at key or ErrNotStored
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores avatar at key with its MIME type
+	Put(ctx context.Context, key string, avatar []byte, contentType string) error
+	// Exists reports whether an avatar is stored at key
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// GetOrGenerate returns the avatar of username encoded in format
+// (png, jpeg, jpg, gif, webp, avif, svg) from s. If it is not stored yet,
+// it is generated and stored.
+func GetOrGenerate(ctx context.Context, s Storage, gender Gender, username string, format string, opts ...Option) ([]byte, error) {
+	return std().GetOrGenerate(ctx, s, gender, username, format, opts...)
+}
+
+// StorageKey returns the key GetOrGenerate stores the avatar at
+func StorageKey(gender Gender, username string, format string, opts ...Option) (string, error) {
+	return std().StorageKey(gender, username, format, opts...)
+}
+
+// GetOrGenerate returns the avatar of username encoded in format
+// (png, jpeg, jpg, gif, webp, avif, svg) from s. If it is not stored yet,
+// it is generated and stored.
+func (g *Generator) GetOrGenerate(ctx context.Context, s Storage, gender Gender, username string, format string, opts ...Option) ([]byte, error) {
+	key, err := g.StorageKey(gender, username, format, opts...)
+	if err != nil {
+		return nil, err
+	}
+	avatar, err := s.Get(ctx, key)
+	if err != ErrNotStored {
+		return avatar, err
+	}
+	if avatar, err = g.encodeFromUsername(gender, username, format, append(opts, WithContext(ctx))); err != nil {
+		return nil, err
+	}
+	return avatar, s.Put(ctx, key, avatar, MIMEType(format))
+}
+
+// StorageKey returns the key GetOrGenerate stores the avatar at. Keys have
+// the form female/<hash>.png and change with the assets of g.
+func (g *Generator) StorageKey(gender Gender, username string, format string, opts ...Option) (string, error) {
+	if MIMEType(format) == "" {
+		return "", errUnknownFormat
+	}
+	name := genderName(gender)
+	if name == "" {
+		return "", errUnknownGender
+	}
+	o, err := g.options(opts)
+	if err != nil {
+		return "", err
+	}
+	return name + "/" + g.cacheKey(gender, username, format, o) + "." + normalizeFormat(format), nil
+}
+
+// DirStorage is a Storage keeping avatars as files in a directory, for
+// development and single machine deployments
+type DirStorage struct {
+	dir string
+}
+
+// NewDirStorage returns a storage in dir
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{dir: dir}
+}
+
+// Get implements Storage
+func (s *DirStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	avatar, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotStored
+	}
+	return avatar, err
+}
+
+// Put implements Storage
+func (s *DirStorage) Put(ctx context.Context, key string, avatar []byte, contentType string) error {
+	file := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(avatar)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Exists implements Storage
+func (s *DirStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(s.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// path returns the file of key, which can not point outside the directory
+func (s *DirStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+}
@@ -0,0 +1,75 @@
+package govatar
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStorage counts puts to a DirStorage
+type countingStorage struct {
+	*DirStorage
+	puts int
+}
+
+func (s *countingStorage) Put(ctx context.Context, key string, avatar []byte, contentType string) error {
+	s.puts++
+	return s.DirStorage.Put(ctx, key, avatar, contentType)
+}
+
+func TestGetOrGenerate(t *testing.T) {
+	ctx := context.Background()
+	s := &countingStorage{DirStorage: NewDirStorage(t.TempDir())}
+
+	b, err := GetOrGenerate(ctx, s, FEMALE, "username@site.com", "png", WithSize(64))
+	assert.NoError(t, err)
+	stored, err := GetOrGenerate(ctx, s, FEMALE, "username@site.com", "png", WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, b, stored)
+	assert.Equal(t, 1, s.puts)
+
+	generated, err := GenerateBytesFromUsername(FEMALE, "username@site.com", "png", WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, generated, b)
+
+	key, err := StorageKey(FEMALE, "username@site.com", "png", WithSize(64))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "female/"))
+	assert.True(t, strings.HasSuffix(key, ".png"))
+	ok, err := s.Exists(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	svg, err := GetOrGenerate(ctx, s, MALE, "username@site.com", "svg")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(svg), "<svg"))
+
+	_, err = GetOrGenerate(ctx, s, MALE, "username@site.com", "bmp")
+	assert.Equal(t, errUnknownFormat, err)
+	_, err = StorageKey(Gender(9), "username@site.com", "png")
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestDirStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := NewDirStorage(filepath.Join(dir, "avatars"))
+
+	_, err := s.Get(ctx, "male/a.png")
+	assert.Equal(t, ErrNotStored, err)
+	ok, err := s.Exists(ctx, "male/a.png")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Put(ctx, "male/a.png", []byte("a"), "image/png"))
+	b, err := s.Get(ctx, "male/a.png")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), b)
+
+	// Keys stay inside the directory
+	assert.NoError(t, s.Put(ctx, "../../escape.png", []byte("b"), "image/png"))
+	assert.FileExists(t, filepath.Join(dir, "avatars", "escape.png"))
+}
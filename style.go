@@ -0,0 +1,226 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+var (
+	errInvalidStyleName = errors.New("Invalid style name")
+	errStyleExists      = errors.New("Style already registered")
+	errUnknownStyle     = errors.New("Unknown style")
+)
+
+// stylePrefix starts asset paths of registered styles, followed by the
+// style name and the path in its file system
+const stylePrefix = "style:"
+
+// registeredStyle is a style added with Register
+type registeredStyle struct {
+	name   string
+	fsys   fs.FS
+	person person
+	// license is the artwork license of the style manifest, nil if none
+	license *License
+}
+
+var (
+	stylesMu sync.RWMutex
+	// styles holds registered styles, the style of Gender NEUTRAL+1+i at i
+	styles []registeredStyle
+)
+
+// Register adds a style drawn from the artwork in fsys, which has the layout
+// of a gender directory: face, clothes, mouth, hair and eye, and optionally
+// extra layers such as earrings, beard, glasses and hat. A manifest.json in
+// fsys orders the layers like the manifest of a pack. Avatars of the
+// style are drawn over the backgrounds of the generator drawing them.
+// Builtin styles are male, female, monster and neutral. Names are lowercase letters,
+// digits, - and _, other than the builtin names, human and the gender codes
+// of specs (m, f, x and n).
+//
+// The style gets a Gender of its own, so registered styles work everywhere
+// genders do: ParseGender, specs, descriptors, builders and editors.
+func Register(name string, fsys fs.FS) error {
+	if !validStyleName(name) {
+		return errInvalidStyleName
+	}
+	for _, layer := range personLayers {
+		info, err := fs.Stat(fsys, layer)
+		if err != nil || !info.IsDir() {
+			return ErrAssetsNotFound
+		}
+	}
+	stylesMu.Lock()
+	defer stylesMu.Unlock()
+	if _, ok := lookupStyle(name); ok {
+		return errStyleExists
+	}
+	src := fsSource{fsys}
+	manifest := readManifest(src, ".")
+	p := loadPerson(src, ".", "", manifest).withPrefix(stylePrefix + name + "/")
+	st := registeredStyle{name: name, fsys: fsys, person: p}
+	if manifest != nil {
+		st.license = manifest.License
+	}
+	styles = append(styles, st)
+	return nil
+}
+
+// StyleLicense returns the artwork license the manifest of the registered
+// style name declares. ok is false for builtin styles, whose license is the
+// one of the assets, and styles declaring none.
+func StyleLicense(name string) (license License, ok bool) {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	for _, st := range styles {
+		if st.name == name && st.license != nil {
+			return *st.license, true
+		}
+	}
+	return License{}, false
+}
+
+// LookupStyle returns the gender of the builtin or registered style name
+func LookupStyle(name string) (Gender, bool) {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	return lookupStyle(name)
+}
+
+func lookupStyle(name string) (Gender, bool) {
+	for g := MALE; g <= NEUTRAL; g++ {
+		if genderName(g) == name {
+			return g, true
+		}
+	}
+	for i, st := range styles {
+		if st.name == name {
+			return NEUTRAL + 1 + Gender(i), true
+		}
+	}
+	return 0, false
+}
+
+// ParseStyle returns the gender drawing avatars of style for avatars of
+// gender g. Empty style keeps g, human keeps g but draws monsters as male,
+// monster draws monsters, neutral draws neutral avatars and other styles are
+// those added with Register.
+func ParseStyle(style string, g Gender) (Gender, error) {
+	switch style {
+	case "":
+		return g, nil
+	case "human":
+		if g == MONSTER {
+			return MALE, nil
+		}
+		return g, nil
+	case "monster":
+		return MONSTER, nil
+	}
+	if s, ok := LookupStyle(style); ok && s != MALE && s != FEMALE {
+		return s, nil
+	}
+	return 0, errUnknownStyle
+}
+
+// Styles returns names of builtin and registered styles in registration order
+func Styles() []string {
+	var names []string
+	for _, g := range genders() {
+		names = append(names, genderName(g))
+	}
+	return names
+}
+
+// GenerateStyle generates avatar of style name from username
+func GenerateStyle(name, username string, opts ...Option) (image.Image, error) {
+	return std().GenerateStyle(name, username, opts...)
+}
+
+// GenerateStyle generates avatar of style name from username with g
+func (g *Generator) GenerateStyle(name, username string, opts ...Option) (image.Image, error) {
+	gender, ok := LookupStyle(name)
+	if !ok {
+		return nil, errUnknownGender
+	}
+	return g.GenerateFromUsername(gender, username, opts...)
+}
+
+// genders returns genders of builtin and registered styles
+func genders() []Gender {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	gs := []Gender{MALE, FEMALE, MONSTER, NEUTRAL}
+	for i := range styles {
+		gs = append(gs, NEUTRAL+1+Gender(i))
+	}
+	return gs
+}
+
+// style returns the registered style of gender
+func style(gender Gender) (registeredStyle, bool) {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	i := int(gender - NEUTRAL - 1)
+	if i < 0 || i >= len(styles) {
+		return registeredStyle{}, false
+	}
+	return styles[i], true
+}
+
+// open opens asset of s or of a registered style
+func (s *store) open(asset string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(asset, stylePrefix) {
+		return s.source.open(asset)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(asset, stylePrefix), "/", 2)
+	g, ok := LookupStyle(parts[0])
+	if !ok || len(parts) != 2 {
+		return nil, errUnknownGender
+	}
+	st, _ := style(g)
+	return st.fsys.Open(parts[1])
+}
+
+func validStyleName(name string) bool {
+	if name == "" || reservedStyleName(name) {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// reservedStyleName reports whether name is taken by the builtin genders:
+// their names, their codes in specs and human, which picks a gender
+func reservedStyleName(name string) bool {
+	if name == "human" {
+		return true
+	}
+	for g, code := range specGenderCodes {
+		if name == code || name == genderName(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// mustRegisterEmbedded registers the style name drawn from directory dir of
+// fsys, for builtin styles with artwork compiled into the binary
+func mustRegisterEmbedded(name string, fsys fs.FS, dir string) {
+	sub, err := fs.Sub(fsys, dir)
+	if err == nil {
+		err = Register(name, sub)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
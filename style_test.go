@@ -0,0 +1,134 @@
+package govatar
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withStyles restores the style registry when the test ends
+func withStyles(t *testing.T) {
+	saved := styles
+	t.Cleanup(func() { styles = saved })
+}
+
+func TestRegister(t *testing.T) {
+	withStyles(t)
+	names := Styles()
+	assert.Equal(t, []string{"male", "female", "monster", "neutral"}, names[:4])
+	assert.NoError(t, Register("custom", os.DirFS("data/monster")))
+	assert.Equal(t, append(names, "custom"), Styles())
+
+	custom, ok := LookupStyle("custom")
+	assert.True(t, ok)
+	assert.Equal(t, "custom", custom.String())
+	parsed, err := ParseGender("Custom")
+	assert.NoError(t, err)
+	assert.Equal(t, custom, parsed)
+	monster, ok := LookupStyle("monster")
+	assert.True(t, ok)
+	assert.Equal(t, MONSTER, monster)
+	_, ok = LookupStyle("alien")
+	assert.False(t, ok)
+
+	// The same artwork draws the same avatars
+	img, err := GenerateStyle("custom", "username@site.com", WithSize(64))
+	assert.NoError(t, err)
+	expected, err := GenerateFromUsername(MONSTER, "username@site.com", WithSize(64))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, img)
+	svg, err := GenerateSVGFromUsername(custom, "username@site.com")
+	assert.NoError(t, err)
+	expectedSVG, err := GenerateSVGFromUsername(MONSTER, "username@site.com")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSVG, svg)
+
+	spec, err := SpecFromUsername(custom, "username@site.com")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^v1:custom:`, FormatSpec(spec))
+	parsedSpec, err := ParseSpec(FormatSpec(spec))
+	assert.NoError(t, err)
+	assert.Equal(t, spec, parsedSpec)
+
+	c := GetCatalog()
+	assert.Len(t, c.Genders, len(names)+1)
+	assert.Equal(t, "custom", c.Genders[len(names)].Name)
+
+	customKey := std().cacheKey(custom, "username", "png", options{})
+	assert.NotEqual(t, std().cacheKey(MONSTER, "username", "png", options{}), customKey)
+
+	_, err = GenerateStyle("alien", "username")
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestParseStyle(t *testing.T) {
+	withStyles(t)
+	assert.NoError(t, Register("custom", os.DirFS("data/monster")))
+	custom, _ := LookupStyle("custom")
+	for _, c := range []struct {
+		style    string
+		g        Gender
+		expected Gender
+	}{
+		{"", FEMALE, FEMALE},
+		{"human", FEMALE, FEMALE},
+		{"human", MONSTER, MALE},
+		{"monster", FEMALE, MONSTER},
+		{"neutral", MALE, NEUTRAL},
+		{"custom", MALE, custom},
+	} {
+		g, err := ParseStyle(c.style, c.g)
+		assert.NoError(t, err, c.style)
+		assert.Equal(t, c.expected, g, c.style)
+	}
+	for _, style := range []string{"male", "female", "alien"} {
+		_, err := ParseStyle(style, MALE)
+		assert.Equal(t, errUnknownStyle, err, style)
+	}
+}
+
+func TestStyleLicense(t *testing.T) {
+	withStyles(t)
+	fsys := fstest.MapFS{}
+	assert.NoError(t, fs.WalkDir(os.DirFS("data/monster"), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile("data/monster/" + name)
+		fsys[name] = &fstest.MapFile{Data: data}
+		return err
+	}))
+	assert.NoError(t, Register("custom", fsys))
+	_, ok := StyleLicense("custom")
+	assert.False(t, ok)
+
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"license": {"name": "CC0-1.0"}}`)}
+	assert.NoError(t, Register("licensed", fsys))
+	license, ok := StyleLicense("licensed")
+	assert.True(t, ok)
+	assert.Equal(t, "CC0-1.0", license.Name)
+	_, ok = StyleLicense("monster")
+	assert.False(t, ok)
+}
+
+func TestRegisterErrors(t *testing.T) {
+	withStyles(t)
+	names := Styles()
+	// Builtin names and spec codes are reserved
+	for _, name := range []string{"", "Custom", "cus/tom", "custom:x", "male", "female", "monster", "neutral", "human", "m", "f", "x", "n"} {
+		assert.Equal(t, errInvalidStyleName, Register(name, os.DirFS("data/monster")), name)
+	}
+	assert.NoError(t, Register("custom-2", os.DirFS("data/monster")))
+	assert.Equal(t, errStyleExists, Register("custom-2", os.DirFS("data/monster")))
+	assert.Equal(t, ErrAssetsNotFound, Register("empty", fstest.MapFS{}))
+	assert.Equal(t, append(names, "custom-2"), Styles())
+
+	// Builtin codes win over styles of the same name
+	styles = append(styles, registeredStyle{name: "m"})
+	spec, err := ParseSpec("v1:m:f0.c0.h0.e0.m0.b0")
+	assert.NoError(t, err)
+	assert.Equal(t, MALE, spec.Gender)
+}
@@ -0,0 +1,101 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+)
+
+// Duotone reduces img to two high contrast colors derived from seed:
+// pixels darker than the average become the shadow color, the rest the
+// highlight color. Transparency is kept. Use the same seed for a user to
+// keep their colors stable.
+func Duotone(img image.Image, seed int64) image.Image {
+	scheme := NewScheme(seed)
+	shadow := hslToRGB(scheme.Hue, 0.6, 0.2)
+	highlight := hslToRGB(scheme.Hue+180, 0.7, 0.85)
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(bounds)
+	threshold := averageLuma(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			tone := highlight
+			if luma(c) < threshold {
+				tone = shadow
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{tone.R, tone.G, tone.B, c.A})
+		}
+	}
+	return dst
+}
+
+// luma returns perceived brightness of c from 0 to 255
+func luma(c color.NRGBA) float64 {
+	return 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+}
+
+// averageLuma returns average brightness of the visible pixels of img
+func averageLuma(img image.Image) float64 {
+	bounds := img.Bounds()
+	var sum float64
+	var n int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+			sum += luma(c)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// lineArtThreshold is the Sobel gradient magnitude above which a pixel is an edge
+const lineArtThreshold = 96
+
+// LineArt extracts the outlines of img and draws them in a single color c on
+// a transparent background, for printing, engraving or tinting via CSS.
+func LineArt(img image.Image, c color.Color) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	// Brightness premultiplied by alpha so edges against transparency count too
+	values := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			values[y*w+x] = luma(p) * float64(p.A) / 0xff
+		}
+	}
+	at := func(x, y int) float64 {
+		if x < 0 {
+			x = 0
+		} else if x >= w {
+			x = w - 1
+		}
+		if y < 0 {
+			y = 0
+		} else if y >= h {
+			y = h - 1
+		}
+		return values[y*w+x]
+	}
+
+	line := color.NRGBAModel.Convert(c).(color.NRGBA)
+	dst := image.NewNRGBA(bounds)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			if gx*gx+gy*gy > lineArtThreshold*lineArtThreshold {
+				dst.SetNRGBA(bounds.Min.X+x, bounds.Min.Y+y, line)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,68 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuotone(t *testing.T) {
+	avatar, err := GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+
+	img := Duotone(avatar, 42)
+	assert.Equal(t, avatar.Bounds(), img.Bounds())
+	assert.Len(t, distinctOpaqueColors(img), 2)
+	assert.True(t, areImagesEquals(img, Duotone(avatar, 42)))
+	assert.False(t, areImagesEquals(img, Duotone(avatar, 43)))
+
+	// Transparent pixels stay transparent
+	transparent := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	transparent.SetNRGBA(1, 0, color.NRGBA{10, 10, 10, 255})
+	img = Duotone(transparent, 42)
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0), a)
+}
+
+// distinctOpaqueColors returns colors of img ignoring alpha
+func distinctOpaqueColors(img image.Image) map[color.NRGBA]struct{} {
+	colors := make(map[color.NRGBA]struct{})
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			c.A = 0xff
+			colors[c] = struct{}{}
+		}
+	}
+	return colors
+}
+
+func TestLineArt(t *testing.T) {
+	avatar, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+
+	black := color.NRGBA{0, 0, 0, 0xff}
+	img := LineArt(avatar, black)
+	assert.Equal(t, avatar.Bounds(), img.Bounds())
+
+	colors := make(map[color.Color]int)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			colors[img.At(x, y)]++
+		}
+	}
+	assert.Len(t, colors, 2)
+	assert.True(t, colors[black] > 0)
+	assert.True(t, colors[color.NRGBA{}] > colors[black])
+
+	// Flat image has no outlines
+	flat := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	img = LineArt(flat, black)
+	assert.True(t, areImagesEquals(image.NewNRGBA(flat.Bounds()), img))
+}
@@ -0,0 +1,61 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+)
+
+var errInvalidSupersample = errors.New("Invalid supersampling factor")
+
+// WithSupersample draws the avatar at factor times its size, from 1 to 4,
+// and scales it down averaging factor by factor pixels. Edges of circles,
+// rounded corners, frames and badges come out smoother at the cost of factor
+// squared the drawing time. Pixel art is drawn as is.
+func WithSupersample(factor int) Option {
+	return func(o *options) {
+		o.supersample = factor
+		if factor < 1 || factor > 4 {
+			o.err = errInvalidSupersample
+		}
+	}
+}
+
+// supersampled returns o drawing at its supersampling factor times the size,
+// with the sizes in pixels it sets scaled alike
+func (o options) supersampled() options {
+	k := o.supersample
+	o.supersample = 0
+	o.size *= k
+	o.radius *= k
+	if o.frame != nil {
+		f := *o.frame
+		f.width *= k
+		o.frame = &f
+	}
+	return o
+}
+
+// downsample returns img scaled down by factor, every pixel the average of
+// the factor by factor pixels it covers
+func downsample(img image.Image, factor int) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx()/factor, b.Dy()/factor))
+	n := uint32(factor * factor)
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			var r, g, bl, a uint32
+			for sy := 0; sy < factor; sy++ {
+				for sx := 0; sx < factor; sx++ {
+					pr, pg, pb, pa := img.At(b.Min.X+x*factor+sx, b.Min.Y+y*factor+sy).RGBA()
+					r, g, bl, a = r+pr, g+pg, bl+pb, a+pa
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,46 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownsample(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	img.Set(1, 1, color.RGBA{0xff, 0, 0, 0xff})
+	small := downsample(img, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), small.Bounds())
+	assert.Equal(t, color.RGBA{0x7f, 0, 0, 0x7f}, small.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{}, small.RGBAAt(1, 0))
+}
+
+func TestWithSupersample(t *testing.T) {
+	g, err := New(DefaultConfig())
+	assert.NoError(t, err)
+	for _, factor := range []int{0, 5} {
+		_, err = g.GenerateFromUsername(MALE, "username@site.com", WithSupersample(factor))
+		assert.Equal(t, errInvalidSupersample, err, factor)
+	}
+
+	plain, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithFrame(3, color.White))
+	assert.NoError(t, err)
+	same, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithFrame(3, color.White), WithSupersample(1))
+	assert.NoError(t, err)
+	assert.Equal(t, plain, same)
+
+	img, err := g.GenerateFromUsername(MALE, "username@site.com", WithSize(100), WithShape(Circle), WithFrame(3, color.White), WithSupersample(4))
+	assert.NoError(t, err)
+	assert.Equal(t, plain.Bounds(), img.Bounds())
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+	// The frame keeps its width in avatar pixels
+	assert.Equal(t, plain.At(50, 1), img.At(50, 1))
+	assert.NotEqual(t, plain, img)
+
+	// Supersampled avatars are cached apart
+	assert.NotEqual(t, g.cacheKey(MALE, "username", "png", options{}), g.cacheKey(MALE, "username", "png", options{supersample: 2}))
+}
@@ -0,0 +1,298 @@
+package govatar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// svgGrid is the number of cells per side assets are traced on. The artwork
+// is drawn on a grid of 20px blocks, 10px cells keep every block.
+const svgGrid = 40
+
+// svgMergeDistance is the largest channel difference of colors merged while tracing
+const svgMergeDistance = 24
+
+// svgID stands in for the prefix of element ids while a document is written.
+// It is replaced by a hash of the document, so avatars inlined in one page
+// share ids only if they are the same.
+const svgID = "\x00"
+
+// vectorLayer is an asset traced into flat colored rectangles
+type vectorLayer []vectorPath
+
+// vectorPath holds path data of all rectangles of one color
+type vectorPath struct {
+	color color.RGBA
+	d     string
+}
+
+// GenerateSVGFromUsername generates avatar from string as an SVG document
+// that scales crisply to any size. Traced layers are flat colored, fine
+// texture of the artwork is averaged out. WithSize sets the document size,
+// WithoutBackground, WithTransparent and the shape, overlay, frame, badge and
+// logo options work as for raster avatars.
+func GenerateSVGFromUsername(gender Gender, username string, opts ...Option) (string, error) {
+	return std().GenerateSVGFromUsername(gender, username, opts...)
+}
+
+// GenerateSVGFromSpec generates avatar from the parts listed in spec as an SVG document
+func GenerateSVGFromSpec(spec Spec, opts ...Option) (string, error) {
+	return std().GenerateSVGFromSpec(spec, opts...)
+}
+
+// GenerateSVGFromUsername generates avatar from string as an SVG document.
+// WithSeed picks the parts like for raster avatars.
+func (g *Generator) GenerateSVGFromUsername(gender Gender, username string, opts ...Option) (string, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return "", err
+	}
+	if o.renderer != nil {
+		return "", errRendererSVG
+	}
+	if !o.seeded {
+		o.seed = usernameSeed(username)
+	}
+	spec, err := g.selectParts(gender, o.seed, o)
+	if err != nil {
+		return "", err
+	}
+	return g.svg(spec, o)
+}
+
+// GenerateSVGFromSpec generates avatar from the parts listed in spec as an SVG document
+func (g *Generator) GenerateSVGFromSpec(spec Spec, opts ...Option) (string, error) {
+	o, err := g.options(opts)
+	if err != nil {
+		return "", err
+	}
+	if o.renderer != nil {
+		return "", errRendererSVG
+	}
+	return g.svg(spec, o)
+}
+
+// svg draws the avatar of spec with the layered artwork of g as an SVG document
+func (g *Generator) svg(spec Spec, o options) (string, error) {
+	layers, err := g.specAssets(spec, o)
+	if err != nil {
+		return "", err
+	}
+	if err = g.describe(spec, o); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, o.size, o.size, svgGrid, svgGrid)
+	// The document is drawn in grid units
+	radius := o.cornerRadius(o.size) * svgGrid / float64(o.size)
+	if radius > 0 {
+		fmt.Fprintf(&b, `<clipPath id="%[4]sshape"><rect width="%[1]d" height="%[2]d" rx="%[3]g" shape-rendering="geometricPrecision"/></clipPath><g clip-path="url(#%[4]sshape)">`, svgGrid, svgGrid, radius, svgID)
+	}
+	if !o.background {
+		// The first layer is background
+		layers = layers[1:]
+		switch {
+		case len(o.backgrounds) > 0:
+			uri, err := pngDataURI(o.backgrounds[pick(spec, len(o.backgrounds))])
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, `<image width="%d" height="%d" preserveAspectRatio="xMidYMid slice" href="%s"/>`, svgGrid, svgGrid, uri)
+		case !o.transparent:
+			fill := "#fff"
+			if len(o.palette) > 0 {
+				fill = svgPaint(o.fill(spec))
+			}
+			fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, svgGrid, svgGrid, fill)
+		}
+	}
+	for _, asset := range layers {
+		if err := o.context().Err(); err != nil {
+			return "", err
+		}
+		layer, err := g.store.vector(asset)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range layer {
+			fmt.Fprintf(&b, `<path fill="%s" d="%s"/>`, shortHexColor(p.color), p.d)
+		}
+	}
+	for _, ov := range o.overlays {
+		if err := ov.svg(&b); err != nil {
+			return "", err
+		}
+	}
+	if o.frame != nil {
+		o.frame.svg(&b, radius, float64(o.frame.width)*svgGrid/float64(o.size))
+	}
+	if radius > 0 {
+		b.WriteString(`</g>`)
+	}
+	if o.badge != nil {
+		if err := o.badge.svg(&b, o.size); err != nil {
+			return "", err
+		}
+	}
+	if o.logo != nil {
+		if err := o.logo.svg(&b, o.size); err != nil {
+			return "", err
+		}
+	}
+	b.WriteString(`</svg>`)
+	doc := b.String()
+	sum := sha256.Sum256([]byte(doc))
+	return strings.ReplaceAll(doc, svgID, "gv"+hex.EncodeToString(sum[:4])+"-"), nil
+}
+
+// vector returns asset traced into rectangles, tracing it on first use
+func (s *store) vector(asset string) (vectorLayer, error) {
+	if v, ok := s.vectors.Load(asset); ok {
+		return v.(vectorLayer), nil
+	}
+	img, err := s.decode(asset)
+	if err != nil {
+		return nil, err
+	}
+	layer := traceLayer(img)
+	s.vectors.Store(asset, layer)
+	return layer, nil
+}
+
+// traceLayer averages img over svgGrid cells, quantizes cell colors to
+// 4 bits per channel, merges close colors and joins equal cells into rectangles
+func traceLayer(img image.Image) vectorLayer {
+	bounds := img.Bounds()
+	var cells [svgGrid][svgGrid]struct {
+		c  color.RGBA
+		ok bool
+	}
+	for cy := 0; cy < svgGrid; cy++ {
+		for cx := 0; cx < svgGrid; cx++ {
+			x0, x1 := bounds.Min.X+cx*bounds.Dx()/svgGrid, bounds.Min.X+(cx+1)*bounds.Dx()/svgGrid
+			y0, y1 := bounds.Min.Y+cy*bounds.Dy()/svgGrid, bounds.Min.Y+(cy+1)*bounds.Dy()/svgGrid
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+					r += uint64(c.R) * uint64(c.A)
+					g += uint64(c.G) * uint64(c.A)
+					b += uint64(c.B) * uint64(c.A)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			// Cells less than half covered are left out
+			if n == 0 || a*2 < n*0xff {
+				continue
+			}
+			q := func(v uint64) uint8 { return uint8((v/a + 8) / 17 * 17) }
+			cells[cy][cx].c = color.RGBA{q(r), q(g), q(b), 0xff}
+			cells[cy][cx].ok = true
+		}
+	}
+
+	// Flatten texture by mapping every color to the most used one near it
+	counts := map[color.RGBA]int{}
+	for y := range cells {
+		for x := range cells[y] {
+			if cells[y][x].ok {
+				counts[cells[y][x].c]++
+			}
+		}
+	}
+	colors := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		if counts[colors[i]] != counts[colors[j]] {
+			return counts[colors[i]] > counts[colors[j]]
+		}
+		return hexColor(colors[i]) < hexColor(colors[j])
+	})
+	var palette []color.RGBA
+	flat := map[color.RGBA]color.RGBA{}
+	for _, c := range colors {
+		flat[c] = c
+		for _, p := range palette {
+			if colorDistance(c, p) <= svgMergeDistance {
+				flat[c] = p
+				break
+			}
+		}
+		if flat[c] == c {
+			palette = append(palette, c)
+		}
+	}
+	for y := range cells {
+		for x := range cells[y] {
+			cells[y][x].c = flat[cells[y][x].c]
+		}
+	}
+
+	var layer vectorLayer
+	index := map[color.RGBA]int{}
+	var done [svgGrid][svgGrid]bool
+	for y := 0; y < svgGrid; y++ {
+		for x := 0; x < svgGrid; x++ {
+			if done[y][x] || !cells[y][x].ok {
+				continue
+			}
+			c := cells[y][x].c
+			same := func(x, y int) bool { return !done[y][x] && cells[y][x].ok && cells[y][x].c == c }
+			w := 1
+			for x+w < svgGrid && same(x+w, y) {
+				w++
+			}
+			h := 1
+		grow:
+			for y+h < svgGrid {
+				for i := 0; i < w; i++ {
+					if !same(x+i, y+h) {
+						break grow
+					}
+				}
+				h++
+			}
+			for j := 0; j < h; j++ {
+				for i := 0; i < w; i++ {
+					done[y+j][x+i] = true
+				}
+			}
+			i, ok := index[c]
+			if !ok {
+				i = len(layer)
+				index[c] = i
+				layer = append(layer, vectorPath{color: c})
+			}
+			layer[i].d += fmt.Sprintf("M%d %dh%dv%dh-%dz", x, y, w, h, w)
+		}
+	}
+	return layer
+}
+
+// shortHexColor returns c, quantized to 4 bits per channel, in #rgb notation
+func shortHexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%x%x%x", c.R/17, c.G/17, c.B/17)
+}
+
+// colorDistance returns the largest channel difference of a and b
+func colorDistance(a, b color.RGBA) int {
+	d := 0
+	for _, v := range []int{int(a.R) - int(b.R), int(a.G) - int(b.G), int(a.B) - int(b.B)} {
+		if v < 0 {
+			v = -v
+		}
+		if v > d {
+			d = v
+		}
+	}
+	return d
+}
@@ -0,0 +1,97 @@
+package govatar
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/draw"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSVGFromUsername(t *testing.T) {
+	svg, err := GenerateSVGFromUsername(FEMALE, "username@site.com", WithSize(128))
+	assert.NoError(t, err)
+	var doc struct {
+		XMLName xml.Name
+		Width   int `xml:"width,attr"`
+		Paths   []struct {
+			Fill string `xml:"fill,attr"`
+		} `xml:"path"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(svg), &doc))
+	assert.Equal(t, "svg", doc.XMLName.Local)
+	assert.Equal(t, 128, doc.Width)
+	assert.NotEmpty(t, doc.Paths)
+
+	again, err := GenerateSVGFromUsername(FEMALE, "username@site.com", WithSize(128))
+	assert.NoError(t, err)
+	assert.Equal(t, svg, again)
+
+	png, err := GenerateBytesFromUsername(FEMALE, "username@site.com", "png")
+	assert.NoError(t, err)
+	assert.True(t, len(svg) < len(png)/10)
+
+	white, err := GenerateSVGFromUsername(FEMALE, "username@site.com", WithoutBackground())
+	assert.NoError(t, err)
+	assert.Contains(t, white, `<rect width="40" height="40" fill="#fff"/>`)
+	transparent, err := GenerateSVGFromUsername(FEMALE, "username@site.com", WithTransparent())
+	assert.NoError(t, err)
+	assert.NotContains(t, transparent, "<rect")
+	assert.True(t, len(transparent) < len(svg))
+
+	_, err = GenerateSVGFromUsername(Gender(100), "username@site.com")
+	assert.Equal(t, errUnknownGender, err)
+
+	// Seeds pick the parts like for raster avatars
+	spec, err := std().store.randomSpec(FEMALE, 42)
+	assert.NoError(t, err)
+	seeded, err := GenerateSVGFromUsername(FEMALE, "username@site.com", WithSeed(42))
+	assert.NoError(t, err)
+	fromSpec, err := GenerateSVGFromSpec(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, fromSpec, seeded)
+
+	_, err = GenerateSVGFromUsername(FEMALE, "username@site.com", WithRenderer(IdenticonRenderer))
+	assert.Equal(t, errRendererSVG, err)
+}
+
+func TestSVGIDs(t *testing.T) {
+	ids := regexp.MustCompile(`id="([^"]+)"`)
+	seen := map[string]bool{}
+	for _, opts := range [][]Option{
+		{WithShape(Circle)},
+		{WithShape(Circle), WithFrame(2, color.Black, color.White)},
+		{WithCornerRadius(20), WithFrame(2, color.White, color.Black)},
+	} {
+		svg, err := GenerateSVGFromUsername(FEMALE, "username@site.com", opts...)
+		assert.NoError(t, err)
+		again, err := GenerateSVGFromUsername(FEMALE, "username@site.com", opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, svg, again)
+		// Avatars inlined in one page must not share ids
+		for _, id := range ids.FindAllStringSubmatch(svg, -1) {
+			assert.False(t, seen[id[1]], id[1])
+			seen[id[1]] = true
+			assert.Contains(t, svg, "url(#"+id[1]+")")
+		}
+	}
+	assert.Len(t, seen, 5)
+}
+
+func TestTraceLayer(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+	draw.Draw(img, image.Rect(0, 0, 400, 200), image.NewUniform(color.RGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+	// Slightly different shade is merged into the red
+	draw.Draw(img, image.Rect(0, 0, 100, 100), image.NewUniform(color.RGBA{0xf0, 0x08, 0, 0xff}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 300, 260, 340), image.NewUniform(color.RGBA{0, 0, 0xff, 0xff}), image.Point{}, draw.Src)
+
+	layer := traceLayer(img)
+	assert.Len(t, layer, 2)
+	assert.Equal(t, "#f00", shortHexColor(layer[0].color))
+	assert.Equal(t, "M0 0h40v20h-40z", layer[0].d)
+	assert.Equal(t, "#00f", shortHexColor(layer[1].color))
+	assert.Equal(t, "M20 30h6v4h-6z", layer[1].d)
+}
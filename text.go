@@ -0,0 +1,77 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	regularFont = mustParseFont(goregular.TTF)
+	boldFont    = mustParseFont(gobold.TTF)
+)
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// fontFace returns face of f at size pixels
+func fontFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		// Only fails for invalid options
+		panic(err)
+	}
+	return face
+}
+
+// fitText returns a face of f not larger than size in which text fits into
+// width pixels, shrinking the font down to minSize and then cutting text
+// with an ellipsis
+func fitText(f *opentype.Font, text string, size, minSize float64, width int) (font.Face, string) {
+	for ; size > minSize; size -= 2 {
+		face := fontFace(f, size)
+		if font.MeasureString(face, text).Ceil() <= width {
+			return face, text
+		}
+		face.Close()
+	}
+	face := fontFace(f, minSize)
+	runes := []rune(text)
+	for len(runes) > 0 && font.MeasureString(face, string(runes)+"…").Ceil() > width {
+		runes = runes[:len(runes)-1]
+	}
+	if len(runes) == len([]rune(text)) {
+		return face, text
+	}
+	return face, string(runes) + "…"
+}
+
+// drawText draws text with its baseline starting at x, y
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// textColor returns black or white, whichever reads better on bg
+func textColor(bg color.Color) color.Color {
+	if luma(color.NRGBAModel.Convert(bg).(color.NRGBA)) > 140 {
+		return color.NRGBA{0x22, 0x22, 0x22, 0xff}
+	}
+	return color.White
+}
@@ -0,0 +1,176 @@
+package govatar
+
+import (
+	"errors"
+	"image/color"
+	"sort"
+	"sync"
+)
+
+var (
+	errInvalidTheme = errors.New("Invalid theme")
+	errThemeExists  = errors.New("Theme already registered")
+	errUnknownTheme = errors.New("Unknown theme")
+)
+
+// Theme gives all avatars of an app a coherent look
+type Theme struct {
+	// Backgrounds are the colors picked for the area around the character
+	// instead of the background artwork, which is kept if empty
+	Backgrounds []color.Color
+	// Colors are the colors tinted layers are moved to, the colors of the
+	// scheme of the parts if empty
+	Colors []color.Color
+	// Layers are tinted, e.g. "clothes"
+	Layers []string
+}
+
+var (
+	themesMu sync.RWMutex
+	// themes holds builtin and registered themes by name
+	themes = map[string]Theme{
+		"pastel": {
+			Backgrounds: []color.Color{
+				color.RGBA{0xff, 0xd1, 0xdc, 0xff},
+				color.RGBA{0xff, 0xe5, 0xb4, 0xff},
+				color.RGBA{0xff, 0xfa, 0xcd, 0xff},
+				color.RGBA{0xd5, 0xf5, 0xe3, 0xff},
+				color.RGBA{0xd6, 0xea, 0xf8, 0xff},
+				color.RGBA{0xe8, 0xda, 0xef, 0xff},
+			},
+			Colors: []color.Color{
+				color.RGBA{0xf4, 0xa6, 0xb7, 0xff},
+				color.RGBA{0xa8, 0xd8, 0xea, 0xff},
+				color.RGBA{0xb5, 0xea, 0xd7, 0xff},
+				color.RGBA{0xff, 0xda, 0xc1, 0xff},
+				color.RGBA{0xc7, 0xce, 0xea, 0xff},
+			},
+			Layers: []string{"clothes"},
+		},
+		"dark": {
+			Backgrounds: []color.Color{
+				color.RGBA{0x1e, 0x1e, 0x2e, 0xff},
+				color.RGBA{0x2e, 0x34, 0x40, 0xff},
+				color.RGBA{0x26, 0x32, 0x38, 0xff},
+				color.RGBA{0x21, 0x21, 0x21, 0xff},
+				color.RGBA{0x3b, 0x2f, 0x4a, 0xff},
+			},
+			Colors: []color.Color{
+				color.RGBA{0x37, 0x47, 0x4f, 0xff},
+				color.RGBA{0x4a, 0x14, 0x8c, 0xff},
+				color.RGBA{0x1b, 0x5e, 0x20, 0xff},
+				color.RGBA{0x88, 0x0e, 0x4f, 0xff},
+				color.RGBA{0x0d, 0x47, 0xa1, 0xff},
+			},
+			Layers: []string{"clothes"},
+		},
+		"neon": {
+			Backgrounds: []color.Color{
+				color.RGBA{0x39, 0xff, 0x14, 0xff},
+				color.RGBA{0xff, 0x07, 0x3a, 0xff},
+				color.RGBA{0x00, 0xff, 0xff, 0xff},
+				color.RGBA{0xff, 0x00, 0xff, 0xff},
+				color.RGBA{0xff, 0xff, 0x33, 0xff},
+			},
+			Colors: []color.Color{
+				color.RGBA{0xff, 0x00, 0xff, 0xff},
+				color.RGBA{0x00, 0xff, 0xff, 0xff},
+				color.RGBA{0x39, 0xff, 0x14, 0xff},
+				color.RGBA{0xff, 0x6e, 0xc7, 0xff},
+				color.RGBA{0x7d, 0xf9, 0xff, 0xff},
+			},
+			Layers: []string{"clothes"},
+		},
+		"corporate": {
+			Backgrounds: []color.Color{
+				color.RGBA{0xec, 0xef, 0xf1, 0xff},
+				color.RGBA{0xcf, 0xd8, 0xdc, 0xff},
+				color.RGBA{0xe3, 0xf2, 0xfd, 0xff},
+				color.RGBA{0xe8, 0xea, 0xf6, 0xff},
+				color.RGBA{0xf5, 0xf5, 0xf5, 0xff},
+			},
+			Colors: []color.Color{
+				color.RGBA{0x15, 0x65, 0xc0, 0xff},
+				color.RGBA{0x37, 0x47, 0x4f, 0xff},
+				color.RGBA{0x28, 0x35, 0x93, 0xff},
+				color.RGBA{0x00, 0x69, 0x5c, 0xff},
+				color.RGBA{0x5d, 0x40, 0x37, 0xff},
+			},
+			Layers: []string{"clothes"},
+		},
+	}
+)
+
+// WithTheme draws the avatar in the look of the builtin or registered theme
+// name: backgrounds are filled with its colors and its layers are tinted with
+// its colors closest to the scheme of the parts, see NewScheme. Layers tinted with WithTints
+// take colors of the theme too. Builtin themes are pastel, dark, neon and
+// corporate.
+func WithTheme(name string) Option {
+	return func(o *options) {
+		t, ok := LookupTheme(name)
+		if !ok {
+			o.err = errUnknownTheme
+			return
+		}
+		if len(t.Backgrounds) > 0 {
+			WithSolidBackground(t.Backgrounds...)(o)
+		}
+		o.tintPalette = t.Colors
+		WithTints(t.Layers...)(o)
+	}
+}
+
+// RegisterTheme makes theme available to WithTheme by name. Names are
+// lowercase letters, digits, - and _.
+func RegisterTheme(name string, theme Theme) error {
+	if !validStyleName(name) {
+		return errInvalidTheme
+	}
+	for _, c := range append(append([]color.Color{}, theme.Backgrounds...), theme.Colors...) {
+		if c == nil {
+			return errInvalidTheme
+		}
+	}
+	for _, layer := range theme.Layers {
+		if !validLayer(layer) {
+			return errInvalidTheme
+		}
+	}
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	if _, ok := themes[name]; ok {
+		return errThemeExists
+	}
+	themes[name] = theme.clone()
+	return nil
+}
+
+// LookupTheme returns the builtin or registered theme name
+func LookupTheme(name string) (Theme, bool) {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	t, ok := themes[name]
+	return t.clone(), ok
+}
+
+// Themes returns sorted names of builtin and registered themes
+func Themes() []string {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clone returns a copy of t that shares no slices with it
+func (t Theme) clone() Theme {
+	return Theme{
+		Backgrounds: append([]color.Color(nil), t.Backgrounds...),
+		Colors:      append([]color.Color(nil), t.Colors...),
+		Layers:      append([]string(nil), t.Layers...),
+	}
+}
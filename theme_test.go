@@ -0,0 +1,77 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTheme(t *testing.T) {
+	assert.Equal(t, []string{"corporate", "dark", "neon", "pastel"}, Themes())
+	pastel, ok := LookupTheme("pastel")
+	assert.True(t, ok)
+
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	img, err := GenerateFromSpec(spec, WithSize(100), WithTheme("pastel"))
+	assert.NoError(t, err)
+	assert.Contains(t, pastel.Backgrounds, color.Color(img.At(0, 0)))
+	again, err := GenerateFromSpec(spec, WithSize(100), WithTheme("pastel"))
+	assert.NoError(t, err)
+	assert.Equal(t, img, again)
+
+	// Themed layers take colors of the theme
+	o, err := std().options([]Option{WithTheme("pastel"), WithTints("hair")})
+	assert.NoError(t, err)
+	for _, layer := range []string{"clothes", "hair"} {
+		tint := o.tint(spec, layer)
+		assert.NotNil(t, tint)
+		assert.Contains(t, pastel.Colors, color.Color(color.RGBA(tint.tone)))
+	}
+	assert.Nil(t, o.tint(spec, "mouth"))
+
+	// The main color of the part becomes the theme color
+	tone := color.NRGBA{0x15, 0x65, 0xc0, 0xff}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{0xc0, 0x30, 0x30, 0xff})
+	src.SetNRGBA(1, 0, color.NRGBA{0x20, 0x20, 0x20, 0xff})
+	recolored := Tint{tone: tone}.apply(src)
+	assert.Equal(t, tone, recolored.At(0, 0))
+	assert.Equal(t, src.At(1, 0), recolored.At(1, 0))
+	// Gray parts are colored too
+	src.SetNRGBA(0, 0, color.NRGBA{0x30, 0x30, 0x30, 0xff})
+	recolored = Tint{tone: tone}.apply(src)
+	assert.Equal(t, tone, recolored.At(0, 0))
+	assert.NotEqual(t, src.At(1, 0), recolored.At(1, 0))
+
+	_, err = GenerateFromSpec(spec, WithTheme("sepia"))
+	assert.Equal(t, errUnknownTheme, err)
+}
+
+func TestRegisterTheme(t *testing.T) {
+	t.Cleanup(func() {
+		themesMu.Lock()
+		defer themesMu.Unlock()
+		delete(themes, "brand")
+	})
+	brand := color.RGBA{0x12, 0x34, 0x56, 0xff}
+	theme := Theme{Backgrounds: []color.Color{brand}, Colors: []color.Color{brand}, Layers: []string{"clothes", "hair"}}
+	assert.NoError(t, RegisterTheme("brand", theme))
+	assert.Equal(t, errThemeExists, RegisterTheme("brand", theme))
+	assert.Equal(t, errThemeExists, RegisterTheme("dark", theme))
+	assert.Equal(t, errInvalidTheme, RegisterTheme("Brand", theme))
+	assert.Equal(t, errInvalidTheme, RegisterTheme("other", Theme{Colors: []color.Color{nil}}))
+	assert.Equal(t, errInvalidTheme, RegisterTheme("other", Theme{Layers: []string{"Hair"}}))
+
+	// Registered themes don't change with the slices they were made of
+	theme.Backgrounds[0] = color.White
+	registered, ok := LookupTheme("brand")
+	assert.True(t, ok)
+	assert.Equal(t, []color.Color{brand}, registered.Backgrounds)
+
+	img, err := GenerateFromUsername(FEMALE, "username@site.com", WithSize(100), WithTheme("brand"))
+	assert.NoError(t, err)
+	assert.Equal(t, color.Color(brand), img.At(0, 0))
+}
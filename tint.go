@@ -0,0 +1,216 @@
+package govatar
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+var errInvalidTint = errors.New("Invalid tint")
+
+// Tint recolors the part of a layer in HSL space. Gray pixels such as
+// outlines keep their color unless Lightness is set.
+type Tint struct {
+	// Hue rotates colors by degrees
+	Hue float64
+	// Saturation scales saturation by 1+Saturation, from -1 to 1
+	Saturation float64
+	// Lightness is added to lightness, from -1 to 1
+	Lightness float64
+	// skin is the skin tone faces are remapped to first, if opaque
+	skin color.NRGBA
+	// tone is the color of a theme the part is remapped to, if opaque
+	tone color.NRGBA
+}
+
+// WithTints recolors the parts of layers, e.g. "hair" and "clothes", with
+// the colors of the scheme of the parts, see NewScheme: clothes move to its
+// Primary color and other layers to its Accent. An avatar always gets the
+// same colors, they go with its solid background and every part comes in
+// many more of them. With a theme the part moves to the color of the theme
+// closest to the scheme's. SVG output is not recolored.
+func WithTints(layers ...string) Option {
+	return func(o *options) {
+		for _, layer := range layers {
+			o.setTint(layer, nil)
+		}
+	}
+}
+
+// WithTint recolors the part of layer with t
+func WithTint(layer string, t Tint) Option {
+	return func(o *options) {
+		if t.Saturation < -1 || t.Saturation > 1 || t.Lightness < -1 || t.Lightness > 1 || math.IsNaN(t.Hue) || math.IsInf(t.Hue, 0) {
+			o.err = errInvalidTint
+			return
+		}
+		o.setTint(layer, &t)
+	}
+}
+
+// setTint recolors layer with t, or a tint picked from the parts if nil
+func (o *options) setTint(layer string, t *Tint) {
+	if !validLayer(layer) {
+		o.err = errInvalidLayer
+		return
+	}
+	if o.tints == nil {
+		o.tints = map[string]*Tint{}
+	}
+	o.tints[layer] = t
+}
+
+// tint returns the tint of layer of the avatar of spec, nil if o leaves it
+// as drawn
+func (o options) tint(spec Spec, layer string) *Tint {
+	t := o.layerTint(spec, layer)
+	tone := o.skinTone(spec)
+	if layer != "face" || tone == nil {
+		return t
+	}
+	var skin Tint
+	if t != nil {
+		skin = *t
+	}
+	skin.skin = color.NRGBAModel.Convert(tone).(color.NRGBA)
+	skin.skin.A = 0xff
+	return &skin
+}
+
+// layerTint returns the tint o sets for layer of the avatar of spec
+func (o options) layerTint(spec Spec, layer string) *Tint {
+	t, ok := o.tints[layer]
+	if !ok || t != nil {
+		return t
+	}
+	scheme := specScheme(spec)
+	target := scheme.Accent
+	if layer == "clothes" {
+		target = scheme.Primary
+	}
+	tone := color.NRGBA(target)
+	if len(o.tintPalette) > 0 {
+		tone = color.NRGBAModel.Convert(o.tintPalette[nearestColor(o.tintPalette, target)]).(color.NRGBA)
+		tone.A = 0xff
+	}
+	return &Tint{tone: tone}
+}
+
+// tintLayers returns the tints of layers of the avatar of spec
+func (o options) tintLayers(spec Spec, layers []string) []*Tint {
+	if len(o.tints) == 0 && len(o.skinTones) == 0 {
+		return nil
+	}
+	tints := make([]*Tint, len(layers))
+	for i, layer := range layers {
+		tints[i] = o.tint(spec, layer)
+	}
+	return tints
+}
+
+// sortedTints returns the layers of tints in order
+func sortedTints(tints map[string]*Tint) []string {
+	layers := make([]string, 0, len(tints))
+	for layer := range tints {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	return layers
+}
+
+// apply returns a copy of img recolored by t
+func (t Tint) apply(img image.Image) image.Image {
+	if t.skin.A != 0 {
+		img = remapSkin(img, t.skin)
+	}
+	if t.tone.A != 0 {
+		img = remap(img, t.tone, false)
+	}
+	if t.Hue == 0 && t.Saturation == 0 && t.Lightness == 0 && (t.skin.A != 0 || t.tone.A != 0) {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+			h, s, l := rgbToHSL(c)
+			s = math.Min(1, s*(1+t.Saturation))
+			l = math.Max(0, math.Min(1, l+t.Lightness))
+			tinted := hslToRGB(h+t.Hue, s, l)
+			tinted.A = c.A
+			dst.SetNRGBA(x, y, color.NRGBA(tinted))
+		}
+	}
+	return dst
+}
+
+// remap returns a copy of img with the pixels of the most common color of
+// img and its shades moved to tone, keeping their shading. Shades of a
+// colored part are pixels of about its hue, of a gray part grays of about
+// its lightness. With skin set, img is returned as is unless its most common
+// color is skin colored.
+func remap(img image.Image, tone color.NRGBA, skin bool) image.Image {
+	base, ok := dominantColor(img)
+	if !ok {
+		return img
+	}
+	bh, bs, bl := rgbToHSL(base)
+	gray := bs < 0.1
+	if skin && (gray || bh > 60) {
+		return img
+	}
+	th, ts, tl := rgbToHSL(tone)
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+			h, s, l := rgbToHSL(c)
+			d := math.Mod(h-bh+540, 360) - 180
+			switch {
+			case gray && s < 0.1 && math.Abs(l-bl) <= 0.25:
+				d, s = 0, ts
+			case !gray && s >= 0.1 && math.Abs(d) <= 25:
+				s = math.Max(0, math.Min(1, s*ts/bs))
+			default:
+				dst.SetNRGBA(x, y, c)
+				continue
+			}
+			l = math.Max(0, math.Min(1, l+tl-bl))
+			remapped := hslToRGB(th+d, s, l)
+			remapped.A = c.A
+			dst.SetNRGBA(x, y, color.NRGBA(remapped))
+		}
+	}
+	return dst
+}
+
+// rgbToHSL converts c to hue (degrees), saturation and lightness (0..1)
+func rgbToHSL(c color.NRGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max, min := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	d := max - min
+	if d == 0 {
+		return 0, 0, l
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
@@ -0,0 +1,75 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTintApply(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{0xff, 0, 0, 0x80})
+	src.SetNRGBA(1, 0, color.NRGBA{0x20, 0x20, 0x20, 0xff})
+
+	img := Tint{Hue: 180}.apply(src)
+	assert.Equal(t, color.NRGBA{0, 0xff, 0xff, 0x80}, img.At(0, 0))
+	// Outlines keep their color, transparent pixels stay transparent
+	assert.Equal(t, color.NRGBA{0x20, 0x20, 0x20, 0xff}, img.At(1, 0))
+	assert.Equal(t, color.NRGBA{}, img.At(2, 0))
+
+	img = Tint{Saturation: -1, Lightness: 0.5}.apply(src)
+	assert.Equal(t, color.NRGBA{0xff, 0xff, 0xff, 0x80}, img.At(0, 0))
+
+	for _, c := range []color.NRGBA{{0x12, 0x34, 0x56, 0xff}, {0xff, 0xd5, 0x4f, 0xff}, {0x81, 0xc7, 0x84, 0xff}} {
+		h, s, l := rgbToHSL(c)
+		assert.Equal(t, color.RGBA(c), hslToRGB(h, s, l))
+	}
+}
+
+func TestWithTints(t *testing.T) {
+	plain, err := GenerateFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	tinted, err := GenerateFromUsername(MALE, "username@site.com", WithTints("hair", "clothes"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain, tinted)
+	again, err := GenerateFromUsername(MALE, "username@site.com", WithTints("clothes", "hair"))
+	assert.NoError(t, err)
+	assert.Equal(t, tinted, again)
+
+	// Only the tinted layers change
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	bare, err := GenerateFromSpec(spec, WithoutLayers("hair", "clothes"))
+	assert.NoError(t, err)
+	bareTinted, err := GenerateFromSpec(spec, WithoutLayers("hair", "clothes"), WithTints("hair", "clothes"))
+	assert.NoError(t, err)
+	assert.Equal(t, bare, bareTinted)
+
+	// Explicit tints override picked ones
+	o, err := std().options([]Option{WithTints("hair"), WithTint("hair", Tint{Hue: 90})})
+	assert.NoError(t, err)
+	assert.Equal(t, &Tint{Hue: 90}, o.tint(spec, "hair"))
+	assert.Nil(t, o.tint(spec, "clothes"))
+
+	_, err = GenerateFromSpec(spec, WithTint("hair", Tint{Lightness: 2}))
+	assert.Equal(t, errInvalidTint, err)
+	_, err = GenerateFromSpec(spec, WithTints("Hair"))
+	assert.Equal(t, errInvalidLayer, err)
+
+	// The editor draws the same colors
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	e, err := NewEditor(d, WithTints("hair"))
+	assert.NoError(t, err)
+	img, err := e.Set(HAIR, (spec.Hair+1)%len(std().store.Male.assets("hair")))
+	assert.NoError(t, err)
+	spec.Hair = (spec.Hair + 1) % len(std().store.Male.assets("hair"))
+	expected, err := GenerateFromSpec(spec, WithTints("hair"))
+	assert.NoError(t, err)
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), expected, image.Point{}, draw.Src)
+	assert.Equal(t, rgba, img)
+}
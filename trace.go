@@ -0,0 +1,46 @@
+package govatar
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records spans of generation steps. Spans are dropped unless the
+// application installs an OpenTelemetry tracer provider.
+var tracer = otel.Tracer("github.com/recoilme/govatar")
+
+// WithContext makes spans recorded by the call children of the span in ctx.
+// Once ctx is done the call stops between layers or before encoding and
+// returns the error of ctx.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// startSpan starts span name as a child of the context of o
+func (o options) startSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracer.Start(o.context(), name, trace.WithAttributes(attrs...))
+	return span
+}
+
+// context returns the context of o, the background context if unset
+func (o options) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// endSpan ends span, marking it failed with err if not nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
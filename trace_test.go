@@ -0,0 +1,53 @@
+package govatar
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	_, err := GenerateBytesFromUsername(MALE, "username@site.com", "png", WithContext(ctx), WithSize(32))
+	assert.NoError(t, err)
+	_, err = GenerateFromUsername(MALE, "username@site.com", WithContext(ctx), WithSize(32), WithFilter(NEAREST+1))
+	assert.Error(t, err)
+	parent.End()
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		if span.Name() != "request" {
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+		}
+	}
+	assert.Equal(t, []string{"govatar.Hash", "govatar.Compose", "govatar.Encode", "request"}, names)
+}
+
+func TestContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GenerateBytesFromUsername(MALE, "username", "png", WithContext(ctx))
+	assert.Equal(t, context.Canceled, err)
+	_, err = GenerateBytesFromUsername(MALE, "username", "png", WithContext(ctx), WithRenderer(IdenticonRenderer))
+	assert.Equal(t, context.Canceled, err)
+	_, err = GenerateSVGFromUsername(MALE, "username", WithContext(ctx))
+	assert.Equal(t, context.Canceled, err)
+
+	img, err := GenerateFromUsername(MALE, "username")
+	assert.NoError(t, err)
+	assert.Equal(t, context.Canceled, std().encode(io.Discard, img, "png", []Option{WithContext(ctx)}))
+	_, err = std().store.render(ctx, []string{"background"}, nil, assetSize, CatmullRom)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,70 @@
+package govatar
+
+import "path/filepath"
+
+// Traits maps the layers of an avatar to its parts and how rare they are,
+// e.g. to show and compare avatars in games. Encoded as JSON it reads
+// {"hair": {"part": "hair3.png", "weight": 1, "rarity": 0.027}, ...}.
+type Traits map[string]Trait
+
+// Trait is the part of an avatar in a layer
+type Trait struct {
+	// Part is the file name of the part, empty for avatars without a part
+	// of an accessory layer
+	Part string `json:"part"`
+	// Weight of the part in random avatars, see Manifest.Weights
+	Weight float64 `json:"weight"`
+	// Rarity is the share of random avatars with the part from 0 to 1.
+	// Rules of the asset pack are not taken into account.
+	Rarity float64 `json:"rarity"`
+}
+
+// WithTraits stores the traits of the generated avatar in t
+func WithTraits(t *Traits) Option {
+	return func(o *options) {
+		o.traits = t
+	}
+}
+
+// DescribeTraits returns the traits of the avatar of spec
+func DescribeTraits(spec Spec) (Traits, error) {
+	return std().DescribeTraits(spec)
+}
+
+// DescribeTraits returns the traits of the avatar of spec drawn by g
+func (g *Generator) DescribeTraits(spec Spec) (Traits, error) {
+	layers, assets, err := g.store.specLayout(spec)
+	if err != nil {
+		return nil, err
+	}
+	p, _ := g.store.person(spec.Gender)
+	traits := make(Traits, len(layers))
+	for i, layer := range layers {
+		index, _ := spec.part(layer)
+		weights, n := p.weights[layer], len(p.layers[layer])
+		if layer == "background" {
+			weights, n = g.store.backgroundWeights, len(g.store.Background)
+		}
+		weight, total := partWeight(weights, n, index)
+		traits[layer] = Trait{Part: filepath.Base(assets[i]), Weight: weight, Rarity: weight / total * (1 - g.store.none(p, layer))}
+	}
+	for _, layer := range p.extras {
+		if _, ok := traits[layer]; !ok && !(p.shared[layer] && !g.store.accessories) {
+			traits[layer] = Trait{Rarity: g.store.none(p, layer)}
+		}
+	}
+	return traits, nil
+}
+
+// partWeight returns the weight of part i of a layer with n parts weighted
+// by weights and the total weight of the layer
+func partWeight(weights []float64, n, i int) (weight, total float64) {
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		// Parts are equally likely, see weightedIndex
+		return 1, float64(n)
+	}
+	return weights[i], total
+}
@@ -0,0 +1,55 @@
+package govatar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraits(t *testing.T) {
+	var traits Traits
+	_, err := GenerateFromUsername(MALE, "username@site.com", WithTraits(&traits))
+	assert.NoError(t, err)
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	d, err := Describe(spec)
+	assert.NoError(t, err)
+	assert.Len(t, traits, len(d.Layers))
+	for _, l := range d.Layers {
+		assert.Equal(t, l.File, traits[l.Name].Part)
+		assert.Equal(t, 1.0, traits[l.Name].Weight)
+	}
+	assert.InDelta(t, 1/float64(len(std().store.Male.assets("hair"))), traits["hair"].Rarity, 1e-9)
+
+	// Cached avatars have traits too
+	var cached Traits
+	assert.NoError(t, GenerateToFromUsername(ioutil.Discard, MALE, "username@site.com", "png", WithCache(NewMemoryCache(1)), WithTraits(&cached)))
+	assert.Equal(t, traits, cached)
+
+	data, err := json.Marshal(traits)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"hair":{"part":"`+traits["hair"].Part+`","weight":1,"rarity":`)
+}
+
+func TestWeightedTraits(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys["hair/hair2.png"] = fsys["hat/hat1.png"]
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"optional": {"hat": 0.5}, "weights": {"hair": {"hair2.png": 3}}}`)}
+	assert.NoError(t, Register("rare", fsys))
+	gender, _ := LookupStyle("rare")
+
+	traits, err := DescribeTraits(NewBuilder(gender).Hair(1).Accessory("hat", 0).Spec())
+	assert.NoError(t, err)
+	assert.Equal(t, Trait{Part: "hair2.png", Weight: 3, Rarity: 0.75}, traits["hair"])
+	assert.Equal(t, Trait{Part: "hat1.png", Weight: 1, Rarity: 0.25}, traits["hat"])
+	assert.Equal(t, Trait{Rarity: accessoryNone["glasses"]}, traits["glasses"])
+
+	traits, err = DescribeTraits(NewBuilder(gender).Spec())
+	assert.NoError(t, err)
+	assert.Equal(t, Trait{Part: "hair1.png", Weight: 1, Rarity: 0.25}, traits["hair"])
+	assert.Equal(t, Trait{Rarity: 0.5}, traits["hat"])
+}
@@ -1,11 +1,19 @@
 package govatar
 
 import (
+	"hash/fnv"
 	"math/rand"
 	"regexp"
 	"strings"
 )
 
+// usernameSeed returns random seed derived from username
+func usernameSeed(username string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(username))
+	return int64(h.Sum32())
+}
+
 // randInt returns random integer
 func randInt(rnd *rand.Rand, min int, max int) int {
 	return min + rnd.Intn(max-min)
@@ -13,7 +21,12 @@ func randInt(rnd *rand.Rand, min int, max int) int {
 
 // randSliceString returns random element from slice of string
 func randSliceString(rnd *rand.Rand, slice []string) string {
-	return slice[randInt(rnd, 0, len(slice))]
+	return slice[randIndex(rnd, slice)]
+}
+
+// randIndex returns random index of slice of string
+func randIndex(rnd *rand.Rand, slice []string) int {
+	return randInt(rnd, 0, len(slice))
 }
 
 type naturalSort []string
@@ -0,0 +1,73 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/jpeg"
+	"strings"
+)
+
+// VCardPhotoSize is width and height of vCard photos. Address books keep
+// contact photos small and many refuse large embedded images.
+const VCardPhotoSize = 256
+
+// vCardLineLength is the maximum line length in octets before folding
+const vCardLineLength = 75
+
+// VCardPhoto returns the avatar of username as a folded vCard 3.0 PHOTO
+// property holding a base64 encoded JPEG
+func VCardPhoto(gender Gender, username string) (string, error) {
+	avatar, err := GenerateFromUsername(gender, username)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, resize(avatar, VCardPhotoSize), &jpeg.Options{Quality: std().config.JPEGQuality}); err != nil {
+		return "", err
+	}
+	return foldVCardLine("PHOTO;ENCODING=b;TYPE=JPEG:" + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// VCard returns a vCard 3.0 for fullName with the avatar of username as photo
+func VCard(gender Gender, username, fullName string) (string, error) {
+	photo, err := VCardPhoto(gender, username)
+	if err != nil {
+		return "", err
+	}
+	name := escapeVCardText(fullName)
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		foldVCardLine("FN:" + name),
+		foldVCardLine("N:;" + name + ";;;"),
+		photo,
+		"END:VCARD",
+	}
+	return strings.Join(lines, "\r\n") + "\r\n", nil
+}
+
+// foldVCardLine splits line into CRLF separated lines of at most
+// vCardLineLength octets, continuation lines start with a space
+func foldVCardLine(line string) string {
+	var b strings.Builder
+	limit := vCardLineLength
+	for len(line) > limit {
+		// Don't split multi byte characters
+		cut := limit
+		for cut > 0 && line[cut]&0xc0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = vCardLineLength - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+var vCardTextEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+func escapeVCardText(s string) string {
+	return vCardTextEscaper.Replace(s)
+}
@@ -0,0 +1,50 @@
+package govatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/jpeg"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVCard(t *testing.T) {
+	card, err := VCard(MALE, "username@site.com", "Doe; John")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(card, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Doe\\; John\r\n"))
+	assert.True(t, strings.HasSuffix(card, "\r\nEND:VCARD\r\n"))
+
+	for _, line := range strings.Split(card, "\r\n") {
+		assert.True(t, len(line) <= vCardLineLength, line)
+	}
+
+	// Unfold and decode the photo
+	unfolded := strings.Replace(card, "\r\n ", "", -1)
+	var photo string
+	for _, line := range strings.Split(unfolded, "\r\n") {
+		if strings.HasPrefix(line, "PHOTO;ENCODING=b;TYPE=JPEG:") {
+			photo = strings.TrimPrefix(line, "PHOTO;ENCODING=b;TYPE=JPEG:")
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(photo)
+	assert.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, VCardPhotoSize, img.Bounds().Dx())
+
+	_, err = VCard(Gender(-1), "username@site.com", "John")
+	assert.Equal(t, errUnknownGender, err)
+}
+
+func TestFoldVCardLine(t *testing.T) {
+	assert.Equal(t, "FN:John", foldVCardLine("FN:John"))
+
+	line := "FN:" + strings.Repeat("ж", 60)
+	folded := foldVCardLine(line)
+	for _, l := range strings.Split(folded, "\r\n") {
+		assert.True(t, len(l) <= vCardLineLength)
+	}
+	assert.Equal(t, line, strings.Replace(folded, "\r\n ", "", -1))
+}
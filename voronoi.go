@@ -0,0 +1,64 @@
+package govatar
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+)
+
+// voronoiCells is the number of cells Voronoi splits an avatar into
+const voronoiCells = 150
+
+// Voronoi renders img as a mosaic of Voronoi cells around sites sampled with
+// seed, each filled with the average color of its pixels. The result keeps
+// the overall look of the avatar while hiding its details. Empty images
+// are returned as empty images.
+func Voronoi(img image.Image, seed int64) image.Image {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return image.NewNRGBA(bounds)
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	sites := make([]image.Point, voronoiCells)
+	for i := range sites {
+		sites[i] = image.Pt(bounds.Min.X+rnd.Intn(bounds.Dx()), bounds.Min.Y+rnd.Intn(bounds.Dy()))
+	}
+
+	cells := make([]int, bounds.Dx()*bounds.Dy())
+	sums := make([][5]uint64, len(sites))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			nearest, best := 0, -1
+			for i, s := range sites {
+				dx, dy := s.X-x, s.Y-y
+				if d := dx*dx + dy*dy; best < 0 || d < best {
+					nearest, best = i, d
+				}
+			}
+			cells[(y-bounds.Min.Y)*bounds.Dx()+x-bounds.Min.X] = nearest
+
+			c := color.RGBA64Model.Convert(img.At(x, y)).(color.RGBA64)
+			sum := &sums[nearest]
+			sum[0] += uint64(c.R)
+			sum[1] += uint64(c.G)
+			sum[2] += uint64(c.B)
+			sum[3] += uint64(c.A)
+			sum[4]++
+		}
+	}
+
+	colors := make([]color.RGBA64, len(sites))
+	for i, sum := range sums {
+		if n := sum[4]; n > 0 {
+			colors[i] = color.RGBA64{uint16(sum[0] / n), uint16(sum[1] / n), uint16(sum[2] / n), uint16(sum[3] / n)}
+		}
+	}
+
+	dst := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dst.Set(x, y, colors[cells[(y-bounds.Min.Y)*bounds.Dx()+x-bounds.Min.X]])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,26 @@
+package govatar
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoronoi(t *testing.T) {
+	avatar, err := GenerateFromUsername(FEMALE, "username@site.com")
+	assert.NoError(t, err)
+
+	img := Voronoi(avatar, 42)
+	assert.Equal(t, avatar.Bounds(), img.Bounds())
+	assert.True(t, len(distinctOpaqueColors(img)) <= voronoiCells)
+	assert.True(t, areImagesEquals(img, Voronoi(avatar, 42)))
+	assert.False(t, areImagesEquals(img, Voronoi(avatar, 43)))
+}
+
+func TestVoronoiEmpty(t *testing.T) {
+	for _, r := range []image.Rectangle{{}, image.Rect(0, 0, 0, 10), image.Rect(5, 5, 15, 5)} {
+		img := Voronoi(image.NewNRGBA(r), 42)
+		assert.True(t, img.Bounds().Empty(), "%v", r)
+	}
+}
@@ -0,0 +1,84 @@
+package govatar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"math/rand"
+)
+
+// watermarkTagSize is the number of HMAC bytes stored with the issuer
+const watermarkTagSize = 8
+
+var (
+	errIssuerTooLong      = errors.New("Issuer longer than 255 bytes")
+	errWatermarkDoesntFit = errors.New("Image too small to watermark")
+)
+
+// Watermark returns a copy of img carrying an invisible mark identifying
+// issuer (server, tenant). The mark is spread over pixels chosen by key and
+// can only be found and verified with the same key. It survives lossless
+// formats (png) only and leaves the first row free for EmbedSpec. Issuers
+// are up to 255 bytes, a 16x16 image holds one of 81 bytes.
+func Watermark(img image.Image, key []byte, issuer string) (image.Image, error) {
+	if len(issuer) > 255 {
+		return nil, errIssuerTooLong
+	}
+	data := append([]byte{byte(len(issuer))}, issuer...)
+	data = append(data, watermarkTag(key, issuer)...)
+	pixels := watermarkPixels(img.Bounds(), key, len(data))
+	if len(pixels)*3 < len(data)*8 {
+		return nil, errWatermarkDoesntFit
+	}
+
+	dst := image.NewNRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	writeLowBits(dst, pixels, data)
+	return dst, nil
+}
+
+// VerifyWatermark returns the issuer stored in img by Watermark with key.
+// ok is false if img doesn't carry a valid mark made with key.
+func VerifyWatermark(img image.Image, key []byte) (issuer string, ok bool) {
+	pixels := watermarkPixels(img.Bounds(), key, 1+255+watermarkTagSize)
+	n := int(readLowBits(img, pixels, 1)[0])
+	data := readLowBits(img, pixels, 1+n+watermarkTagSize)
+	issuer = string(data[1 : 1+n])
+	if !hmac.Equal(data[1+n:], watermarkTag(key, issuer)) {
+		return "", false
+	}
+	return issuer, true
+}
+
+func watermarkTag(key []byte, issuer string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(issuer))
+	return mac.Sum(nil)[:watermarkTagSize]
+}
+
+// watermarkPixels returns pixels able to hold n bytes, picked pseudo-randomly
+// from key outside of the first row
+func watermarkPixels(bounds image.Rectangle, key []byte, n int) []image.Point {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("govatar watermark"))
+	seed := int64(binary.BigEndian.Uint64(mac.Sum(nil)))
+	rnd := rand.New(rand.NewSource(seed))
+
+	w, h := bounds.Dx(), bounds.Dy()-1
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	perm := rnd.Perm(w * h)
+	count := (n*8 + 2) / 3
+	if count > len(perm) {
+		count = len(perm)
+	}
+	pixels := make([]image.Point, count)
+	for i := range pixels {
+		pixels[i] = image.Pt(bounds.Min.X+perm[i]%w, bounds.Min.Y+1+perm[i]/w)
+	}
+	return pixels
+}
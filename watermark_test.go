@@ -0,0 +1,56 @@
+package govatar
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatermark(t *testing.T) {
+	spec, err := SpecFromUsername(MALE, "username@site.com")
+	assert.NoError(t, err)
+	avatar, err := GenerateFromSpec(spec)
+	assert.NoError(t, err)
+
+	key := []byte("secret")
+	embedded, err := EmbedSpec(avatar, spec)
+	assert.NoError(t, err)
+	img, err := Watermark(embedded, key, "avatars.site.com")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	decoded, _, err := image.Decode(&buf)
+	assert.NoError(t, err)
+
+	issuer, ok := VerifyWatermark(decoded, key)
+	assert.True(t, ok)
+	assert.Equal(t, "avatars.site.com", issuer)
+
+	// Watermark doesn't destroy the embedded spec
+	recovered, err := RecoverSpec(decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, spec, recovered)
+
+	_, ok = VerifyWatermark(decoded, []byte("other secret"))
+	assert.False(t, ok)
+
+	_, ok = VerifyWatermark(avatar, key)
+	assert.False(t, ok)
+
+	// Marks are never truncated
+	small := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	img, err = Watermark(small, key, strings.Repeat("a", 81))
+	assert.NoError(t, err)
+	issuer, ok = VerifyWatermark(img, key)
+	assert.True(t, ok)
+	assert.Equal(t, strings.Repeat("a", 81), issuer)
+	_, err = Watermark(small, key, strings.Repeat("a", 82))
+	assert.Equal(t, errWatermarkDoesntFit, err)
+	_, err = Watermark(avatar, key, strings.Repeat("a", 256))
+	assert.Equal(t, errIssuerTooLong, err)
+}
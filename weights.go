@@ -0,0 +1,135 @@
+package govatar
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// loadWeights returns the weights of assets by file name, 1 for assets
+// byName leaves out, or nil if all weights are 1. Negative weights are 0.
+func loadWeights(assets []string, byName map[string]float64) []float64 {
+	if len(byName) == 0 {
+		return nil
+	}
+	weights := make([]float64, len(assets))
+	uniform := true
+	for i, asset := range assets {
+		weights[i] = 1
+		if w, ok := byName[filepath.Base(asset)]; ok {
+			weights[i] = w
+			if w < 0 {
+				weights[i] = 0
+			}
+		}
+		uniform = uniform && weights[i] == 1
+	}
+	if uniform {
+		return nil
+	}
+	return weights
+}
+
+// weightedIndex returns a random index below n drawn from rnd, indices
+// with higher weights more likely. Without weights, or if all of them are
+// 0, every index is equally likely.
+func weightedIndex(rnd *rand.Rand, n int, weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return randInt(rnd, 0, n)
+	}
+	x := rnd.Float64() * total
+	last := 0
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		if x < w {
+			return i
+		}
+		x -= w
+		last = i
+	}
+	// Rounding left x just above the total
+	return last
+}
+
+// randomPart returns a random index of the parts of layer drawn from rnd
+func (p person) randomPart(rnd *rand.Rand, layer string) int {
+	return weightedIndex(rnd, len(p.layers[layer]), p.weights[layer])
+}
+
+// mixWeights returns the weights of a layer with the n parts of one person
+// weighted by a followed by the m parts of another weighted by b
+func mixWeights(a []float64, n int, b []float64, m int) []float64 {
+	if a == nil && b == nil {
+		return nil
+	}
+	mixed := make([]float64, 0, n+m)
+	for _, w := range []struct {
+		weights []float64
+		count   int
+	}{{a, n}, {b, m}} {
+		if w.weights != nil {
+			mixed = append(mixed, w.weights...)
+			continue
+		}
+		for i := 0; i < w.count; i++ {
+			mixed = append(mixed, 1)
+		}
+	}
+	return mixed
+}
+
+// checkWeights lists problems of the weights of m: weights of unknown
+// directories or parts, negative weights and layers whose parts all weigh 0
+func checkWeights(m *Manifest) (problems []string) {
+	var dirs []string
+	for dir := range m.Weights {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		parts := m.Background
+		if dir != "background" {
+			gender, layer := path.Split(dir)
+			parts = m.Genders[strings.TrimSuffix(gender, "/")][layer]
+		}
+		if parts == nil {
+			problems = append(problems, fmt.Sprintf("%s: weights of unknown directory %s", ManifestFile, filepath.FromSlash(dir)))
+			continue
+		}
+		found := map[string]bool{}
+		for _, name := range parts {
+			found[name] = true
+		}
+		var names []string
+		for name := range m.Weights[dir] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			part := filepath.Join(filepath.FromSlash(dir), name)
+			if !found[name] {
+				problems = append(problems, fmt.Sprintf("%s: weight of unknown part %s", ManifestFile, part))
+			} else if m.Weights[dir][name] < 0 {
+				problems = append(problems, fmt.Sprintf("%s: negative weight of %s", ManifestFile, part))
+			}
+		}
+		weights := loadWeights(parts, m.Weights[dir])
+		var total float64
+		for _, w := range weights {
+			total += w
+		}
+		if weights != nil && total <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: all parts weigh 0", filepath.FromSlash(dir)))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,122 @@
+package govatar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedIndex(t *testing.T) {
+	// Without weights the same parts are picked as before
+	a, b := rand.New(rand.NewSource(1)), rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, b.Intn(7), weightedIndex(a, 7, nil))
+	}
+
+	counts := make([]int, 3)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		counts[weightedIndex(rnd, 3, []float64{1, 0, 3})]++
+	}
+	assert.Equal(t, 0, counts[1])
+	assert.InDelta(t, 250, counts[0], 50)
+	assert.InDelta(t, 750, counts[2], 50)
+
+	assert.Nil(t, loadWeights([]string{"a/hair1.png"}, map[string]float64{"hair1.png": 1}))
+	assert.Equal(t, []float64{1, 0}, loadWeights([]string{"a/hair1.png", "a/hair2.png"}, map[string]float64{"hair2.png": -1}))
+}
+
+func TestManifestWeights(t *testing.T) {
+	withStyles(t)
+	fsys := accessorizedFS(t)
+	fsys["hair/hair2.png"] = fsys["hat/hat1.png"]
+	fsys[ManifestFile] = &fstest.MapFile{Data: []byte(`{"optional": {"hat": 0}, "weights": {"hat": {"hat1.png": 0}, "hair": {"hair2.png": 9}}}`)}
+	assert.NoError(t, Register("weighted", fsys))
+	gender, _ := LookupStyle("weighted")
+
+	rare := 0
+	for i := 0; i < 200; i++ {
+		username := "user" + strconv.Itoa(i)
+		spec, err := SpecFromUsername(gender, username)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, spec.Accessories["hat"])
+		if spec.Hair == 0 {
+			rare++
+		}
+		again, err := SpecFromUsername(gender, username)
+		assert.NoError(t, err)
+		assert.Equal(t, spec, again)
+	}
+	assert.InDelta(t, 20, rare, 12)
+
+	// Parts of weight 0 are still drawn when asked for
+	_, err := NewBuilder(gender).Accessory("hat", 0).Render()
+	assert.NoError(t, err)
+}
+
+func TestPackWeights(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+	m, err := BuildPack(dir)
+	assert.NoError(t, err)
+
+	m.Weights = map[string]map[string]float64{"background": {"background1.png": 2}, "male/hair": {"hair1.png": 0.5}}
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	m, err = BuildPack(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, m.Weights["male/hair"]["hair1.png"])
+
+	m.Weights = map[string]map[string]float64{"male/hair": {"hair1.png": 0, "hair9.png": 1}, "female/eye": {"eye1.png": -1}, "hair": {"hair1.png": 1}}
+	data, err = json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+	_, err = BuildPack(dir)
+	problems, ok := err.(PackProblems)
+	assert.True(t, ok)
+	assert.Len(t, problems, 5)
+	assert.Contains(t, err.Error(), "negative weight of "+filepath.Join("female", "eye", "eye1.png"))
+	assert.Contains(t, err.Error(), filepath.Join("female", "eye")+": all parts weigh 0")
+	assert.Contains(t, err.Error(), filepath.Join("male", "hair")+": all parts weigh 0")
+	assert.Contains(t, err.Error(), "weight of unknown part "+filepath.Join("male", "hair", "hair9.png"))
+	assert.Contains(t, err.Error(), "weights of unknown directory hair")
+}
+
+func TestGenderWeights(t *testing.T) {
+	dir := newTestPack(t)
+	defer os.RemoveAll(dir)
+	for _, g := range []Gender{MALE, FEMALE} {
+		writeTestImage(t, filepath.Join(dir, genderName(g), "hair", "hair2.png"), 400)
+	}
+	m, err := BuildPack(dir)
+	assert.NoError(t, err)
+	m.Weights = map[string]map[string]float64{"male/hair": {"hair1.png": 0}}
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0644))
+
+	c := DefaultConfig()
+	c.AssetsPath = dir
+	g, err := New(c)
+	assert.NoError(t, err)
+	female := 0
+	for i := 0; i < 100; i++ {
+		spec, err := g.SpecFromUsername(MALE, "user"+strconv.Itoa(i))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, spec.Hair)
+		if spec, err = g.SpecFromUsername(FEMALE, "user"+strconv.Itoa(i)); spec.Hair == 0 {
+			female++
+		}
+		assert.NoError(t, err)
+	}
+	// Weights of male hair leave female hair alone
+	assert.InDelta(t, 50, female, 20)
+}